@@ -0,0 +1,418 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/xackery/quail/helper"
+	"github.com/xackery/quail/quail"
+	"github.com/xackery/quail/wce"
+)
+
+func init() {
+	rootCmd.AddCommand(animCmd)
+	animCmd.AddCommand(animCopyCmd)
+	animCopyCmd.PersistentFlags().String("from", "", "model to copy animations from, like ELF")
+	animCopyCmd.PersistentFlags().String("to", "", "model to copy animations to, like XEL")
+	animCopyCmd.PersistentFlags().StringSlice("anims", nil, "animation codes to copy, e.g. --anims C01,L01, defaults to every animation")
+	animCopyCmd.PersistentFlags().String("bonemap", "", "text file of from=to bone names, one per line")
+	animCopyCmd.PersistentFlags().String("src", "", "s3d or eqg the --from model is in, defaults to the target file")
+	animCopyCmd.PersistentFlags().String("out", "", "file to write, defaults to overwriting the target")
+	animCmd.AddCommand(animAuditCmd)
+	animAuditCmd.PersistentFlags().String("path", "", "path to s3d or eqg")
+	animAuditCmd.PersistentFlags().String("set", wce.AnimSetPlayable, "animations models need, playable or npc")
+	animAuditCmd.PersistentFlags().Bool("json", false, "output as json")
+	animCmd.AddCommand(animRetimeCmd)
+	animRetimeCmd.PersistentFlags().String("model", "", "model whose animations are retimed, like ELF")
+	animRetimeCmd.PersistentFlags().StringSlice("anims", nil, "animations to retime, e.g. --anims C01,L01, defaults to every animation")
+	animRetimeCmd.PersistentFlags().Float32("speed", 1, "playback speed, 2 plays twice as fast")
+	animRetimeCmd.PersistentFlags().Uint32("interval", 0, "milliseconds between resampled frames")
+	animRetimeCmd.PersistentFlags().String("trim", "", "start:end milliseconds to keep, e.g. --trim 200:1200 or --trim 200:")
+	animRetimeCmd.PersistentFlags().String("reverse", "", "start:end milliseconds to play backwards after trimming, e.g. --reverse 0:")
+	animRetimeCmd.PersistentFlags().String("out", "", "file to write, defaults to overwriting the source")
+}
+
+// animCmd represents the anim command
+var animCmd = &cobra.Command{
+	Use:   "anim",
+	Short: "Character animation tools",
+	Long: `Character animation tools for s3d tracks and eqg .ani files
+Example: quail anim copy global_chr.s3d --from ELF --to XEL --anims C01,L01
+Example: quail anim audit global_chr.s3d
+Example: quail anim retime global_chr.s3d --model ELF --anims L01 --speed 1.5`,
+}
+
+// animCopyCmd represents the anim copy command
+var animCopyCmd = &cobra.Command{
+	Use:   "copy",
+	Short: "Copy animations from one character model to another",
+	Long: `Copy animations of the --from model onto the --to model of a s3d or eqg
+s3d animations are the TRACKINSTANCEs named by animation code and dag track, like C01ELFHE_TRACK
+eqg animations are the .ani files named by model and animation code, like elf_c01.ani
+Bones are renamed through --bonemap, where each line is from=to like HE=HEAD, and bones the target lacks are skipped
+Translations are rescaled by how long the target bone is compared to the source bone
+Animations the target already has are replaced
+Usage: quail anim copy <file> --from <model> --to <model>
+Example: quail anim copy global_chr.s3d --from ELF --to XEL --anims C01,L01
+Example: quail anim copy xel_chr.s3d --src global_chr.s3d --from ELF --to XEL --bonemap elf_xel.txt
+Example: quail anim copy dra.eqg --from dra --to drb --out drb.eqg`,
+	Run: runAnimCopy,
+}
+
+// animAuditCmd represents the anim audit command
+var animAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Report which standard animations each character model has",
+	Long: `Check every character model of a s3d or eqg against a standard animation set
+s3d models are checked for animation codes like C01 and L01, eqg models for .ani names like stnd and walk
+eqg models converted from s3d keep their codes, like hum_c01.ani, and are checked for codes like C01
+An animation is mismatched when it does not animate every bone of the model, or its bones have different frame counts
+--set playable checks every standard animation, --set npc only the few every npc needs
+Example: quail anim audit global_chr.s3d
+Example: quail anim audit exo.eqg --set npc --json`,
+	Run: runAnimAudit,
+}
+
+// animRetimeCmd represents the anim retime command
+var animRetimeCmd = &cobra.Command{
+	Use:   "retime",
+	Short: "Trim, reverse, speed up or resample animations",
+	Long: `Change the timing of animations of a model without re-authoring them
+Frames are first trimmed to --trim, then --reverse plays a range backwards, then --speed scales every frame time
+--interval resamples frames at a fixed rate, with rotations slerped and translations and scales lerped
+s3d tracks play frames at a fixed sleep, so they are always resampled, by default at the sped up sleep
+Times are milliseconds from the first frame, and a range without an end runs to the last frame
+Usage: quail anim retime <file> --model <model>
+Example: quail anim retime global_chr.s3d --model ELF --anims L01 --speed 1.5
+Example: quail anim retime global_chr.s3d --model ELF --anims C01 --trim 100:900 --interval 50
+Example: quail anim retime exo.eqg --model exo --anims stnd --reverse 0: --out exo_edit.eqg`,
+	Run: runAnimRetime,
+}
+
+func runAnimCopy(cmd *cobra.Command, args []string) {
+	err := runAnimCopyE(cmd, args)
+	if err != nil {
+		fmt.Printf("Failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func runAnimCopyE(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return cmd.Usage()
+	}
+	dstPath := args[0]
+
+	opt := &wce.AnimCopyOption{}
+	var err error
+	opt.From, err = cmd.Flags().GetString("from")
+	if err != nil {
+		return fmt.Errorf("parse from: %w", err)
+	}
+	opt.To, err = cmd.Flags().GetString("to")
+	if err != nil {
+		return fmt.Errorf("parse to: %w", err)
+	}
+	if opt.From == "" || opt.To == "" {
+		return fmt.Errorf("--from and --to are required")
+	}
+	opt.Animations, err = cmd.Flags().GetStringSlice("anims")
+	if err != nil {
+		return fmt.Errorf("parse anims: %w", err)
+	}
+	boneMapPath, err := cmd.Flags().GetString("bonemap")
+	if err != nil {
+		return fmt.Errorf("parse bonemap: %w", err)
+	}
+	if boneMapPath != "" {
+		opt.BoneMap, err = animBoneMapRead(boneMapPath)
+		if err != nil {
+			return fmt.Errorf("bonemap: %w", err)
+		}
+	}
+	srcPath, err := cmd.Flags().GetString("src")
+	if err != nil {
+		return fmt.Errorf("parse src: %w", err)
+	}
+	outPath, err := cmd.Flags().GetString("out")
+	if err != nil {
+		return fmt.Errorf("parse out: %w", err)
+	}
+	if outPath == "" {
+		outPath = dstPath
+	}
+
+	q := quail.New()
+	err = q.PfsRead(dstPath)
+	if err != nil {
+		return fmt.Errorf("pfs read: %w", err)
+	}
+	if q.Wld == nil {
+		return fmt.Errorf("%s has no models", filepath.Base(dstPath))
+	}
+	src := q.Wld
+	if srcPath != "" {
+		srcQ := quail.New()
+		err = srcQ.PfsRead(srcPath)
+		if err != nil {
+			return fmt.Errorf("pfs read %s: %w", srcPath, err)
+		}
+		if srcQ.Wld == nil {
+			return fmt.Errorf("%s has no models", filepath.Base(srcPath))
+		}
+		src = srcQ.Wld
+	}
+
+	copied, err := q.Wld.AnimCopy(src, opt)
+	if err != nil {
+		return fmt.Errorf("anim copy: %w", err)
+	}
+	if len(copied) == 0 {
+		return fmt.Errorf("no animations of %s found to copy to %s", opt.From, opt.To)
+	}
+
+	err = q.PfsWrite(1, 1, outPath)
+	if err != nil {
+		return fmt.Errorf("pfs write: %w", err)
+	}
+	fmt.Printf("Copied %s from %s to %s in %s\n", strings.Join(copied, ","), opt.From, opt.To, filepath.Base(outPath))
+	return nil
+}
+
+// animBoneMapRead reads a bone map of from=to lines, skipping blank lines and # comments
+func animBoneMapRead(path string) (map[string]string, error) {
+	r, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	boneMap := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		from, to, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: %s is not from=to", lineNumber, line)
+		}
+		boneMap[strings.TrimSpace(from)] = strings.TrimSpace(to)
+	}
+	err = scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+	return boneMap, nil
+}
+
+func runAnimAudit(cmd *cobra.Command, args []string) {
+	err := runAnimAuditE(cmd, args)
+	if err != nil {
+		fmt.Printf("Failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func runAnimAuditE(cmd *cobra.Command, args []string) error {
+	path, err := cmd.Flags().GetString("path")
+	if err != nil {
+		return fmt.Errorf("parse path: %w", err)
+	}
+	if path == "" {
+		if len(args) < 1 {
+			return cmd.Usage()
+		}
+		path = args[0]
+	}
+	set, err := cmd.Flags().GetString("set")
+	if err != nil {
+		return fmt.Errorf("parse set: %w", err)
+	}
+	isJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return fmt.Errorf("parse json: %w", err)
+	}
+
+	q := quail.New()
+	err = q.PfsRead(path)
+	if err != nil {
+		return fmt.Errorf("pfs read: %w", err)
+	}
+	if q.Wld == nil {
+		return fmt.Errorf("%s has no models", filepath.Base(path))
+	}
+
+	audits, err := q.Wld.AnimAudit(set)
+	if err != nil {
+		return fmt.Errorf("anim audit: %w", err)
+	}
+
+	if isJSON {
+		return animAuditWriteJSON(os.Stdout, filepath.Base(path), set, audits)
+	}
+	return animAuditWriteText(os.Stdout, filepath.Base(path), set, audits)
+}
+
+func animAuditWriteJSON(w io.Writer, name string, set string, audits []*wce.AnimAudit) error {
+	out := struct {
+		File   string
+		Set    string
+		Models []*wce.AnimAudit
+	}{
+		File:   name,
+		Set:    set,
+		Models: audits,
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(out)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+	return nil
+}
+
+func animAuditWriteText(w io.Writer, name string, set string, audits []*wce.AnimAudit) error {
+	fmt.Fprintf(w, "%s: %d character model%s checked for %s animations\n", name, len(audits), helper.Pluralize(len(audits)), set)
+	for _, audit := range audits {
+		fmt.Fprintf(w, "  %s bones=%d present=%d missing=%d mismatched=%d\n", audit.Model, audit.Bones, len(audit.Present), len(audit.Missing), len(audit.Mismatched))
+		if len(audit.Present) > 0 {
+			fmt.Fprintf(w, "    present: %s\n", strings.Join(audit.Present, ","))
+		}
+		if len(audit.Missing) > 0 {
+			fmt.Fprintf(w, "    missing: %s\n", strings.Join(audit.Missing, ","))
+		}
+		for _, mismatch := range audit.Mismatched {
+			fmt.Fprintf(w, "    mismatched %s: %s\n", mismatch.Code, mismatch.Reason)
+		}
+	}
+	return nil
+}
+
+func runAnimRetime(cmd *cobra.Command, args []string) {
+	err := runAnimRetimeE(cmd, args)
+	if err != nil {
+		fmt.Printf("Failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func runAnimRetimeE(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return cmd.Usage()
+	}
+	srcPath := args[0]
+
+	model, err := cmd.Flags().GetString("model")
+	if err != nil {
+		return fmt.Errorf("parse model: %w", err)
+	}
+	if model == "" {
+		return fmt.Errorf("--model is required")
+	}
+	anims, err := cmd.Flags().GetStringSlice("anims")
+	if err != nil {
+		return fmt.Errorf("parse anims: %w", err)
+	}
+	opt := &wce.AnimTimingOption{}
+	opt.Speed, err = cmd.Flags().GetFloat32("speed")
+	if err != nil {
+		return fmt.Errorf("parse speed: %w", err)
+	}
+	if opt.Speed <= 0 {
+		return fmt.Errorf("speed %0.2f must be above 0", opt.Speed)
+	}
+	opt.Interval, err = cmd.Flags().GetUint32("interval")
+	if err != nil {
+		return fmt.Errorf("parse interval: %w", err)
+	}
+	trim, err := cmd.Flags().GetString("trim")
+	if err != nil {
+		return fmt.Errorf("parse trim: %w", err)
+	}
+	if trim != "" {
+		opt.TrimStart, opt.TrimEnd, err = animRangeParse(trim)
+		if err != nil {
+			return fmt.Errorf("trim: %w", err)
+		}
+	}
+	reverse, err := cmd.Flags().GetString("reverse")
+	if err != nil {
+		return fmt.Errorf("parse reverse: %w", err)
+	}
+	if reverse != "" {
+		opt.IsReverse = true
+		opt.ReverseStart, opt.ReverseEnd, err = animRangeParse(reverse)
+		if err != nil {
+			return fmt.Errorf("reverse: %w", err)
+		}
+	}
+	dstPath, err := cmd.Flags().GetString("out")
+	if err != nil {
+		return fmt.Errorf("parse out: %w", err)
+	}
+	if dstPath == "" {
+		dstPath = srcPath
+	}
+
+	q := quail.New()
+	err = q.PfsRead(srcPath)
+	if err != nil {
+		return fmt.Errorf("pfs read: %w", err)
+	}
+	if q.Wld == nil {
+		return fmt.Errorf("%s has no models", filepath.Base(srcPath))
+	}
+
+	retimed, err := q.Wld.RetimeAnimations(model, anims, opt)
+	if err != nil {
+		return fmt.Errorf("anim retime: %w", err)
+	}
+	if len(retimed) == 0 {
+		return fmt.Errorf("no animations of %s found to retime", model)
+	}
+
+	err = q.PfsWrite(1, 1, dstPath)
+	if err != nil {
+		return fmt.Errorf("pfs write: %w", err)
+	}
+	fmt.Printf("Retimed %s of %s in %s\n", strings.Join(retimed, ","), model, filepath.Base(dstPath))
+	return nil
+}
+
+// animRangeParse parses a start:end millisecond range, where a missing end is 0
+func animRangeParse(value string) (uint32, uint32, error) {
+	startValue, endValue, ok := strings.Cut(value, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("%s is not start:end", value)
+	}
+	start, end := uint64(0), uint64(0)
+	var err error
+	if startValue != "" {
+		start, err = strconv.ParseUint(startValue, 10, 32)
+		if err != nil {
+			return 0, 0, fmt.Errorf("start: %w", err)
+		}
+	}
+	if endValue != "" {
+		end, err = strconv.ParseUint(endValue, 10, 32)
+		if err != nil {
+			return 0, 0, fmt.Errorf("end: %w", err)
+		}
+		if end <= start {
+			return 0, 0, fmt.Errorf("end %d is not after start %d", end, start)
+		}
+	}
+	return uint32(start), uint32(end), nil
+}
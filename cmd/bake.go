@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/xackery/quail/quail"
+	"github.com/xackery/quail/wce"
+)
+
+func init() {
+	rootCmd.AddCommand(bakeCmd)
+	bakeCmd.PersistentFlags().Bool("shadow", false, "occlude lights by ray casting against zone geometry")
+}
+
+// bakeCmd represents the bake command
+var bakeCmd = &cobra.Command{
+	Use:   "bake",
+	Short: "Recompute baked vertex lighting of a zone",
+	Long: `Recompute zone vertex colors from the zone's point and ambient lights
+For s3d zones, a <zone>_obj.s3d next to the zone is loaded for placed objects
+For eqg zones, .lit data of every placed model is baked from the zone lights
+Usage: quail bake <src> <dst>
+Example: quail bake crushbone.s3d crushbone_baked.s3d
+Example: quail bake crushbone.s3d crushbone_baked.s3d --shadow
+Example: quail bake buriedsea.eqg buriedsea_baked.eqg`,
+	Run: runBake,
+}
+
+func runBake(cmd *cobra.Command, args []string) {
+	err := runBakeE(cmd, args)
+	if err != nil {
+		fmt.Printf("Failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func runBakeE(cmd *cobra.Command, args []string) error {
+	if len(args) < 2 {
+		return cmd.Usage()
+	}
+	srcPath := args[0]
+	dstPath := args[1]
+
+	opt := &wce.LightBakeOption{}
+	var err error
+	opt.IsShadow, err = cmd.Flags().GetBool("shadow")
+	if err != nil {
+		return fmt.Errorf("parse shadow: %w", err)
+	}
+
+	ext := strings.ToLower(filepath.Ext(srcPath))
+	if ext != ".s3d" && ext != ".eqg" {
+		return fmt.Errorf("unsupported extension %s, wanted .s3d or .eqg", ext)
+	}
+
+	q := quail.New()
+	err = q.PfsRead(srcPath)
+	if err != nil {
+		return fmt.Errorf("pfs read: %w", err)
+	}
+
+	var models *wce.Wce
+	if ext == ".s3d" {
+		models, err = zoneLoadObjects(strings.TrimSuffix(srcPath, filepath.Ext(srcPath)) + "_obj.s3d")
+		if err != nil {
+			return err
+		}
+	} else {
+		err = quailLoadSideFile(q, strings.TrimSuffix(srcPath, filepath.Ext(srcPath))+".zon")
+		if err != nil {
+			return fmt.Errorf("load side file .zon: %w", err)
+		}
+	}
+
+	err = q.BakeVertexLights(models, opt)
+	if err != nil {
+		return err
+	}
+
+	err = q.PfsWrite(1, 1, dstPath)
+	if err != nil {
+		return fmt.Errorf("pfs write: %w", err)
+	}
+	fmt.Printf("Baked %s to %s\n", filepath.Base(srcPath), filepath.Base(dstPath))
+	return nil
+}
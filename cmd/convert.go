@@ -91,6 +91,13 @@ func runConvertE(cmd *cobra.Command, args []string) error {
 			if err != nil {
 				return fmt.Errorf("load side file .zon: %w", err)
 			}
+			// only s3d conversion needs the v4 terrain mesh, an eqg keeps its .dat
+			if filepath.Ext(dstPath) == ".s3d" {
+				err = v4TerrainAdd(srcPath, q.Wld)
+				if err != nil {
+					return err
+				}
+			}
 		}
 	}
 
@@ -140,6 +147,9 @@ func runConvertE(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return fmt.Errorf("pfs write: %w", err)
 		}
+		for _, note := range q.ConvertNotes {
+			fmt.Printf("  %s\n", note)
+		}
 
 	}
 
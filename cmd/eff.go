@@ -0,0 +1,422 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/xackery/quail/helper"
+	"github.com/xackery/quail/pfs"
+	"github.com/xackery/quail/quail"
+	"github.com/xackery/quail/wce"
+)
+
+func init() {
+	rootCmd.AddCommand(effCmd)
+	effCmd.AddCommand(effExportCmd)
+	effCmd.AddCommand(effImportCmd)
+	effCmd.AddCommand(effConvertCmd)
+	effConvertCmd.PersistentFlags().String("edd", "", "emitter file to add emitters to, defaults to emitters.edd next to the output")
+	effConvertCmd.PersistentFlags().String("archive", "", "s3d holding the sprites of spells.eff, e.g. --archive spells.s3d")
+	effCmd.AddCommand(effValidateCmd)
+	effValidateCmd.PersistentFlags().StringSlice("edd", nil, "emitter files, in order, defaults to emitters.edd next to the eff")
+	effValidateCmd.PersistentFlags().StringSlice("archive", nil, "s3d or eqg files holding emitter textures, e.g. --archive spells.s3d,spelleffects.eqg")
+	effValidateCmd.PersistentFlags().Bool("strict", false, "fail on unused emitters too")
+	effValidateCmd.PersistentFlags().Bool("json", false, "output as json")
+}
+
+// errEffInvalid is returned when eff validate finds broken references
+var errEffInvalid = errors.New("spell effects are invalid")
+
+// effCmd represents the eff command
+var effCmd = &cobra.Command{
+	Use:   "eff",
+	Short: "Spell effect table tools",
+	Long: `Spell effect table tools for spells.eff and spellsnew.eff
+Example: quail eff export spellsnew.eff effects.csv
+Example: quail eff import effects.csv spellsnew.eff
+Example: quail eff convert spells.eff spellsnew.eff
+Example: quail eff validate spellsnew.eff --archive spells.s3d`,
+}
+
+// effExportCmd represents the eff export command
+var effExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export a spell effect table to csv",
+	Long: `Export spells.eff or spellsnew.eff to a csv with one row per effect index
+spellsnew.eff columns are the effect name, emitter references like first0_emitter_id and unknown fields
+spells.eff columns are named by block, sub effect and field, like source_sound_ref and target_sub0_sprite
+Usage: quail eff export <spells.eff|spellsnew.eff> <out.csv>
+Example: quail eff export spellsnew.eff effects.csv`,
+	Run: runEffExport,
+}
+
+// effImportCmd represents the eff import command
+var effImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import a spell effect table from csv",
+	Long: `Import a csv written by eff export back into spells.eff or spellsnew.eff
+Rows may be in any order, columns left out and empty cells are zero, and effect indexes without a row are empty
+Usage: quail eff import <in.csv> <spells.eff|spellsnew.eff>
+Example: quail eff import effects.csv spellsnew.eff`,
+	Run: runEffImport,
+}
+
+// effConvertCmd represents the eff convert command
+var effConvertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "Convert spells.eff to spellsnew.eff and emitters",
+	Long: `Convert the classic effects of spells.eff to spellsnew.eff, with an emitter made for each sub and extra effect with a sprite
+Source emitters become the first emitters of an effect and target emitters the second, up to 4 each
+Emitters are added after those already in the --edd file, which is created when missing
+Emitter textures are the texture files of the sprites in the --archive s3d, and sprites not found there keep their name
+Fields spellsnew.eff cannot hold, like sound refs, dag indexes, unknown fields and source to target sprites, are reported
+Usage: quail eff convert <spells.eff> <spellsnew.eff>
+Example: quail eff convert spells.eff spellsnew.eff --archive spells.s3d
+Example: quail eff convert spells.eff out/spellsnew.eff --edd out/emitters.edd --archive spells.s3d`,
+	Run: runEffConvert,
+}
+
+// effValidateCmd represents the eff validate command
+var effValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check spellsnew.eff emitter and texture references",
+	Long: `Check that every emitter slot of spellsnew.eff refers to an emitter, and every emitter texture is in an archive
+Emitter IDs count from 1 through the --edd files in order, and 0 is an unused slot
+Textures match archive file names and s3d sprite tags, ignoring case and extension, and are only checked with --archive
+Unused emitters are reported, and only fail validation with --strict
+Exits 0 when valid, 2 when references are broken, and 1 when files cannot be read
+Usage: quail eff validate <spellsnew.eff>
+Example: quail eff validate spellsnew.eff
+Example: quail eff validate spellsnew.eff --edd emitters.edd --archive spells.s3d,spelleffects.eqg --json`,
+	Run: runEffValidate,
+}
+
+func runEffExport(cmd *cobra.Command, args []string) {
+	err := runEffExportE(cmd, args)
+	if err != nil {
+		fmt.Printf("Failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func runEffExportE(cmd *cobra.Command, args []string) error {
+	if len(args) < 2 {
+		return cmd.Usage()
+	}
+	srcPath := args[0]
+	dstPath := args[1]
+
+	q := quail.New()
+	err := q.EffRead(srcPath)
+	if err != nil {
+		return fmt.Errorf("eff read: %w", err)
+	}
+
+	w, err := os.Create(dstPath)
+	if err != nil {
+		return fmt.Errorf("create csv: %w", err)
+	}
+	defer w.Close()
+	err = q.Wld.WriteEffCSV(w)
+	if err != nil {
+		return fmt.Errorf("write csv: %w", err)
+	}
+
+	count := len(q.Wld.EffectOlds) + len(q.Wld.EffectNews)
+	fmt.Printf("Exported %d effect%s to %s\n", count, helper.Pluralize(count), filepath.Base(dstPath))
+	return nil
+}
+
+func runEffImport(cmd *cobra.Command, args []string) {
+	err := runEffImportE(cmd, args)
+	if err != nil {
+		fmt.Printf("Failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func runEffImportE(cmd *cobra.Command, args []string) error {
+	if len(args) < 2 {
+		return cmd.Usage()
+	}
+	srcPath := args[0]
+	dstPath := args[1]
+
+	r, err := os.Open(srcPath)
+	if err != nil {
+		return fmt.Errorf("open csv: %w", err)
+	}
+	defer r.Close()
+
+	fileName := filepath.Base(dstPath)
+	q := quail.New()
+	q.Wld = wce.New(strings.TrimSuffix(fileName, filepath.Ext(fileName)))
+	err = q.Wld.ReadEffCSV(r)
+	if err != nil {
+		return fmt.Errorf("read csv: %w", err)
+	}
+
+	// eff files are told apart by name, so the csv columns must match it
+	switch strings.ToLower(fileName) {
+	case "spells.eff":
+		if len(q.Wld.EffectOlds) == 0 {
+			return fmt.Errorf("%s has spellsnew.eff columns", filepath.Base(srcPath))
+		}
+	case "spellsnew.eff":
+		if len(q.Wld.EffectNews) == 0 {
+			return fmt.Errorf("%s has spells.eff columns", filepath.Base(srcPath))
+		}
+	default:
+		return fmt.Errorf("unsupported eff file %s: expected spells.eff or spellsnew.eff", fileName)
+	}
+
+	err = q.EffWrite(dstPath)
+	if err != nil {
+		return fmt.Errorf("eff write: %w", err)
+	}
+
+	count := len(q.Wld.EffectOlds) + len(q.Wld.EffectNews)
+	fmt.Printf("Imported %d effect%s to %s\n", count, helper.Pluralize(count), fileName)
+	return nil
+}
+
+func runEffConvert(cmd *cobra.Command, args []string) {
+	err := runEffConvertE(cmd, args)
+	if err != nil {
+		fmt.Printf("Failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func runEffConvertE(cmd *cobra.Command, args []string) error {
+	if len(args) < 2 {
+		return cmd.Usage()
+	}
+	srcPath := args[0]
+	dstPath := args[1]
+	eddPath, err := cmd.Flags().GetString("edd")
+	if err != nil {
+		return fmt.Errorf("parse edd: %w", err)
+	}
+	if eddPath == "" {
+		eddPath = filepath.Join(filepath.Dir(dstPath), "emitters.edd")
+	}
+	archivePath, err := cmd.Flags().GetString("archive")
+	if err != nil {
+		return fmt.Errorf("parse archive: %w", err)
+	}
+
+	src := quail.New()
+	err = src.EffRead(srcPath)
+	if err != nil {
+		return fmt.Errorf("eff read: %w", err)
+	}
+
+	q := quail.New()
+	_, err = os.Stat(eddPath)
+	if err == nil {
+		err = q.EddRead(eddPath)
+		if err != nil {
+			return fmt.Errorf("edd read: %w", err)
+		}
+	} else {
+		q.Wld = wce.New("spellsnew")
+	}
+	emitterCount := len(q.Wld.EmitterDefs)
+
+	var sprites *wce.Wce
+	if archivePath != "" {
+		archive := quail.New()
+		err = archive.PfsRead(archivePath)
+		if err != nil {
+			return fmt.Errorf("archive %s: %w", filepath.Base(archivePath), err)
+		}
+		sprites = archive.Wld
+	}
+
+	notes, err := src.Wld.ConvertEffectOlds(q.Wld, sprites)
+	if err != nil {
+		return fmt.Errorf("convert: %w", err)
+	}
+
+	err = q.EffWrite(dstPath)
+	if err != nil {
+		return fmt.Errorf("eff write: %w", err)
+	}
+	err = q.EddWrite(eddPath)
+	if err != nil {
+		return fmt.Errorf("edd write: %w", err)
+	}
+
+	for _, note := range notes {
+		fmt.Printf("  %s\n", note)
+	}
+	added := len(q.Wld.EmitterDefs) - emitterCount
+	fmt.Printf("Converted %d effect%s to %s with %d emitter%s added to %s, %d note%s\n",
+		len(q.Wld.EffectNews), helper.Pluralize(len(q.Wld.EffectNews)), filepath.Base(dstPath),
+		added, helper.Pluralize(added), filepath.Base(eddPath),
+		len(notes), helper.Pluralize(len(notes)))
+	return nil
+}
+
+func runEffValidate(cmd *cobra.Command, args []string) {
+	err := runEffValidateE(cmd, args)
+	if errors.Is(err, errEffInvalid) {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Printf("Failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func runEffValidateE(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return cmd.Usage()
+	}
+	path := args[0]
+	eddPaths, err := cmd.Flags().GetStringSlice("edd")
+	if err != nil {
+		return fmt.Errorf("parse edd: %w", err)
+	}
+	if len(eddPaths) == 0 {
+		eddPaths = []string{filepath.Join(filepath.Dir(path), "emitters.edd")}
+	}
+	archivePaths, err := cmd.Flags().GetStringSlice("archive")
+	if err != nil {
+		return fmt.Errorf("parse archive: %w", err)
+	}
+	isStrict, err := cmd.Flags().GetBool("strict")
+	if err != nil {
+		return fmt.Errorf("parse strict: %w", err)
+	}
+	isJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return fmt.Errorf("parse json: %w", err)
+	}
+
+	q := quail.New()
+	err = q.EffRead(path)
+	if err != nil {
+		return fmt.Errorf("eff read: %w", err)
+	}
+	for _, eddPath := range eddPaths {
+		edd := quail.New()
+		err = edd.EddRead(eddPath)
+		if err != nil {
+			return fmt.Errorf("edd read: %w", err)
+		}
+		q.Wld.EmitterDefs = append(q.Wld.EmitterDefs, edd.Wld.EmitterDefs...)
+	}
+
+	// textures are only checked when archives are given
+	var textures []string
+	if len(archivePaths) > 0 {
+		textures = []string{}
+	}
+	for _, archivePath := range archivePaths {
+		names, err := effArchiveTextures(archivePath)
+		if err != nil {
+			return fmt.Errorf("archive %s: %w", filepath.Base(archivePath), err)
+		}
+		textures = append(textures, names...)
+	}
+
+	result, err := q.Wld.EffValidate(textures)
+	if err != nil {
+		return fmt.Errorf("eff validate: %w", err)
+	}
+
+	if isJSON {
+		err = effValidateWriteJSON(os.Stdout, filepath.Base(path), result)
+	} else {
+		err = effValidateWriteText(os.Stdout, filepath.Base(path), result, len(archivePaths) > 0)
+	}
+	if err != nil {
+		return err
+	}
+	if !result.IsValid() || (isStrict && len(result.UnusedEmitters) > 0) {
+		return errEffInvalid
+	}
+	return nil
+}
+
+// effArchiveTextures returns the file names of an archive, and the sprite tags of its wld
+func effArchiveTextures(path string) ([]string, error) {
+	archive, err := pfs.NewFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("pfs load: %w", err)
+	}
+	defer archive.Close()
+
+	names := []string{}
+	hasWld := false
+	for _, file := range archive.Files() {
+		names = append(names, file.Name())
+		if strings.EqualFold(filepath.Ext(file.Name()), ".wld") {
+			hasWld = true
+		}
+	}
+	if !hasWld {
+		return names, nil
+	}
+
+	q := quail.New()
+	err = q.PfsRead(path)
+	if err != nil {
+		return nil, fmt.Errorf("pfs read: %w", err)
+	}
+	if q.Wld != nil {
+		for _, sprite := range q.Wld.BlitSpriteDefs {
+			names = append(names, sprite.Tag)
+		}
+		for _, sprite := range q.Wld.SimpleSpriteDefs {
+			names = append(names, sprite.Tag)
+		}
+	}
+	return names, nil
+}
+
+func effValidateWriteJSON(w io.Writer, name string, result *wce.EffValidation) error {
+	out := struct {
+		File string
+		*wce.EffValidation
+	}{
+		File:          name,
+		EffValidation: result,
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(out)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+	return nil
+}
+
+func effValidateWriteText(w io.Writer, name string, result *wce.EffValidation, isTextureChecked bool) error {
+	fmt.Fprintf(w, "%s: %d effect%s, %d emitter%s\n", name, result.Effects, helper.Pluralize(result.Effects), result.Emitters, helper.Pluralize(result.Emitters))
+	for _, ref := range result.UnknownEmitters {
+		fmt.Fprintf(w, "  effect %d %s: unknown emitter %d\n", ref.Effect, ref.Slot, ref.EmitterID)
+	}
+	for _, emitter := range result.MissingTextures {
+		fmt.Fprintf(w, "  emitter %d %s: missing texture %q\n", emitter.EmitterID, emitter.Name, emitter.Texture)
+	}
+	for _, emitter := range result.UnusedEmitters {
+		fmt.Fprintf(w, "  emitter %d %s: unused\n", emitter.EmitterID, emitter.Name)
+	}
+	if !isTextureChecked {
+		fmt.Fprintf(w, "  textures not checked, no --archive given\n")
+	}
+	fmt.Fprintf(w, "%d unknown emitter%s, %d missing texture%s, %d unused emitter%s\n",
+		len(result.UnknownEmitters), helper.Pluralize(len(result.UnknownEmitters)),
+		len(result.MissingTextures), helper.Pluralize(len(result.MissingTextures)),
+		len(result.UnusedEmitters), helper.Pluralize(len(result.UnusedEmitters)))
+	return nil
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/xackery/quail/helper"
+	"github.com/xackery/quail/pfs"
+	"github.com/xackery/quail/quail"
+	"github.com/xackery/quail/raw"
+	"github.com/xackery/quail/wce"
+)
+
+func init() {
+	rootCmd.AddCommand(floraCmd)
+	floraCmd.PersistentFlags().String("path", "", "path to v4 zone eqg")
+	floraCmd.PersistentFlags().Bool("json", false, "output as json")
+	floraCmd.PersistentFlags().Float32("units-per-vert", 0, "terrain units per vertex, defaults to the zone .zon")
+	floraCmd.PersistentFlags().Int("quads-per-tile", 0, "terrain quads per tile, defaults to the zone .zon")
+}
+
+// floraCmd represents the flora command
+var floraCmd = &cobra.Command{
+	Use:   "flora",
+	Short: "List radial flora (.rfd) placements per terrain tile of a v4 zone",
+	Long: `List every radial flora entry inside a v4 zone eqg grouped by the terrain tile its center falls in
+Tile size is read from the v4 <zone>.zon inside the eqg unless overridden
+Example: quail flora arcstone.eqg
+Example: quail flora arcstone.eqg --json
+Example: quail flora arcstone.eqg --units-per-vert 2 --quads-per-tile 128`,
+	Run: runFlora,
+}
+
+func runFlora(cmd *cobra.Command, args []string) {
+	err := runFloraE(cmd, args)
+	if err != nil {
+		fmt.Printf("Failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func runFloraE(cmd *cobra.Command, args []string) error {
+	path, err := cmd.Flags().GetString("path")
+	if err != nil {
+		return fmt.Errorf("parse path: %w", err)
+	}
+	if path == "" {
+		if len(args) < 1 {
+			return cmd.Usage()
+		}
+		path = args[0]
+	}
+
+	isJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return fmt.Errorf("parse json: %w", err)
+	}
+	unitsPerVert, err := cmd.Flags().GetFloat32("units-per-vert")
+	if err != nil {
+		return fmt.Errorf("parse units-per-vert: %w", err)
+	}
+	quadsPerTile, err := cmd.Flags().GetInt("quads-per-tile")
+	if err != nil {
+		return fmt.Errorf("parse quads-per-tile: %w", err)
+	}
+
+	if unitsPerVert == 0 || quadsPerTile == 0 {
+		info, err := floraZonInfo(path)
+		if err != nil {
+			return err
+		}
+		if unitsPerVert == 0 {
+			unitsPerVert = info.UnitsPerVert
+		}
+		if quadsPerTile == 0 {
+			quadsPerTile = info.QuadsPerTile
+		}
+	}
+
+	q := quail.New()
+	err = q.PfsRead(path)
+	if err != nil {
+		return fmt.Errorf("pfs read: %w", err)
+	}
+	if q.Wld == nil {
+		return fmt.Errorf("no wld found in %s", filepath.Base(path))
+	}
+
+	tiles, err := q.Wld.FloraTiles(unitsPerVert, quadsPerTile)
+	if err != nil {
+		return err
+	}
+
+	if isJSON {
+		return floraWriteJSON(os.Stdout, filepath.Base(path), tiles)
+	}
+	return floraWriteText(os.Stdout, filepath.Base(path), tiles)
+}
+
+// floraZonInfo reads the tile layout from the v4 .zon inside an eqg
+func floraZonInfo(path string) (*raw.V4Info, error) {
+	archive, err := pfs.NewFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("pfs load: %w", err)
+	}
+	defer archive.Close()
+
+	zon, _, err := v4ZonLoad(archive)
+	if err != nil {
+		return nil, fmt.Errorf("%w, set --units-per-vert and --quads-per-tile", err)
+	}
+	return &zon.V4Info, nil
+}
+
+func floraWriteJSON(w io.Writer, name string, tiles []*wce.FloraTile) error {
+	out := struct {
+		File  string
+		Tiles []*wce.FloraTile
+	}{
+		File:  name,
+		Tiles: tiles,
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(out)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+	return nil
+}
+
+func floraWriteText(w io.Writer, name string, tiles []*wce.FloraTile) error {
+	fmt.Fprintf(w, "%s: %d tile%s with flora\n", name, len(tiles), helper.Pluralize(len(tiles)))
+	for _, tile := range tiles {
+		fmt.Fprintf(w, "  tile lng=%d lat=%d flora=%d\n", tile.Lng, tile.Lat, len(tile.Flora))
+		for _, flora := range tile.Flora {
+			entry := flora.Entry
+			fmt.Fprintf(w, "    %s %s pos:%0.0f,%0.0f,%0.0f radius=%0.1f density=%0.3f\n", flora.RfdTag, entry.Flora, entry.Position[0], entry.Position[1], entry.Position[2], entry.Radius, entry.Density)
+		}
+	}
+	return nil
+}
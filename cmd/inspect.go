@@ -12,21 +12,26 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/xackery/quail/helper"
 	"github.com/xackery/quail/pfs"
+	"github.com/xackery/quail/quail"
 	"github.com/xackery/quail/raw"
+	"github.com/xackery/quail/wce"
 )
 
 func init() {
 	rootCmd.AddCommand(inspectCmd)
 	inspectCmd.PersistentFlags().String("path", "", "path to inspect")
 	inspectCmd.PersistentFlags().String("path2", "", "path to compare")
+	inspectCmd.PersistentFlags().Bool("textures", false, "list animated textures with frame counts and timings")
 }
 
 // inspectCmd represents the inspect command
 var inspectCmd = &cobra.Command{
 	Use:   "inspect",
 	Short: "Inspect an EverQuest asset",
-	Long:  `Inspect an EverQuest asset to discover contents within`,
-	Run:   runInspect,
+	Long: `Inspect an EverQuest asset to discover contents within
+Example: quail inspect gfaydark.s3d
+Example: quail inspect gfaydark.s3d --textures`,
+	Run: runInspect,
 }
 
 func runInspect(cmd *cobra.Command, args []string) {
@@ -59,6 +64,14 @@ func runInspectE(cmd *cobra.Command, args []string) error {
 		path = args[0]
 	}
 
+	isTextures, err := cmd.Flags().GetBool("textures")
+	if err != nil {
+		return fmt.Errorf("parse textures: %w", err)
+	}
+	if isTextures {
+		return inspectTextures(os.Stdout, path)
+	}
+
 	path2, err := cmd.Flags().GetString("path2")
 	if err != nil {
 		return fmt.Errorf("parse path2: %w", err)
@@ -215,3 +228,36 @@ func inspectDump(inspect interface{}, index int, w io.Writer) error {
 	}
 	return nil
 }
+
+// inspectTextures lists every animated texture of an archive
+func inspectTextures(w io.Writer, path string) error {
+	q := quail.New()
+	err := q.PfsRead(path)
+	if err != nil {
+		return fmt.Errorf("pfs read: %w", err)
+	}
+
+	textures := []*wce.AnimatedTexture{}
+	for _, wld := range []*wce.Wce{q.Wld, q.WldObject, q.WldLights} {
+		if wld == nil {
+			continue
+		}
+		textures = append(textures, wld.AnimatedTextures()...)
+	}
+	inspectWriteTextures(w, filepath.Base(path), textures)
+	return nil
+}
+
+func inspectWriteTextures(w io.Writer, name string, textures []*wce.AnimatedTexture) {
+	fmt.Fprintf(w, "%s: %d animated texture%s\n", name, len(textures), helper.Pluralize(len(textures)))
+	for _, texture := range textures {
+		tag := texture.Tag
+		if texture.Owner != "" {
+			tag = texture.Owner + " " + tag
+		}
+		fmt.Fprintf(w, "  %s %s frames=%d sleep=%dms cycle=%dms\n", texture.Definition, tag, len(texture.Frames), texture.Sleep, texture.Cycle())
+		for i, frame := range texture.Frames {
+			fmt.Fprintf(w, "    %d: %s\n", i, frame)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/xackery/quail/helper"
+	"github.com/xackery/quail/quail"
+	"github.com/xackery/quail/wce"
+)
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+	lintCmd.PersistentFlags().String("path", "", "path to eqg")
+	lintCmd.PersistentFlags().StringSlice("edd", nil, "emitter files, in order, defaults to the .edd next to the eqg")
+	lintCmd.PersistentFlags().Bool("json", false, "output as json")
+}
+
+// errLintIssues is returned when lint finds issues
+var errLintIssues = errors.New("lint issues found")
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check an eqg for broken particle attachments",
+	Long: `Check the files of an eqg reference each other correctly
+.pts particle points must attach to bones of the model of the same name, and be played by its .prt
+.prt entries must play a known particle point and emitter, with emitter IDs counting from 1 through the --edd files
+Emitters are only checked when an --edd is given or the eqg has a .edd next to it
+Exits 0 when clean, 2 when issues are found, and 1 when files cannot be read
+Usage: quail lint <file.eqg>
+Example: quail lint torch.eqg
+Example: quail lint torch.eqg --edd actoremittersnew.edd --json`,
+	Run: runLint,
+}
+
+func runLint(cmd *cobra.Command, args []string) {
+	err := runLintE(cmd, args)
+	if errors.Is(err, errLintIssues) {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Printf("Failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func runLintE(cmd *cobra.Command, args []string) error {
+	path, err := cmd.Flags().GetString("path")
+	if err != nil {
+		return fmt.Errorf("parse path: %w", err)
+	}
+	if path == "" {
+		if len(args) < 1 {
+			return cmd.Usage()
+		}
+		path = args[0]
+	}
+	eddPaths, err := cmd.Flags().GetStringSlice("edd")
+	if err != nil {
+		return fmt.Errorf("parse edd: %w", err)
+	}
+	if len(eddPaths) == 0 {
+		sidePath := strings.TrimSuffix(path, filepath.Ext(path)) + ".edd"
+		_, err = os.Stat(sidePath)
+		if err == nil {
+			eddPaths = []string{sidePath}
+		}
+	}
+	isJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return fmt.Errorf("parse json: %w", err)
+	}
+
+	q := quail.New()
+	err = q.PfsRead(path)
+	if err != nil {
+		return fmt.Errorf("pfs read: %w", err)
+	}
+	if q.Wld == nil {
+		return fmt.Errorf("%s has no models", filepath.Base(path))
+	}
+	for _, eddPath := range eddPaths {
+		edd := quail.New()
+		err = edd.EddRead(eddPath)
+		if err != nil {
+			return fmt.Errorf("edd read: %w", err)
+		}
+		q.Wld.EmitterDefs = append(q.Wld.EmitterDefs, edd.Wld.EmitterDefs...)
+	}
+
+	issues := q.Wld.ParticleLint()
+
+	if isJSON {
+		err = lintWriteJSON(os.Stdout, filepath.Base(path), issues)
+	} else {
+		err = lintWriteText(os.Stdout, filepath.Base(path), issues, len(eddPaths) > 0)
+	}
+	if err != nil {
+		return err
+	}
+	if len(issues) > 0 {
+		return errLintIssues
+	}
+	return nil
+}
+
+func lintWriteJSON(w io.Writer, name string, issues []*wce.LintIssue) error {
+	out := struct {
+		File   string
+		Issues []*wce.LintIssue
+	}{
+		File:   name,
+		Issues: issues,
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(out)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+	return nil
+}
+
+func lintWriteText(w io.Writer, name string, issues []*wce.LintIssue, isEmitterChecked bool) error {
+	fmt.Fprintf(w, "%s: %d issue%s\n", name, len(issues), helper.Pluralize(len(issues)))
+	for _, issue := range issues {
+		if issue.Name == "" {
+			fmt.Fprintf(w, "  %s: %s\n", issue.File, issue.Reason)
+			continue
+		}
+		fmt.Fprintf(w, "  %s %s: %s\n", issue.File, issue.Name, issue.Reason)
+	}
+	if !isEmitterChecked {
+		fmt.Fprintf(w, "  emitters not checked, no .edd found\n")
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/xackery/quail/quail"
+	"github.com/xackery/quail/wce"
+)
+
+func init() {
+	rootCmd.AddCommand(lodCmd)
+	lodCmd.AddCommand(lodGenerateCmd)
+	lodGenerateCmd.PersistentFlags().Int("levels", 3, "number of reduced models to make per model")
+	lodGenerateCmd.PersistentFlags().Float32("ratio", 0.5, "face count of each level relative to the level before it")
+	lodGenerateCmd.PersistentFlags().Float32Slice("distances", nil, "switch distances, one per level plus the source model, e.g. --distances 150,250,400,1000")
+	lodGenerateCmd.PersistentFlags().String("model", "", "only reduce this model")
+	lodGenerateCmd.PersistentFlags().String("out", "", "eqg to write, defaults to overwriting the source")
+}
+
+// lodCmd represents the lod command
+var lodCmd = &cobra.Command{
+	Use:   "lod",
+	Short: "Level of detail tools for eqg models",
+	Long: `Level of detail tools for eqg models
+Example: quail lod generate model.eqg --levels 3`,
+}
+
+// lodGenerateCmd represents the lod generate command
+var lodGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate decimated LOD models and .lod files for an eqg",
+	Long: `Reduce every model of an eqg into <model>_LOD1.._LODn levels with quadric edge collapse,
+keeping uv seams and material boundaries, and write a matching <model>.lod
+Each level keeps --ratio of the faces of the level before it
+Distances default to doubling from 100 (100, 200, 400, 800)
+Previously generated levels of a model are replaced
+The source eqg is overwritten unless --out is set
+Usage: quail lod generate <model.eqg>
+Example: quail lod generate model.eqg --levels 3
+Example: quail lod generate model.eqg --levels 2 --distances 150,400,1000 --out model_lod.eqg
+Example: quail lod generate zone.eqg --model obj_firepit --ratio 0.25`,
+	Run: runLodGenerate,
+}
+
+func runLodGenerate(cmd *cobra.Command, args []string) {
+	err := runLodGenerateE(cmd, args)
+	if err != nil {
+		fmt.Printf("Failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func runLodGenerateE(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return cmd.Usage()
+	}
+	srcPath := args[0]
+	if strings.ToLower(filepath.Ext(srcPath)) != ".eqg" {
+		return fmt.Errorf("unsupported extension %s, wanted .eqg", filepath.Ext(srcPath))
+	}
+
+	opt := &wce.LodOption{}
+	var err error
+	opt.Levels, err = cmd.Flags().GetInt("levels")
+	if err != nil {
+		return fmt.Errorf("parse levels: %w", err)
+	}
+	opt.Ratio, err = cmd.Flags().GetFloat32("ratio")
+	if err != nil {
+		return fmt.Errorf("parse ratio: %w", err)
+	}
+	opt.Distances, err = cmd.Flags().GetFloat32Slice("distances")
+	if err != nil {
+		return fmt.Errorf("parse distances: %w", err)
+	}
+	opt.ModelTag, err = cmd.Flags().GetString("model")
+	if err != nil {
+		return fmt.Errorf("parse model: %w", err)
+	}
+	dstPath, err := cmd.Flags().GetString("out")
+	if err != nil {
+		return fmt.Errorf("parse out: %w", err)
+	}
+	if dstPath == "" {
+		dstPath = srcPath
+	}
+
+	q := quail.New()
+	err = q.PfsRead(srcPath)
+	if err != nil {
+		return fmt.Errorf("pfs read: %w", err)
+	}
+
+	err = q.GenerateLods(opt)
+	if err != nil {
+		return err
+	}
+
+	err = q.PfsWrite(1, 1, dstPath)
+	if err != nil {
+		return fmt.Errorf("pfs write: %w", err)
+	}
+	fmt.Printf("Generated %d lod levels for %s to %s\n", opt.Levels, filepath.Base(srcPath), filepath.Base(dstPath))
+	return nil
+}
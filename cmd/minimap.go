@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/xackery/quail/helper"
+	"github.com/xackery/quail/quail"
+	"github.com/xackery/quail/wce"
+)
+
+func init() {
+	rootCmd.AddCommand(minimapCmd)
+	minimapCmd.PersistentFlags().String("path", "", "path to zone s3d or eqg")
+	minimapCmd.PersistentFlags().String("out", "maps", "directory to write the map txt file to")
+	minimapCmd.PersistentFlags().Float32Slice("z", nil, "z levels to slice, e.g. --z 0,50,100")
+	minimapCmd.PersistentFlags().Float32("step", 50, "distance between z levels when --z is not set")
+	minimapCmd.PersistentFlags().Bool("zonelines", false, "add labeled points for zonelines")
+	minimapCmd.PersistentFlags().Bool("objects", false, "add labeled points for placed objects")
+}
+
+// minimapCmd represents the minimap command
+var minimapCmd = &cobra.Command{
+	Use:   "minimap",
+	Short: "Generate an in-game map (maps/<zone>_1.txt) from zone geometry",
+	Long: `Slice s3d or eqg zone geometry at z levels into brewall style L line records,
+optionally adding P point records for zonelines and placed objects
+For s3d zones, a <zone>_obj.s3d next to the zone is loaded for placed objects
+Example: quail minimap crushbone.s3d
+Example: quail minimap crushbone.s3d --z 0,40 --zonelines --objects`,
+	Run: runMinimap,
+}
+
+func runMinimap(cmd *cobra.Command, args []string) {
+	err := runMinimapE(cmd, args)
+	if err != nil {
+		fmt.Printf("Failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func runMinimapE(cmd *cobra.Command, args []string) error {
+	path, err := cmd.Flags().GetString("path")
+	if err != nil {
+		return fmt.Errorf("parse path: %w", err)
+	}
+	if path == "" {
+		if len(args) < 1 {
+			return cmd.Usage()
+		}
+		path = args[0]
+	}
+	out, err := cmd.Flags().GetString("out")
+	if err != nil {
+		return fmt.Errorf("parse out: %w", err)
+	}
+	opt := &quail.MinimapOption{}
+	opt.Levels, err = cmd.Flags().GetFloat32Slice("z")
+	if err != nil {
+		return fmt.Errorf("parse z: %w", err)
+	}
+	opt.Step, err = cmd.Flags().GetFloat32("step")
+	if err != nil {
+		return fmt.Errorf("parse step: %w", err)
+	}
+	opt.IsZonelines, err = cmd.Flags().GetBool("zonelines")
+	if err != nil {
+		return fmt.Errorf("parse zonelines: %w", err)
+	}
+	opt.IsObjects, err = cmd.Flags().GetBool("objects")
+	if err != nil {
+		return fmt.Errorf("parse objects: %w", err)
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".s3d" && ext != ".eqg" {
+		return fmt.Errorf("unsupported extension %s, wanted .s3d or .eqg", ext)
+	}
+	zoneName := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	q := quail.New()
+	err = q.PfsRead(path)
+	if err != nil {
+		return fmt.Errorf("pfs read: %w", err)
+	}
+
+	var models *wce.Wce
+	if ext == ".s3d" {
+		models, err = zoneLoadObjects(strings.TrimSuffix(path, filepath.Ext(path)) + "_obj.s3d")
+		if err != nil {
+			return err
+		}
+	} else {
+		err = v4TerrainAdd(path, q.Wld)
+		if err != nil {
+			return err
+		}
+	}
+
+	mt, err := q.Minimap(models, opt)
+	if err != nil {
+		return fmt.Errorf("minimap: %w", err)
+	}
+
+	err = os.MkdirAll(out, 0755)
+	if err != nil {
+		return fmt.Errorf("mkdir: %w", err)
+	}
+	txtPath := filepath.Join(out, zoneName+"_1.txt")
+	err = rawWriteFile(txtPath, mt)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s with %d line%s and %d point%s\n", txtPath, len(mt.Lines), helper.Pluralize(len(mt.Lines)), len(mt.Points), helper.Pluralize(len(mt.Points)))
+	return nil
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/xackery/quail/gltf"
+	"github.com/xackery/quail/helper"
+	"github.com/xackery/quail/quail"
+	"github.com/xackery/quail/wce"
+)
+
+func init() {
+	rootCmd.AddCommand(morphCmd)
+	morphCmd.AddCommand(morphExportCmd)
+	morphCmd.AddCommand(morphImportCmd)
+	morphExportCmd.PersistentFlags().String("out", "", "gltf or glb to write, defaults to <sprite>.glb")
+	morphImportCmd.PersistentFlags().String("out", "", "s3d to write, defaults to overwriting the source")
+}
+
+// morphCmd represents the morph command
+var morphCmd = &cobra.Command{
+	Use:   "morph",
+	Short: "Export and import DMTRACKDEF2 vertex animations as gltf morph targets",
+	Long: `Export and import DMTRACKDEF2 vertex animations as gltf morph targets
+Example: quail morph export qeynos.s3d FLAG_DMSPRITEDEF
+Example: quail morph import qeynos.s3d FLAG_DMSPRITEDEF flag.glb`,
+}
+
+// morphExportCmd represents the morph export command
+var morphExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write the vertex animation of a mesh as a gltf with one morph target per frame",
+	Long: `Write the DMTRACKDEF2 of a DMSPRITEDEF2 as a gltf mesh with one morph target per frame,
+and a weight animation that steps through the frames at the track sleep
+Positions are converted from z up to the y up gltf expects
+The file is a binary glb unless --out ends with .gltf
+Usage: quail morph export <file.s3d> <SPRITE_DMSPRITEDEF>
+Example: quail morph export qeynos.s3d FLAG_DMSPRITEDEF
+Example: quail morph export qeynos_obj.s3d BANNER_DMSPRITEDEF --out banner.gltf`,
+	Run: runMorphExport,
+}
+
+// morphImportCmd represents the morph import command
+var morphImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Replace the vertex animation of a mesh with the morph target animation of a gltf",
+	Long: `Sample the morph target weight animation of a gltf or glb into the DMTRACKDEF2 of a DMSPRITEDEF2
+The gltf mesh must have the same vertices as the sprite, and a sprite without a track gets one
+Sleep is the shortest time between keys, weights are sampled every sleep by the key interpolation,
+and FPSCALE is the finest that fits the animated vertices
+Node translation, rotation, scale and matrix are applied, and nodes moved by an animation are rejected
+The source s3d is overwritten unless --out is set
+Usage: quail morph import <file.s3d> <SPRITE_DMSPRITEDEF> <file.glb>
+Example: quail morph import qeynos.s3d FLAG_DMSPRITEDEF flag.glb
+Example: quail morph import qeynos_obj.s3d BANNER_DMSPRITEDEF banner.gltf --out qeynos_obj_edit.s3d`,
+	Run: runMorphImport,
+}
+
+func runMorphExport(cmd *cobra.Command, args []string) {
+	err := runMorphExportE(cmd, args)
+	if err != nil {
+		fmt.Printf("Failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func runMorphExportE(cmd *cobra.Command, args []string) error {
+	if len(args) < 2 {
+		return cmd.Usage()
+	}
+	srcPath := args[0]
+	spriteTag := args[1]
+	dstPath, err := cmd.Flags().GetString("out")
+	if err != nil {
+		return fmt.Errorf("parse out: %w", err)
+	}
+	if dstPath == "" {
+		dstPath = strings.ToLower(strings.TrimSuffix(spriteTag, "_DMSPRITEDEF")) + ".glb"
+	}
+
+	q := quail.New()
+	err = q.PfsRead(srcPath)
+	if err != nil {
+		return fmt.Errorf("pfs read: %w", err)
+	}
+	wld, err := morphWld(q, spriteTag)
+	if err != nil {
+		return err
+	}
+	doc, err := wld.DMTrackGltf(spriteTag)
+	if err != nil {
+		return fmt.Errorf("morph export: %w", err)
+	}
+
+	w, err := os.Create(dstPath)
+	if err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+	defer w.Close()
+	if strings.ToLower(filepath.Ext(dstPath)) == ".gltf" {
+		err = doc.Write(w)
+	} else {
+		err = doc.WriteGLB(w)
+	}
+	if err != nil {
+		return fmt.Errorf("write %s: %w", dstPath, err)
+	}
+	fmt.Printf("Exported %d frame%s of %s to %s\n", len(doc.Meshes[0].Weights), helper.Pluralize(len(doc.Meshes[0].Weights)), spriteTag, dstPath)
+	return nil
+}
+
+func runMorphImport(cmd *cobra.Command, args []string) {
+	err := runMorphImportE(cmd, args)
+	if err != nil {
+		fmt.Printf("Failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func runMorphImportE(cmd *cobra.Command, args []string) error {
+	if len(args) < 3 {
+		return cmd.Usage()
+	}
+	srcPath := args[0]
+	spriteTag := args[1]
+	gltfPath := args[2]
+	dstPath, err := cmd.Flags().GetString("out")
+	if err != nil {
+		return fmt.Errorf("parse out: %w", err)
+	}
+	if dstPath == "" {
+		dstPath = srcPath
+	}
+
+	r, err := os.Open(gltfPath)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+	doc, err := gltf.Read(r)
+	r.Close()
+	if err != nil {
+		return fmt.Errorf("read %s: %w", gltfPath, err)
+	}
+
+	q := quail.New()
+	err = q.PfsRead(srcPath)
+	if err != nil {
+		return fmt.Errorf("pfs read: %w", err)
+	}
+	wld, err := morphWld(q, spriteTag)
+	if err != nil {
+		return err
+	}
+	track, err := wld.DMTrackFromGltf(spriteTag, doc)
+	if err != nil {
+		return fmt.Errorf("morph import: %w", err)
+	}
+
+	err = q.PfsWrite(1, 1, dstPath)
+	if err != nil {
+		return fmt.Errorf("pfs write: %w", err)
+	}
+	fmt.Printf("Imported %d frame%s into %s of %s\n", len(track.Frames), helper.Pluralize(len(track.Frames)), track.Tag, filepath.Base(dstPath))
+	return nil
+}
+
+// morphWld returns the wld of an s3d that has the sprite named spriteTag
+func morphWld(q *quail.Quail, spriteTag string) (*wce.Wce, error) {
+	for _, wld := range []*wce.Wce{q.Wld, q.WldObject} {
+		if wld == nil {
+			continue
+		}
+		if _, ok := wld.ByTag(spriteTag).(*wce.DMSpriteDef2); ok {
+			return wld, nil
+		}
+	}
+	return nil, fmt.Errorf("dmspritedef2 %s not found", spriteTag)
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/xackery/quail/helper"
+	"github.com/xackery/quail/quail"
+	"github.com/xackery/quail/wce"
+)
+
+func init() {
+	rootCmd.AddCommand(regionsCmd)
+	regionsCmd.PersistentFlags().String("path", "", "path to zone s3d")
+	regionsCmd.PersistentFlags().Bool("json", false, "output as json")
+}
+
+// regionsCmd represents the regions command
+var regionsCmd = &cobra.Command{
+	Use:   "regions",
+	Short: "List typed zone regions (water, lava, zonelines, pvp, slippery)",
+	Long: `List every zone region group inside a s3d zone with its decoded type
+Example: quail regions crushbone.s3d
+Example: quail regions crushbone.s3d --json`,
+	Run: runRegions,
+}
+
+func runRegions(cmd *cobra.Command, args []string) {
+	err := runRegionsE(cmd, args)
+	if err != nil {
+		fmt.Printf("Failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func runRegionsE(cmd *cobra.Command, args []string) error {
+	path, err := cmd.Flags().GetString("path")
+	if err != nil {
+		return fmt.Errorf("parse path: %w", err)
+	}
+	if path == "" {
+		if len(args) < 1 {
+			return cmd.Usage()
+		}
+		path = args[0]
+	}
+
+	isJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return fmt.Errorf("parse json: %w", err)
+	}
+
+	q := quail.New()
+	err = q.PfsRead(path)
+	if err != nil {
+		return fmt.Errorf("pfs read: %w", err)
+	}
+	if q.Wld == nil {
+		return fmt.Errorf("no zone wld found in %s", filepath.Base(path))
+	}
+
+	entries, err := regionEntries(q.Wld)
+	if err != nil {
+		return err
+	}
+
+	if isJSON {
+		return regionsWriteJSON(os.Stdout, filepath.Base(path), entries)
+	}
+	return regionsWriteText(os.Stdout, filepath.Base(path), entries)
+}
+
+// regionEntry is a single zone's decoded type for output
+type regionEntry struct {
+	Tag         string
+	Kind        string
+	Flags       string
+	RegionCount int
+	UserData    string
+	Zoneline    *wce.Zoneline
+}
+
+func regionEntries(wld *wce.Wce) ([]*regionEntry, error) {
+	entries := []*regionEntry{}
+	for _, zone := range wld.Zones {
+		zt, err := zone.ZoneType()
+		if err != nil {
+			return nil, fmt.Errorf("zone %s: %w", zone.Tag, err)
+		}
+		entries = append(entries, &regionEntry{
+			Tag:         zone.Tag,
+			Kind:        zt.Kind.String(),
+			Flags:       zt.Flags.String(),
+			RegionCount: len(zone.Regions),
+			UserData:    zone.UserData,
+			Zoneline:    zt.Zoneline,
+		})
+	}
+	return entries, nil
+}
+
+func regionsWriteJSON(w io.Writer, name string, entries []*regionEntry) error {
+	out := struct {
+		File  string
+		Zones []*regionEntry
+	}{
+		File:  name,
+		Zones: entries,
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(out)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+	return nil
+}
+
+func regionsWriteText(w io.Writer, name string, entries []*regionEntry) error {
+	fmt.Fprintf(w, "%s: %d zone region group%s\n", name, len(entries), helper.Pluralize(len(entries)))
+	for _, entry := range entries {
+		fmt.Fprintf(w, "  %s kind=%s flags=%s regions=%d", entry.Tag, entry.Kind, entry.Flags, entry.RegionCount)
+		if entry.Zoneline != nil {
+			if entry.Zoneline.IsReference {
+				fmt.Fprintf(w, " zoneline=reference:%d", entry.Zoneline.Index)
+			} else {
+				fmt.Fprintf(w, " zoneline=zone:%d pos:%0.0f,%0.0f,%0.0f heading:%d", entry.Zoneline.ZoneID, entry.Zoneline.Position[0], entry.Zoneline.Position[1], entry.Zoneline.Position[2], entry.Zoneline.Heading)
+			}
+		}
+		if entry.UserData != "" {
+			fmt.Fprintf(w, " userdata=%q", entry.UserData)
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	return nil
+}
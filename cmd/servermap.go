@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/xackery/quail/pfs"
+	"github.com/xackery/quail/quail"
+	"github.com/xackery/quail/raw"
+	"github.com/xackery/quail/wce"
+)
+
+func init() {
+	rootCmd.AddCommand(servermapCmd)
+	servermapCmd.PersistentFlags().String("path", "", "path to zone s3d or eqg")
+	servermapCmd.PersistentFlags().String("out", ".", "directory to write .map and .wtr files to")
+}
+
+// servermapCmd represents the servermap command
+var servermapCmd = &cobra.Command{
+	Use:   "servermap",
+	Short: "Generate EQEmu server .map and .wtr files from a zone",
+	Long: `Generate EQEmu server collision (.map) and water (.wtr) files from a s3d or eqg zone
+For s3d zones, a <zone>_obj.s3d next to the zone is loaded for placed objects
+Example: quail servermap crushbone.s3d
+Example: quail servermap buriedsea.eqg --out maps`,
+	Run: runServermap,
+}
+
+func runServermap(cmd *cobra.Command, args []string) {
+	err := runServermapE(cmd, args)
+	if err != nil {
+		fmt.Printf("Failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func runServermapE(cmd *cobra.Command, args []string) error {
+	path, err := cmd.Flags().GetString("path")
+	if err != nil {
+		return fmt.Errorf("parse path: %w", err)
+	}
+	if path == "" {
+		if len(args) < 1 {
+			return cmd.Usage()
+		}
+		path = args[0]
+	}
+	out, err := cmd.Flags().GetString("out")
+	if err != nil {
+		return fmt.Errorf("parse out: %w", err)
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".s3d" && ext != ".eqg" {
+		return fmt.Errorf("unsupported extension %s, wanted .s3d or .eqg", ext)
+	}
+	zoneName := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	q := quail.New()
+	err = q.PfsRead(path)
+	if err != nil {
+		return fmt.Errorf("pfs read: %w", err)
+	}
+
+	var models *wce.Wce
+	var water *raw.DatWtr
+	if ext == ".s3d" {
+		models, err = zoneLoadObjects(strings.TrimSuffix(path, filepath.Ext(path)) + "_obj.s3d")
+		if err != nil {
+			return err
+		}
+	} else {
+		water, err = servermapLoadWater(path)
+		if err != nil {
+			return err
+		}
+		err = v4TerrainAdd(path, q.Wld)
+		if err != nil {
+			return err
+		}
+	}
+
+	emuMap, emuWtr, err := q.ServerMap(models, water)
+	if err != nil {
+		return fmt.Errorf("server map: %w", err)
+	}
+
+	err = os.MkdirAll(out, 0755)
+	if err != nil {
+		return fmt.Errorf("mkdir: %w", err)
+	}
+
+	mapPath := filepath.Join(out, zoneName+".map")
+	err = rawWriteFile(mapPath, emuMap)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s with %d collidable and %d non collidable triangles\n", mapPath, len(emuMap.Indices)/3, len(emuMap.NonCollideIndices)/3)
+
+	wtrPath := filepath.Join(out, zoneName+".wtr")
+	err = rawWriteFile(wtrPath, emuWtr)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s version %d with %d nodes and %d regions\n", wtrPath, emuWtr.Version, len(emuWtr.Nodes), len(emuWtr.Regions))
+	return nil
+}
+
+// zoneLoadObjects returns the wld of an optional <zone>_obj.s3d
+func zoneLoadObjects(path string) (*wce.Wce, error) {
+	_, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil // object archives are optional
+		}
+		return nil, fmt.Errorf("stat %s: %w", filepath.Base(path), err)
+	}
+	q := quail.New()
+	err = q.PfsRead(path)
+	if err != nil {
+		return nil, fmt.Errorf("pfs read %s: %w", filepath.Base(path), err)
+	}
+	fmt.Printf("Loaded objects %s\n", filepath.Base(path))
+	return q.Wld, nil
+}
+
+// servermapLoadWater returns the optional water.dat of an eqg zone
+func servermapLoadWater(path string) (*raw.DatWtr, error) {
+	archive, err := pfs.NewFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", filepath.Base(path), err)
+	}
+	defer archive.Close()
+
+	data, err := archive.File("water.dat")
+	if err != nil {
+		return nil, nil // water.dat is optional
+	}
+	water := &raw.DatWtr{}
+	err = water.Read(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("water.dat: %w", err)
+	}
+	return water, nil
+}
+
+func rawWriteFile(path string, w raw.Writer) error {
+	buf := &bytes.Buffer{}
+	err := w.Write(buf)
+	if err != nil {
+		return fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+	err = os.WriteFile(path, buf.Bytes(), 0644)
+	if err != nil {
+		return fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
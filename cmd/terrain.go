@@ -0,0 +1,306 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/xackery/quail/pfs"
+	"github.com/xackery/quail/quail"
+)
+
+func init() {
+	rootCmd.AddCommand(terrainCmd)
+	terrainCmd.AddCommand(terrainExportCmd)
+	terrainCmd.AddCommand(terrainImportCmd)
+	terrainExportCmd.PersistentFlags().String("out", "", "folder to write images to, defaults to <zone>_terrain")
+	terrainImportCmd.PersistentFlags().String("in", "", "folder to read images from, defaults to <zone>_terrain")
+	terrainImportCmd.PersistentFlags().String("out", "", "eqg to write, defaults to overwriting the source")
+}
+
+// terrainCmd represents the terrain command
+var terrainCmd = &cobra.Command{
+	Use:   "terrain",
+	Short: "Export and import v4 zone terrain as images",
+	Long: `Export and import v4 zone terrain as images
+Example: quail terrain export arcstone.eqg
+Example: quail terrain import arcstone.eqg`,
+}
+
+// terrainExportCmd represents the terrain export command
+var terrainExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write v4 zone terrain heights, colors and layer masks as png images",
+	Long: `Stitch every tile of a v4 zone .dat into zone wide images:
+height.png is 16 bit grayscale spanning the lowest to highest terrain point
+color.png is the vertex color of every terrain vertex
+mask_<material>.png is the detail mask of each material layer
+terrain.json keeps the height range and mask names for import
+Example: quail terrain export arcstone.eqg
+Example: quail terrain export arcstone.eqg --out arcstone_terrain`,
+	Run: runTerrainExport,
+}
+
+// terrainImportCmd represents the terrain import command
+var terrainImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Write edited terrain images back into a v4 zone .dat",
+	Long: `Read images made by terrain export and write them back into the zone .dat
+Images missing from the folder are left unchanged, as are layers tiles do not already have
+The source eqg is overwritten unless --out is set
+Example: quail terrain import arcstone.eqg
+Example: quail terrain import arcstone.eqg --in arcstone_terrain --out arcstone_edit.eqg`,
+	Run: runTerrainImport,
+}
+
+// terrainInfo is terrain.json
+type terrainInfo struct {
+	HeightMin float32
+	HeightMax float32
+	MaskDim   int
+	Masks     map[string]string // material to file name
+}
+
+var terrainMaskNameRegex = regexp.MustCompile(`[^a-z0-9_\-]+`)
+
+func runTerrainExport(cmd *cobra.Command, args []string) {
+	err := runTerrainExportE(cmd, args)
+	if err != nil {
+		fmt.Printf("Failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func runTerrainExportE(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return cmd.Usage()
+	}
+	srcPath := args[0]
+	dir, err := cmd.Flags().GetString("out")
+	if err != nil {
+		return fmt.Errorf("parse out: %w", err)
+	}
+	if dir == "" {
+		dir = strings.TrimSuffix(srcPath, filepath.Ext(srcPath)) + "_terrain"
+	}
+
+	archive, err := pfs.NewFile(srcPath)
+	if err != nil {
+		return fmt.Errorf("pfs load: %w", err)
+	}
+	dat, _, err := v4DatLoad(archive)
+	archive.Close()
+	if err != nil {
+		return err
+	}
+
+	images, err := quail.TerrainExport(dat)
+	if err != nil {
+		return fmt.Errorf("terrain export: %w", err)
+	}
+
+	err = os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("mkdir: %w", err)
+	}
+	err = terrainWritePng(filepath.Join(dir, "height.png"), images.Height)
+	if err != nil {
+		return err
+	}
+	err = terrainWritePng(filepath.Join(dir, "color.png"), images.Color)
+	if err != nil {
+		return err
+	}
+
+	info := &terrainInfo{
+		HeightMin: images.HeightMin,
+		HeightMax: images.HeightMax,
+		MaskDim:   images.MaskDim,
+		Masks:     map[string]string{},
+	}
+	for material, mask := range images.Masks {
+		name := "mask_" + terrainMaskNameRegex.ReplaceAllString(strings.ToLower(material), "_") + ".png"
+		info.Masks[material] = name
+		err = terrainWritePng(filepath.Join(dir, name), mask)
+		if err != nil {
+			return err
+		}
+	}
+
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal terrain.json: %w", err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "terrain.json"), data, 0644)
+	if err != nil {
+		return fmt.Errorf("write terrain.json: %w", err)
+	}
+
+	fmt.Printf("Exported %d tiles and %d layer masks of %s to %s\n", len(dat.Tiles), len(images.Masks), filepath.Base(srcPath), dir)
+	return nil
+}
+
+func runTerrainImport(cmd *cobra.Command, args []string) {
+	err := runTerrainImportE(cmd, args)
+	if err != nil {
+		fmt.Printf("Failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func runTerrainImportE(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return cmd.Usage()
+	}
+	srcPath := args[0]
+	dir, err := cmd.Flags().GetString("in")
+	if err != nil {
+		return fmt.Errorf("parse in: %w", err)
+	}
+	if dir == "" {
+		dir = strings.TrimSuffix(srcPath, filepath.Ext(srcPath)) + "_terrain"
+	}
+	dstPath, err := cmd.Flags().GetString("out")
+	if err != nil {
+		return fmt.Errorf("parse out: %w", err)
+	}
+	if dstPath == "" {
+		dstPath = srcPath
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "terrain.json"))
+	if err != nil {
+		return fmt.Errorf("read terrain.json: %w", err)
+	}
+	info := &terrainInfo{}
+	err = json.Unmarshal(data, info)
+	if err != nil {
+		return fmt.Errorf("parse terrain.json: %w", err)
+	}
+
+	images := &quail.TerrainImages{
+		HeightMin: info.HeightMin,
+		HeightMax: info.HeightMax,
+		MaskDim:   info.MaskDim,
+		Masks:     map[string]*image.Gray{},
+	}
+	img, err := terrainReadPng(filepath.Join(dir, "height.png"))
+	if err != nil {
+		return err
+	}
+	if img != nil {
+		images.Height = image.NewGray16(img.Bounds())
+		draw.Draw(images.Height, img.Bounds(), img, img.Bounds().Min, draw.Src)
+	}
+	img, err = terrainReadPng(filepath.Join(dir, "color.png"))
+	if err != nil {
+		return err
+	}
+	if img != nil {
+		images.Color = terrainNRGBA(img)
+	}
+	for material, name := range info.Masks {
+		img, err = terrainReadPng(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		if img == nil {
+			continue
+		}
+		mask := image.NewGray(img.Bounds())
+		draw.Draw(mask, img.Bounds(), img, img.Bounds().Min, draw.Src)
+		images.Masks[material] = mask
+	}
+
+	archive, err := pfs.NewFile(srcPath)
+	if err != nil {
+		return fmt.Errorf("pfs load: %w", err)
+	}
+	defer archive.Close()
+	dat, datName, err := v4DatLoad(archive)
+	if err != nil {
+		return err
+	}
+	err = quail.TerrainImport(dat, images)
+	if err != nil {
+		return fmt.Errorf("terrain import: %w", err)
+	}
+
+	buf := &bytes.Buffer{}
+	err = dat.Write(buf)
+	if err != nil {
+		return fmt.Errorf("%s write: %w", datName, err)
+	}
+	err = archive.SetFile(datName, buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("set %s: %w", datName, err)
+	}
+
+	w, err := os.Create(dstPath)
+	if err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+	defer w.Close()
+	err = archive.Write(w)
+	if err != nil {
+		return fmt.Errorf("pfs write: %w", err)
+	}
+
+	fmt.Printf("Imported terrain from %s into %s\n", dir, filepath.Base(dstPath))
+	return nil
+}
+
+func terrainWritePng(path string, img image.Image) error {
+	w, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", filepath.Base(path), err)
+	}
+	defer w.Close()
+	err = png.Encode(w, img)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// terrainReadPng returns nil when the image does not exist
+func terrainReadPng(path string) (image.Image, error) {
+	r, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open %s: %w", filepath.Base(path), err)
+	}
+	defer r.Close()
+	img, err := png.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", filepath.Base(path), err)
+	}
+	return img, nil
+}
+
+// terrainNRGBA converts an image to non premultiplied color, keeping the color of transparent pixels
+func terrainNRGBA(img image.Image) *image.NRGBA {
+	nrgba, ok := img.(*image.NRGBA)
+	if ok {
+		return nrgba
+	}
+	bounds := img.Bounds()
+	out := image.NewNRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			out.Set(x, y, color.NRGBAModel.Convert(img.At(x, y)))
+		}
+	}
+	return out
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/xackery/quail/helper"
+	"github.com/xackery/quail/pfs"
+	"github.com/xackery/quail/quail"
+	"github.com/xackery/quail/wce"
+)
+
+func init() {
+	rootCmd.AddCommand(togCmd)
+	togCmd.PersistentFlags().String("path", "", "path to v4 zone eqg")
+	togCmd.PersistentFlags().Bool("json", false, "output as json")
+}
+
+// togCmd represents the tog command
+var togCmd = &cobra.Command{
+	Use:   "tog",
+	Short: "List toggled object groups (.tog) of a v4 zone and the tiles placing them",
+	Long: `List every .tog object group inside a v4 zone eqg, its objects, and the
+terrain tiles of the zone .dat that reference it
+Example: quail tog arcstone.eqg
+Example: quail tog arcstone.eqg --json`,
+	Run: runTog,
+}
+
+func runTog(cmd *cobra.Command, args []string) {
+	err := runTogE(cmd, args)
+	if err != nil {
+		fmt.Printf("Failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func runTogE(cmd *cobra.Command, args []string) error {
+	path, err := cmd.Flags().GetString("path")
+	if err != nil {
+		return fmt.Errorf("parse path: %w", err)
+	}
+	if path == "" {
+		if len(args) < 1 {
+			return cmd.Usage()
+		}
+		path = args[0]
+	}
+
+	isJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return fmt.Errorf("parse json: %w", err)
+	}
+
+	archive, err := pfs.NewFile(path)
+	if err != nil {
+		return fmt.Errorf("pfs load: %w", err)
+	}
+	dat, _, err := v4DatLoad(archive)
+	archive.Close()
+	if err != nil {
+		return err
+	}
+
+	q := quail.New()
+	err = q.PfsRead(path)
+	if err != nil {
+		return fmt.Errorf("pfs read: %w", err)
+	}
+	if q.Wld == nil {
+		return fmt.Errorf("no wld found in %s", filepath.Base(path))
+	}
+
+	tiles := q.Wld.TogTiles(dat)
+	if isJSON {
+		return togWriteJSON(os.Stdout, filepath.Base(path), q.Wld.TogDefs, tiles)
+	}
+	return togWriteText(os.Stdout, filepath.Base(path), q.Wld.TogDefs, tiles)
+}
+
+// togTileEntry is a tile reference for output
+type togTileEntry struct {
+	Lng      int32
+	Lat      int32
+	Tog      string
+	Position [3]float32
+	IsFound  bool
+}
+
+func togTileEntries(tiles []*wce.TogTile) []*togTileEntry {
+	entries := []*togTileEntry{}
+	for _, tile := range tiles {
+		entries = append(entries, &togTileEntry{
+			Lng:      tile.Lng,
+			Lat:      tile.Lat,
+			Tog:      tile.Ref.Name,
+			Position: tile.Ref.Position,
+			IsFound:  tile.Tog != nil,
+		})
+	}
+	return entries
+}
+
+func togWriteJSON(w io.Writer, name string, togs []*wce.EqgTogDef, tiles []*wce.TogTile) error {
+	out := struct {
+		File  string
+		Togs  []*wce.EqgTogDef
+		Tiles []*togTileEntry
+	}{
+		File:  name,
+		Togs:  togs,
+		Tiles: togTileEntries(tiles),
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(out)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+	return nil
+}
+
+func togWriteText(w io.Writer, name string, togs []*wce.EqgTogDef, tiles []*wce.TogTile) error {
+	fmt.Fprintf(w, "%s: %d object group%s\n", name, len(togs), helper.Pluralize(len(togs)))
+	for _, tog := range togs {
+		fmt.Fprintf(w, "  %s objects=%d\n", tog.Tag, len(tog.Entries))
+		for _, entry := range tog.Entries {
+			fmt.Fprintf(w, "    %s %s %s pos:%0.0f,%0.0f,%0.0f\n", entry.Name, entry.FileType, entry.FileName, entry.Position[0], entry.Position[1], entry.Position[2])
+		}
+	}
+
+	fmt.Fprintf(w, "%d tile reference%s\n", len(tiles), helper.Pluralize(len(tiles)))
+	for _, entry := range togTileEntries(tiles) {
+		fmt.Fprintf(w, "  tile lng=%d lat=%d %s pos:%0.0f,%0.0f,%0.0f", entry.Lng, entry.Lat, entry.Tog, entry.Position[0], entry.Position[1], entry.Position[2])
+		if !entry.IsFound {
+			fmt.Fprintf(w, " (missing)")
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	return nil
+}
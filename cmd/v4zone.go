@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/xackery/quail/pfs"
+	"github.com/xackery/quail/raw"
+	"github.com/xackery/quail/wce"
+)
+
+var errV4ZonNotFound = errors.New("no v4 .zon found")
+
+// v4ZonLoad returns the v4 .zon inside an archive and its file name without extension
+func v4ZonLoad(archive *pfs.Pfs) (*raw.Zon, string, error) {
+	for _, file := range archive.Files() {
+		if strings.ToLower(filepath.Ext(file.Name())) != ".zon" {
+			continue
+		}
+		zon := &raw.Zon{}
+		err := zon.Read(bytes.NewReader(file.Data()))
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", file.Name(), err)
+		}
+		if zon.V4Info.QuadsPerTile == 0 {
+			continue
+		}
+		return zon, strings.TrimSuffix(file.Name(), filepath.Ext(file.Name())), nil
+	}
+	return nil, "", fmt.Errorf("%w in %s", errV4ZonNotFound, archive.Name())
+}
+
+// v4DatLoad returns the terrain .dat named after the v4 .zon inside an archive, and its file name
+func v4DatLoad(archive *pfs.Pfs) (*raw.DatZon, string, error) {
+	zon, name, err := v4ZonLoad(archive)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, file := range archive.Files() {
+		if !strings.EqualFold(file.Name(), name+".dat") {
+			continue
+		}
+		dat := &raw.DatZon{QuadsPerTile: zon.V4Info.QuadsPerTile}
+		err = dat.Read(bytes.NewReader(file.Data()))
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", file.Name(), err)
+		}
+		dat.SetFileName(file.Name())
+		return dat, file.Name(), nil
+	}
+	return nil, "", fmt.Errorf("no %s.dat found in %s", name, archive.Name())
+}
+
+// v4TerrainAdd adds the terrain mesh of a v4 zone archive to a wld read from it, for commands that
+// need zone geometry. Archives without a v4 .zon are left as is
+func v4TerrainAdd(path string, wld *wce.Wce) error {
+	archive, err := pfs.NewFile(path)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", filepath.Base(path), err)
+	}
+	defer archive.Close()
+
+	zon, _, err := v4ZonLoad(archive)
+	if err != nil {
+		if errors.Is(err, errV4ZonNotFound) {
+			return nil
+		}
+		return err
+	}
+	dat, _, err := v4DatLoad(archive)
+	if err != nil {
+		return err
+	}
+	err = wld.AddV4Terrain(&zon.V4Info, dat)
+	if err != nil {
+		return fmt.Errorf("v4 terrain: %w", err)
+	}
+	return nil
+}
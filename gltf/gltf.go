@@ -0,0 +1,516 @@
+// Package gltf reads and writes the subset of glTF 2.0 quail exchanges with modeling tools:
+// meshes with morph targets, and the animations that drive them
+package gltf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Component types of an accessor
+const (
+	ComponentUnsignedByte  = 5121
+	ComponentUnsignedShort = 5123
+	ComponentUnsignedInt   = 5125
+	ComponentFloat         = 5126
+)
+
+// Element types of an accessor
+const (
+	TypeScalar = "SCALAR"
+	TypeVec2   = "VEC2"
+	TypeVec3   = "VEC3"
+	TypeVec4   = "VEC4"
+)
+
+// Buffer view targets
+const (
+	TargetArrayBuffer        = 34962
+	TargetElementArrayBuffer = 34963
+)
+
+// Animation interpolations
+const (
+	InterpolationLinear      = "LINEAR"
+	InterpolationStep        = "STEP"
+	InterpolationCubicSpline = "CUBICSPLINE"
+)
+
+const (
+	glbMagic     = 0x46546C67 // glTF
+	glbChunkJSON = 0x4E4F534A // JSON
+	glbChunkBin  = 0x004E4942 // BIN
+	dataURIBase  = "data:application/octet-stream;base64,"
+)
+
+var typeSizes = map[string]int{
+	TypeScalar: 1,
+	TypeVec2:   2,
+	TypeVec3:   3,
+	TypeVec4:   4,
+}
+
+var componentSizes = map[int]int{
+	ComponentUnsignedByte:  1,
+	ComponentUnsignedShort: 2,
+	ComponentUnsignedInt:   4,
+	ComponentFloat:         4,
+}
+
+// Document is a glTF asset. Every accessor built by quail lives in buffer 0
+type Document struct {
+	Asset       Asset         `json:"asset"`
+	Scene       *int          `json:"scene,omitempty"`
+	Scenes      []*Scene      `json:"scenes,omitempty"`
+	Nodes       []*Node       `json:"nodes,omitempty"`
+	Meshes      []*Mesh       `json:"meshes,omitempty"`
+	Accessors   []*Accessor   `json:"accessors,omitempty"`
+	BufferViews []*BufferView `json:"bufferViews,omitempty"`
+	Buffers     []*Buffer     `json:"buffers,omitempty"`
+	Animations  []*Animation  `json:"animations,omitempty"`
+
+	data [][]byte // contents of each buffer
+}
+
+// Asset is the glTF version header
+type Asset struct {
+	Generator string `json:"generator,omitempty"`
+	Version   string `json:"version"`
+}
+
+// Scene lists the root nodes shown
+type Scene struct {
+	Name  string `json:"name,omitempty"`
+	Nodes []int  `json:"nodes,omitempty"`
+}
+
+// Node places a mesh, moved by either its column major matrix or its translation, rotation
+// (x, y, z, w) and scale
+type Node struct {
+	Name        string    `json:"name,omitempty"`
+	Mesh        *int      `json:"mesh,omitempty"`
+	Children    []int     `json:"children,omitempty"`
+	Matrix      []float32 `json:"matrix,omitempty"`
+	Translation []float32 `json:"translation,omitempty"`
+	Rotation    []float32 `json:"rotation,omitempty"`
+	Scale       []float32 `json:"scale,omitempty"`
+}
+
+// Mesh is a set of primitives sharing morph target weights
+type Mesh struct {
+	Name       string       `json:"name,omitempty"`
+	Primitives []*Primitive `json:"primitives"`
+	Weights    []float32    `json:"weights,omitempty"`
+	Extras     *MeshExtras  `json:"extras,omitempty"`
+}
+
+// MeshExtras holds the morph target names modeling tools show
+type MeshExtras struct {
+	TargetNames []string `json:"targetNames,omitempty"`
+}
+
+// Primitive is a triangle list with optional morph targets
+type Primitive struct {
+	Attributes map[string]int   `json:"attributes"`
+	Indices    *int             `json:"indices,omitempty"`
+	Mode       *int             `json:"mode,omitempty"`
+	Targets    []map[string]int `json:"targets,omitempty"`
+}
+
+// Accessor is a typed view into a buffer view
+type Accessor struct {
+	BufferView    *int      `json:"bufferView,omitempty"`
+	ByteOffset    int       `json:"byteOffset,omitempty"`
+	ComponentType int       `json:"componentType"`
+	Normalized    bool      `json:"normalized,omitempty"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float32 `json:"min,omitempty"`
+	Max           []float32 `json:"max,omitempty"`
+	Sparse        *Sparse   `json:"sparse,omitempty"`
+}
+
+// Sparse replaces some elements of an accessor
+type Sparse struct {
+	Count   int           `json:"count"`
+	Indices SparseIndices `json:"indices"`
+	Values  SparseValues  `json:"values"`
+}
+
+// SparseIndices are the elements a sparse accessor replaces
+type SparseIndices struct {
+	BufferView    int `json:"bufferView"`
+	ByteOffset    int `json:"byteOffset,omitempty"`
+	ComponentType int `json:"componentType"`
+}
+
+// SparseValues are the replacement elements of a sparse accessor
+type SparseValues struct {
+	BufferView int `json:"bufferView"`
+	ByteOffset int `json:"byteOffset,omitempty"`
+}
+
+// BufferView is a slice of a buffer
+type BufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset,omitempty"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride,omitempty"`
+	Target     int `json:"target,omitempty"`
+}
+
+// Buffer is binary data, embedded as a data uri or the glb binary chunk
+type Buffer struct {
+	ByteLength int    `json:"byteLength"`
+	URI        string `json:"uri,omitempty"`
+}
+
+// Animation plays samplers on node properties
+type Animation struct {
+	Name     string     `json:"name,omitempty"`
+	Channels []*Channel `json:"channels"`
+	Samplers []*Sampler `json:"samplers"`
+}
+
+// Channel binds a sampler to a node property
+type Channel struct {
+	Sampler int           `json:"sampler"`
+	Target  ChannelTarget `json:"target"`
+}
+
+// ChannelTarget is the node property a channel animates, like weights
+type ChannelTarget struct {
+	Node *int   `json:"node,omitempty"`
+	Path string `json:"path"`
+}
+
+// Sampler maps key times (input) to values (output)
+type Sampler struct {
+	Input         int    `json:"input"`
+	Interpolation string `json:"interpolation,omitempty"`
+	Output        int    `json:"output"`
+}
+
+// New returns an empty document
+func New() *Document {
+	return &Document{
+		Asset: Asset{Generator: "quail", Version: "2.0"},
+	}
+}
+
+// Int returns a pointer to v, for optional indexes
+func Int(v int) *int {
+	return &v
+}
+
+// AddFloats adds an accessor of float elements of typ, returning its index
+func (d *Document) AddFloats(values []float32, typ string, target int) int {
+	size := typeSizes[typ]
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.LittleEndian, values)
+	accessor := &Accessor{
+		BufferView:    Int(d.addBufferView(buf.Bytes(), target)),
+		ComponentType: ComponentFloat,
+		Count:         len(values) / size,
+		Type:          typ,
+	}
+	if typ == TypeVec3 || typ == TypeScalar {
+		// positions and animation inputs must have bounds
+		accessor.Min = make([]float32, size)
+		accessor.Max = make([]float32, size)
+		for i, v := range values {
+			c := i % size
+			if i < size || v < accessor.Min[c] {
+				accessor.Min[c] = v
+			}
+			if i < size || v > accessor.Max[c] {
+				accessor.Max[c] = v
+			}
+		}
+	}
+	d.Accessors = append(d.Accessors, accessor)
+	return len(d.Accessors) - 1
+}
+
+// AddIndices adds an accessor of triangle indices, returning its index
+func (d *Document) AddIndices(values []uint32) int {
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.LittleEndian, values)
+	d.Accessors = append(d.Accessors, &Accessor{
+		BufferView:    Int(d.addBufferView(buf.Bytes(), TargetElementArrayBuffer)),
+		ComponentType: ComponentUnsignedInt,
+		Count:         len(values),
+		Type:          TypeScalar,
+	})
+	return len(d.Accessors) - 1
+}
+
+func (d *Document) addBufferView(data []byte, target int) int {
+	if len(d.Buffers) == 0 {
+		d.Buffers = append(d.Buffers, &Buffer{})
+		d.data = append(d.data, nil)
+	}
+	view := &BufferView{
+		ByteOffset: len(d.data[0]),
+		ByteLength: len(data),
+		Target:     target,
+	}
+	d.data[0] = append(d.data[0], data...)
+	for len(d.data[0])%4 != 0 {
+		d.data[0] = append(d.data[0], 0)
+	}
+	d.Buffers[0].ByteLength = len(d.data[0])
+	d.BufferViews = append(d.BufferViews, view)
+	return len(d.BufferViews) - 1
+}
+
+// Floats returns the elements of a float accessor, flattened
+func (d *Document) Floats(index int) ([]float32, error) {
+	if index < 0 || index >= len(d.Accessors) {
+		return nil, fmt.Errorf("accessor %d out of range", index)
+	}
+	accessor := d.Accessors[index]
+	if accessor.ComponentType != ComponentFloat {
+		return nil, fmt.Errorf("accessor %d component type %d is not float", index, accessor.ComponentType)
+	}
+	size, ok := typeSizes[accessor.Type]
+	if !ok {
+		return nil, fmt.Errorf("accessor %d type %s unsupported", index, accessor.Type)
+	}
+
+	values := make([]float32, accessor.Count*size)
+	if accessor.BufferView != nil {
+		data, stride, err := d.viewData(*accessor.BufferView, accessor.ByteOffset, accessor.Count, size*4)
+		if err != nil {
+			return nil, fmt.Errorf("accessor %d: %w", index, err)
+		}
+		for i := 0; i < accessor.Count; i++ {
+			for c := 0; c < size; c++ {
+				values[i*size+c] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*stride+c*4:]))
+			}
+		}
+	}
+
+	if accessor.Sparse == nil {
+		return values, nil
+	}
+	sparse := accessor.Sparse
+	indices, err := d.indexData(sparse.Indices.BufferView, sparse.Indices.ByteOffset, sparse.Count, sparse.Indices.ComponentType)
+	if err != nil {
+		return nil, fmt.Errorf("accessor %d sparse indices: %w", index, err)
+	}
+	data, _, err := d.viewData(sparse.Values.BufferView, sparse.Values.ByteOffset, sparse.Count, size*4)
+	if err != nil {
+		return nil, fmt.Errorf("accessor %d sparse values: %w", index, err)
+	}
+	for i, element := range indices {
+		if int(element) >= accessor.Count {
+			return nil, fmt.Errorf("accessor %d sparse index %d out of range", index, element)
+		}
+		for c := 0; c < size; c++ {
+			values[int(element)*size+c] = math.Float32frombits(binary.LittleEndian.Uint32(data[(i*size+c)*4:]))
+		}
+	}
+	return values, nil
+}
+
+// Indices returns the elements of an unsigned integer scalar accessor
+func (d *Document) Indices(index int) ([]uint32, error) {
+	if index < 0 || index >= len(d.Accessors) {
+		return nil, fmt.Errorf("accessor %d out of range", index)
+	}
+	accessor := d.Accessors[index]
+	if accessor.BufferView == nil {
+		return nil, fmt.Errorf("accessor %d has no buffer view", index)
+	}
+	values, err := d.indexData(*accessor.BufferView, accessor.ByteOffset, accessor.Count, accessor.ComponentType)
+	if err != nil {
+		return nil, fmt.Errorf("accessor %d: %w", index, err)
+	}
+	return values, nil
+}
+
+func (d *Document) indexData(view int, offset int, count int, componentType int) ([]uint32, error) {
+	size, ok := componentSizes[componentType]
+	if !ok || componentType == ComponentFloat {
+		return nil, fmt.Errorf("component type %d is not an index", componentType)
+	}
+	data, stride, err := d.viewData(view, offset, count, size)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]uint32, count)
+	for i := range values {
+		switch size {
+		case 1:
+			values[i] = uint32(data[i*stride])
+		case 2:
+			values[i] = uint32(binary.LittleEndian.Uint16(data[i*stride:]))
+		default:
+			values[i] = binary.LittleEndian.Uint32(data[i*stride:])
+		}
+	}
+	return values, nil
+}
+
+// viewData returns the bytes of count elements of elementSize in a buffer view, and the stride between them
+func (d *Document) viewData(index int, offset int, count int, elementSize int) ([]byte, int, error) {
+	if index < 0 || index >= len(d.BufferViews) {
+		return nil, 0, fmt.Errorf("buffer view %d out of range", index)
+	}
+	view := d.BufferViews[index]
+	if view.Buffer < 0 || view.Buffer >= len(d.data) {
+		return nil, 0, fmt.Errorf("buffer %d out of range", view.Buffer)
+	}
+	stride := view.ByteStride
+	if stride == 0 {
+		stride = elementSize
+	}
+	start := view.ByteOffset + offset
+	end := start + stride*(count-1) + elementSize
+	if count == 0 {
+		end = start
+	}
+	buffer := d.data[view.Buffer]
+	if start < 0 || end > len(buffer) || end > view.ByteOffset+view.ByteLength {
+		return nil, 0, fmt.Errorf("buffer view %d too short", index)
+	}
+	return buffer[start:end], stride, nil
+}
+
+// Write writes the document as a .gltf, with buffers embedded as data uris
+func (d *Document) Write(w io.Writer) error {
+	for i, buffer := range d.Buffers {
+		buffer.URI = dataURIBase + base64.StdEncoding.EncodeToString(d.data[i])
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(d)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+	return nil
+}
+
+// WriteGLB writes the document as a binary .glb, with buffer 0 as its binary chunk
+func (d *Document) WriteGLB(w io.Writer) error {
+	if len(d.Buffers) > 1 {
+		return fmt.Errorf("glb holds one buffer, document has %d", len(d.Buffers))
+	}
+	for _, buffer := range d.Buffers {
+		buffer.URI = ""
+	}
+	doc, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+	for len(doc)%4 != 0 {
+		doc = append(doc, ' ')
+	}
+
+	length := 12 + 8 + len(doc)
+	if len(d.data) > 0 {
+		length += 8 + len(d.data[0])
+	}
+	header := []uint32{glbMagic, 2, uint32(length), uint32(len(doc)), glbChunkJSON}
+	err = binary.Write(w, binary.LittleEndian, header)
+	if err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+	_, err = w.Write(doc)
+	if err != nil {
+		return fmt.Errorf("write json: %w", err)
+	}
+	if len(d.data) == 0 {
+		return nil
+	}
+	err = binary.Write(w, binary.LittleEndian, []uint32{uint32(len(d.data[0])), glbChunkBin})
+	if err != nil {
+		return fmt.Errorf("write bin header: %w", err)
+	}
+	_, err = w.Write(d.data[0])
+	if err != nil {
+		return fmt.Errorf("write bin: %w", err)
+	}
+	return nil
+}
+
+// Read reads a .gltf with embedded buffers, or a binary .glb
+func Read(r io.Reader) (*Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	var bin []byte
+	isGLB := len(data) >= 12 && binary.LittleEndian.Uint32(data) == glbMagic
+	if isGLB {
+		data, bin, err = readGLBChunks(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	d := &Document{}
+	err = json.Unmarshal(data, d)
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	for i, buffer := range d.Buffers {
+		switch {
+		case buffer.URI == "" && isGLB && i == 0:
+			d.data = append(d.data, bin)
+		case strings.HasPrefix(buffer.URI, "data:"):
+			comma := strings.Index(buffer.URI, ",")
+			if comma < 0 || !strings.Contains(buffer.URI[:comma], ";base64") {
+				return nil, fmt.Errorf("buffer %d data uri is not base64", i)
+			}
+			raw, err := base64.StdEncoding.DecodeString(buffer.URI[comma+1:])
+			if err != nil {
+				return nil, fmt.Errorf("buffer %d: %w", i, err)
+			}
+			d.data = append(d.data, raw)
+		default:
+			return nil, fmt.Errorf("buffer %d uri %s is external, export with embedded buffers or as glb", i, buffer.URI)
+		}
+		if len(d.data[i]) < buffer.ByteLength {
+			return nil, fmt.Errorf("buffer %d is %d bytes, want %d", i, len(d.data[i]), buffer.ByteLength)
+		}
+	}
+	return d, nil
+}
+
+// readGLBChunks returns the json and binary chunks of a glb
+func readGLBChunks(data []byte) ([]byte, []byte, error) {
+	version := binary.LittleEndian.Uint32(data[4:])
+	if version != 2 {
+		return nil, nil, fmt.Errorf("glb version %d unsupported", version)
+	}
+	var doc, bin []byte
+	offset := 12
+	for offset+8 <= len(data) {
+		length := int(binary.LittleEndian.Uint32(data[offset:]))
+		chunkType := binary.LittleEndian.Uint32(data[offset+4:])
+		offset += 8
+		if offset+length > len(data) {
+			return nil, nil, fmt.Errorf("glb chunk at %d too short", offset)
+		}
+		switch chunkType {
+		case glbChunkJSON:
+			doc = data[offset : offset+length]
+		case glbChunkBin:
+			bin = data[offset : offset+length]
+		}
+		offset += length
+	}
+	if doc == nil {
+		return nil, nil, fmt.Errorf("glb has no json chunk")
+	}
+	return doc, bin, nil
+}
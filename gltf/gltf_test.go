@@ -0,0 +1,75 @@
+package gltf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReadWrite(t *testing.T) {
+	doc := New()
+	positions := doc.AddFloats([]float32{0, 0, 0, 1, 0, 0, 0, 1, -2}, TypeVec3, TargetArrayBuffer)
+	indices := doc.AddIndices([]uint32{0, 1, 2})
+	doc.Meshes = append(doc.Meshes, &Mesh{Primitives: []*Primitive{{Attributes: map[string]int{"POSITION": positions}, Indices: Int(indices)}}})
+
+	if doc.Accessors[positions].Min[2] != -2 || doc.Accessors[positions].Max[0] != 1 {
+		t.Fatalf("bounds %v %v not set", doc.Accessors[positions].Min, doc.Accessors[positions].Max)
+	}
+
+	for _, glb := range []bool{false, true} {
+		buf := &bytes.Buffer{}
+		var err error
+		if glb {
+			err = doc.WriteGLB(buf)
+		} else {
+			err = doc.Write(buf)
+		}
+		if err != nil {
+			t.Fatalf("write glb %t: %v", glb, err)
+		}
+		got, err := Read(buf)
+		if err != nil {
+			t.Fatalf("read glb %t: %v", glb, err)
+		}
+		values, err := got.Floats(got.Meshes[0].Primitives[0].Attributes["POSITION"])
+		if err != nil {
+			t.Fatalf("floats glb %t: %v", glb, err)
+		}
+		if len(values) != 9 || values[8] != -2 {
+			t.Fatalf("glb %t positions %v", glb, values)
+		}
+		tris, err := got.Indices(*got.Meshes[0].Primitives[0].Indices)
+		if err != nil {
+			t.Fatalf("indices glb %t: %v", glb, err)
+		}
+		if len(tris) != 3 || tris[2] != 2 {
+			t.Fatalf("glb %t indices %v", glb, tris)
+		}
+	}
+}
+
+func TestSparse(t *testing.T) {
+	doc := New()
+	doc.AddFloats([]float32{5, 6, 7}, TypeVec3, 0)
+	doc.AddIndices([]uint32{1})
+	// two zero vec3 with the second replaced by 5 6 7
+	doc.Accessors = append(doc.Accessors, &Accessor{
+		ComponentType: ComponentFloat,
+		Count:         2,
+		Type:          TypeVec3,
+		Sparse: &Sparse{
+			Count:   1,
+			Indices: SparseIndices{BufferView: 1, ComponentType: ComponentUnsignedInt},
+			Values:  SparseValues{BufferView: 0},
+		},
+	})
+	values, err := doc.Floats(2)
+	if err != nil {
+		t.Fatalf("floats: %v", err)
+	}
+	want := []float32{0, 0, 0, 5, 6, 7}
+	for i := range want {
+		if values[i] != want[i] {
+			t.Fatalf("got %v, want %v", values, want)
+		}
+	}
+}
@@ -320,3 +320,212 @@ func takeNumber(rest *string, prefix string) uint32 {
 	*rest = t[i:]
 	return num
 }
+
+// ——— Render styles ————————————————————————————————————————————————————
+
+// RenderKind is how a surface lets what is behind it show through
+type RenderKind int
+
+const (
+	RenderOpaque RenderKind = iota
+	RenderMasked
+	RenderBlended
+	RenderAdditive
+	RenderInvisible
+)
+
+var renderKindNames = map[RenderKind]string{
+	RenderOpaque:    "opaque",
+	RenderMasked:    "masked",
+	RenderBlended:   "blended",
+	RenderAdditive:  "additive",
+	RenderInvisible: "invisible",
+}
+
+func (k RenderKind) String() string {
+	return renderKindNames[k]
+}
+
+// RenderStyle is how a surface is drawn, the part of a wld render method and an eqg shader
+// both can express
+type RenderStyle struct {
+	Kind    RenderKind
+	Unlit   bool
+	Opacity float32 // 0 to 1, for blended surfaces
+}
+
+type renderStyleEntry struct {
+	name       string
+	style      RenderStyle
+	properties []string // material properties a shader reads, all of which conversion writes
+}
+
+// renderMethodStyles are the render methods written by conversion, first match wins
+var renderMethodStyles = []renderStyleEntry{
+	{"USERDEFINED_2", RenderStyle{Kind: RenderOpaque}, nil},
+	{"USERDEFINED_20", RenderStyle{Kind: RenderMasked}, nil},
+	{"USERDEFINED_6", RenderStyle{Kind: RenderBlended, Opacity: 0.5}, nil},
+	{"USERDEFINED_10", RenderStyle{Kind: RenderBlended, Opacity: 0.25}, nil},
+	{"USERDEFINED_11", RenderStyle{Kind: RenderBlended, Opacity: 0.75}, nil},
+	{"USERDEFINED_24", RenderStyle{Kind: RenderAdditive}, nil},
+	{"USERDEFINED_12", RenderStyle{Kind: RenderAdditive, Unlit: true}, nil},
+	{"TRANSPARENT", RenderStyle{Kind: RenderInvisible}, nil},
+	{"USERDEFINED_21", RenderStyle{Kind: RenderOpaque}, nil},
+	{"USERDEFINED_22", RenderStyle{Kind: RenderOpaque}, nil},
+	{"USERDEFINED_84", RenderStyle{Kind: RenderOpaque}, nil},
+}
+
+// shaderStyles are the eqg shaders written by conversion, first match wins. A wld material only
+// has a diffuse texture, so these read no normal or environment map. The glow map of the G shaders
+// lights a surface by itself, so unlit surfaces use their diffuse texture as it
+var shaderStyles = []renderStyleEntry{
+	{"Opaque_MaxC1.fx", RenderStyle{Kind: RenderOpaque}, []string{"e_TextureDiffuse0"}},
+	{"Alpha_MaxC1.fx", RenderStyle{Kind: RenderMasked}, []string{"e_TextureDiffuse0"}},
+	{"AddAlpha_MaxC1.fx", RenderStyle{Kind: RenderAdditive}, []string{"e_TextureDiffuse0"}},
+	{"Opaque_MaxCG1.fx", RenderStyle{Kind: RenderOpaque, Unlit: true}, []string{"e_TextureDiffuse0", "e_TextureGlow0"}},
+	{"Alpha_MaxCG1.fx", RenderStyle{Kind: RenderMasked, Unlit: true}, []string{"e_TextureDiffuse0", "e_TextureGlow0"}},
+	{"AddAlpha_MaxCG1.fx", RenderStyle{Kind: RenderAdditive, Unlit: true}, []string{"e_TextureDiffuse0", "e_TextureGlow0"}},
+}
+
+// renderKindFallbacks are the kinds to draw a kind as when a format has no match, closest first
+var renderKindFallbacks = map[RenderKind][]RenderKind{
+	RenderMasked:    {RenderOpaque},
+	RenderBlended:   {RenderMasked, RenderOpaque},
+	RenderAdditive:  {RenderBlended, RenderMasked, RenderOpaque},
+	RenderInvisible: {RenderMasked, RenderOpaque},
+}
+
+// RenderMethodStyle returns the style of a wld render method
+func RenderMethodStyle(renderMethod string) RenderStyle {
+	for _, entry := range renderMethodStyles {
+		if entry.name == renderMethod {
+			return entry.style
+		}
+	}
+
+	v := RenderMethodInt(renderMethod)
+	if v == 0 {
+		return RenderStyle{Kind: RenderInvisible}
+	}
+	if (v>>bfUserDefinedShift)&bfUserDefinedMask == 1 {
+		// user defined methods not in the table are drawn as plain diffuse
+		return RenderStyle{Kind: RenderOpaque}
+	}
+
+	style := RenderStyle{Kind: RenderOpaque}
+	if (v>>bfMaskedShift)&bfMaskedMask == 1 {
+		style.Kind = RenderMasked
+	}
+	if (v>>bfAlphaToggleShift)&bfAlphaToggleMask == 1 {
+		style.Kind = RenderBlended
+		style.Opacity = float32((v>>bfAlphaShift)&bfAlphaMask) / 16
+		if style.Opacity == 0 {
+			style.Opacity = 0.5
+		}
+	}
+	if (v>>bfAdditiveShift)&bfAdditiveMask == 1 {
+		style.Kind = RenderAdditive
+		style.Opacity = 0
+	}
+	if (v>>bfLightingShift)&bfLightingMask == lightingCodes["CONSTANT"] {
+		style.Unlit = true
+	}
+	return style
+}
+
+// ShaderStyle returns the style of an eqg shader
+func ShaderStyle(shader string) RenderStyle {
+	for _, entry := range shaderStyles {
+		if strings.EqualFold(entry.name, shader) {
+			return entry.style
+		}
+	}
+	name := strings.ToLower(shader)
+	switch {
+	case strings.HasPrefix(name, "addalpha"):
+		return RenderStyle{Kind: RenderAdditive}
+	case strings.HasPrefix(name, "alpha"), strings.HasPrefix(name, "chroma"):
+		return RenderStyle{Kind: RenderMasked}
+	case strings.Contains(name, "water"):
+		return RenderStyle{Kind: RenderBlended, Opacity: 0.5}
+	}
+	return RenderStyle{Kind: RenderOpaque}
+}
+
+// ShaderProperties returns the material properties a shader written by conversion reads, or nil
+// for other shaders
+func ShaderProperties(shader string) []string {
+	for _, entry := range shaderStyles {
+		if strings.EqualFold(entry.name, shader) {
+			return entry.properties
+		}
+	}
+	return nil
+}
+
+// RenderStyleMethod returns the wld render method closest to style, with a note for each part of
+// style the render method does not keep
+func RenderStyleMethod(style RenderStyle) (string, []string) {
+	return renderStyleClosest(renderMethodStyles, style)
+}
+
+// RenderStyleShader returns the eqg shader closest to style, with a note for each part of style
+// the shader does not keep
+func RenderStyleShader(style RenderStyle) (string, []string) {
+	return renderStyleClosest(shaderStyles, style)
+}
+
+// RenderMethodShader returns the eqg shader closest to a wld render method, with a note for each
+// part of the render method the shader does not keep
+func RenderMethodShader(renderMethod string) (string, []string) {
+	return RenderStyleShader(RenderMethodStyle(renderMethod))
+}
+
+// ShaderRenderMethod returns the wld render method closest to an eqg shader, with a note for each
+// part of the shader the render method does not keep
+func ShaderRenderMethod(shader string) (string, []string) {
+	return RenderStyleMethod(ShaderStyle(shader))
+}
+
+// renderStyleClosest picks the entry of the kind of style, falling back to similar kinds, then the
+// entry with the same lighting and nearest opacity
+func renderStyleClosest(entries []renderStyleEntry, style RenderStyle) (string, []string) {
+	kinds := append([]RenderKind{style.Kind}, renderKindFallbacks[style.Kind]...)
+	for _, kind := range kinds {
+		var best *renderStyleEntry
+		bestScore := float32(0)
+		for i := range entries {
+			entry := &entries[i]
+			if entry.style.Kind != kind {
+				continue
+			}
+			score := float32(math.Abs(float64(entry.style.Opacity - style.Opacity)))
+			if entry.style.Unlit != style.Unlit {
+				score += 2
+			}
+			if best == nil || score < bestScore {
+				best = entry
+				bestScore = score
+			}
+		}
+		if best == nil {
+			continue
+		}
+
+		notes := []string{}
+		if best.style.Kind != style.Kind {
+			notes = append(notes, fmt.Sprintf("%s drawn as %s", style.Kind, best.style.Kind))
+		}
+		if style.Unlit && !best.style.Unlit {
+			notes = append(notes, "unlit drawn lit")
+		}
+		if !style.Unlit && best.style.Unlit {
+			notes = append(notes, "lit drawn unlit")
+		}
+		if style.Kind == RenderBlended && best.style.Kind == RenderBlended && best.style.Opacity != style.Opacity {
+			notes = append(notes, fmt.Sprintf("opacity %.1f%% drawn as %.1f%%", style.Opacity*100, best.style.Opacity*100))
+		}
+		return best.name, notes
+	}
+	return entries[0].name, []string{fmt.Sprintf("%s drawn as %s", style.Kind, entries[0].style.Kind)}
+}
@@ -0,0 +1,73 @@
+package helper
+
+import (
+	"testing"
+)
+
+func TestRenderMethodShader(t *testing.T) {
+	tests := []struct {
+		name         string
+		renderMethod string
+		want         string
+		wantLossy    bool
+	}{
+		{name: "diffuse", renderMethod: "USERDEFINED_2", want: "Opaque_MaxC1.fx"},
+		{name: "masked", renderMethod: "USERDEFINED_20", want: "Alpha_MaxC1.fx"},
+		{name: "additive", renderMethod: "USERDEFINED_24", want: "AddAlpha_MaxC1.fx"},
+		{name: "blended", renderMethod: "USERDEFINED_6", want: "Alpha_MaxC1.fx", wantLossy: true},
+		{name: "unlit additive", renderMethod: "USERDEFINED_12", want: "AddAlpha_MaxCG1.fx"},
+		{name: "invisible", renderMethod: "TRANSPARENT", want: "Alpha_MaxC1.fx", wantLossy: true},
+		{name: "bitfield masked", renderMethod: "TRANSSOLIDFILLAMBIENTGOURAUD1", want: "Alpha_MaxC1.fx"},
+		{name: "bitfield opaque", renderMethod: "SOLIDFILLAMBIENTGOURAUD1", want: "Opaque_MaxC1.fx"},
+		{name: "bitfield unlit", renderMethod: "SOLIDFILLCONSTANT", want: "Opaque_MaxCG1.fx"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, notes := RenderMethodShader(tt.renderMethod)
+			if got != tt.want {
+				t.Fatalf("RenderMethodShader() = %v, want %v", got, tt.want)
+			}
+			if (len(notes) > 0) != tt.wantLossy {
+				t.Fatalf("RenderMethodShader() notes = %v, want lossy %v", notes, tt.wantLossy)
+			}
+		})
+	}
+}
+
+func TestShaderRenderMethod(t *testing.T) {
+	tests := []struct {
+		name   string
+		shader string
+		want   string
+	}{
+		{name: "opaque", shader: "Opaque_MaxCB1.fx", want: "USERDEFINED_2"},
+		{name: "other opaque", shader: "Opaque_MPLBasic.fx", want: "USERDEFINED_2"},
+		{name: "alpha", shader: "Alpha_MaxCBSG1.fx", want: "USERDEFINED_20"},
+		{name: "chroma", shader: "Chroma_MaxC1.fx", want: "USERDEFINED_20"},
+		{name: "additive", shader: "AddAlpha_MaxC1.fx", want: "USERDEFINED_24"},
+		{name: "water", shader: "Opaque_MaxWater.fx", want: "USERDEFINED_6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, notes := ShaderRenderMethod(tt.shader)
+			if got != tt.want {
+				t.Fatalf("ShaderRenderMethod() = %v, want %v", got, tt.want)
+			}
+			if len(notes) > 0 {
+				t.Fatalf("ShaderRenderMethod() notes = %v, want none", notes)
+			}
+		})
+	}
+
+	// each shader written by conversion maps back to the render method it came from
+	for _, entry := range renderMethodStyles[:7] {
+		shader, notes := RenderMethodShader(entry.name)
+		if len(notes) > 0 {
+			continue
+		}
+		got, _ := ShaderRenderMethod(shader)
+		if got != entry.name {
+			t.Fatalf("%s to %s back to %s", entry.name, shader, got)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package mesh
+
+import (
+	"container/heap"
+	"math"
+)
+
+// quadric is a symmetric 4x4 error matrix stored as its upper triangle
+type quadric [10]float64
+
+func planeQuadric(n [3]float64, d float64) quadric {
+	a, b, c := n[0], n[1], n[2]
+	return quadric{a * a, a * b, a * c, a * d, b * b, b * c, b * d, c * c, c * d, d * d}
+}
+
+func (q *quadric) add(o quadric) {
+	for i := range q {
+		q[i] += o[i]
+	}
+}
+
+// cost returns the squared distance error of placing a vertex at p
+func (q quadric) cost(p [3]float32) float64 {
+	x, y, z := float64(p[0]), float64(p[1]), float64(p[2])
+	return q[0]*x*x + 2*q[1]*x*y + 2*q[2]*x*z + 2*q[3]*x +
+		q[4]*y*y + 2*q[5]*y*z + 2*q[6]*y +
+		q[7]*z*z + 2*q[8]*z + q[9]
+}
+
+// collapse is a candidate to move vertex from onto vertex to
+type collapse struct {
+	cost        float64
+	from        int
+	to          int
+	fromVersion int
+	toVersion   int
+}
+
+type collapseHeap []*collapse
+
+func (h collapseHeap) Len() int            { return len(h) }
+func (h collapseHeap) Less(i, j int) bool  { return h[i].cost < h[j].cost }
+func (h collapseHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *collapseHeap) Push(x interface{}) { *h = append(*h, x.(*collapse)) }
+func (h *collapseHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// Decimate reduces the mesh to at most targetFaces faces with quadric error metric half edge
+// collapses, so every remaining vertex is an original vertex and keeps its data.
+// Vertices on open edges, on UV seams (a position shared by several vertices) and between faces
+// of different groups are locked, as are vertices isLocked (optional) reports.
+// The returned keep lists map each new vertex and face to the original it came from
+func (m *Mesh) Decimate(targetFaces int, isLocked func(vertex int) bool) ([]int, []int) {
+	vertexCount := len(m.Positions)
+	faces := make([][3]int, len(m.Faces))
+	isFaceAlive := make([]bool, len(m.Faces))
+	vertexFaces := make([][]int, vertexCount)
+	quadrics := make([]quadric, vertexCount)
+	for i, face := range m.Faces {
+		isFaceAlive[i] = true
+		for j := 0; j < 3; j++ {
+			faces[i][j] = int(face.Index[j])
+			vertexFaces[face.Index[j]] = append(vertexFaces[face.Index[j]], i)
+		}
+		n := normalize(m.faceNormal(face))
+		if n == [3]float32{} {
+			continue
+		}
+		p := m.Positions[face.Index[0]]
+		nd := [3]float64{float64(n[0]), float64(n[1]), float64(n[2])}
+		q := planeQuadric(nd, -(nd[0]*float64(p[0]) + nd[1]*float64(p[1]) + nd[2]*float64(p[2])))
+		for _, index := range face.Index {
+			quadrics[index].add(q)
+		}
+	}
+
+	locked := m.decimateLocked(isLocked)
+
+	versions := make([]int, vertexCount)
+	h := &collapseHeap{}
+	push := func(from int, to int) {
+		if locked[from] {
+			return
+		}
+		q := quadrics[from]
+		q.add(quadrics[to])
+		heap.Push(h, &collapse{cost: q.cost(m.Positions[to]), from: from, to: to, fromVersion: versions[from], toVersion: versions[to]})
+	}
+	for _, face := range faces {
+		for j := 0; j < 3; j++ {
+			push(face[j], face[(j+1)%3])
+			push(face[(j+1)%3], face[j])
+		}
+	}
+
+	faceCount := len(m.Faces)
+	for faceCount > targetFaces && h.Len() > 0 {
+		c := heap.Pop(h).(*collapse)
+		if c.fromVersion != versions[c.from] || c.toVersion != versions[c.to] {
+			continue
+		}
+		if !m.decimateCanCollapse(faces, isFaceAlive, vertexFaces[c.from], c.from, c.to) {
+			continue
+		}
+
+		neighbours := map[int]bool{}
+		for _, faceIndex := range vertexFaces[c.from] {
+			if !isFaceAlive[faceIndex] {
+				continue
+			}
+			face := &faces[faceIndex]
+			hasTo := face[0] == c.to || face[1] == c.to || face[2] == c.to
+			if hasTo {
+				isFaceAlive[faceIndex] = false
+				faceCount--
+				continue
+			}
+			for j := 0; j < 3; j++ {
+				if face[j] == c.from {
+					face[j] = c.to
+				} else {
+					neighbours[face[j]] = true
+				}
+			}
+			vertexFaces[c.to] = append(vertexFaces[c.to], faceIndex)
+		}
+		vertexFaces[c.from] = nil
+		quadrics[c.to].add(quadrics[c.from])
+		versions[c.from]++
+		versions[c.to]++
+		for _, faceIndex := range vertexFaces[c.to] {
+			if !isFaceAlive[faceIndex] {
+				continue
+			}
+			for _, index := range faces[faceIndex] {
+				if index != c.to {
+					neighbours[index] = true
+				}
+			}
+		}
+		for n := range neighbours {
+			push(n, c.to)
+			push(c.to, n)
+		}
+	}
+
+	remap := make([]int, vertexCount)
+	for i := range remap {
+		remap[i] = -1
+	}
+	keep := []int{}
+	faceKeep := []int{}
+	newFaces := []*Face{}
+	for i, face := range faces {
+		if !isFaceAlive[i] {
+			continue
+		}
+		newFace := &Face{Group: m.Faces[i].Group}
+		for j, index := range face {
+			if remap[index] < 0 {
+				remap[index] = len(keep)
+				keep = append(keep, index)
+			}
+			newFace.Index[j] = uint32(remap[index])
+		}
+		newFaces = append(newFaces, newFace)
+		faceKeep = append(faceKeep, i)
+	}
+	m.Faces = newFaces
+	m.Normals = Reorder(m.Normals, keep, vertexCount)
+	m.Positions = Reorder(m.Positions, keep, vertexCount)
+	return keep, faceKeep
+}
+
+// decimateLocked returns which vertices may not be collapsed away
+func (m *Mesh) decimateLocked(isLocked func(vertex int) bool) []bool {
+	locked := make([]bool, len(m.Positions))
+
+	positions := map[[3]float32]int{}
+	for _, p := range m.Positions {
+		positions[p]++
+	}
+	for i, p := range m.Positions {
+		if positions[p] > 1 {
+			locked[i] = true
+		}
+		if isLocked != nil && isLocked(i) {
+			locked[i] = true
+		}
+	}
+
+	groups := map[int]int{}
+	edges := map[[2]uint32]int{}
+	for _, face := range m.Faces {
+		for j := 0; j < 3; j++ {
+			index := face.Index[j]
+			group, ok := groups[int(index)]
+			if ok && group != face.Group {
+				locked[index] = true
+			}
+			groups[int(index)] = face.Group
+
+			a, b := face.Index[j], face.Index[(j+1)%3]
+			if a > b {
+				a, b = b, a
+			}
+			edges[[2]uint32{a, b}]++
+		}
+	}
+	for edge, count := range edges {
+		if count == 1 {
+			locked[edge[0]] = true
+			locked[edge[1]] = true
+		}
+	}
+	return locked
+}
+
+// decimateCanCollapse rejects collapses that are no longer an edge or that flip a face
+func (m *Mesh) decimateCanCollapse(faces [][3]int, isFaceAlive []bool, fromFaces []int, from int, to int) bool {
+	isEdge := false
+	for _, faceIndex := range fromFaces {
+		if !isFaceAlive[faceIndex] {
+			continue
+		}
+		face := faces[faceIndex]
+		if face[0] == to || face[1] == to || face[2] == to {
+			isEdge = true
+			continue
+		}
+		before := triangleNormal(m.Positions[face[0]], m.Positions[face[1]], m.Positions[face[2]])
+		moved := [3][3]float32{}
+		for j, index := range face {
+			moved[j] = m.Positions[index]
+			if index == from {
+				moved[j] = m.Positions[to]
+			}
+		}
+		after := triangleNormal(moved[0], moved[1], moved[2])
+		if before[0]*after[0]+before[1]*after[1]+before[2]*after[2] <= 0 {
+			return false
+		}
+	}
+	return isEdge
+}
+
+func triangleNormal(a [3]float32, b [3]float32, c [3]float32) [3]float64 {
+	e1 := [3]float64{float64(b[0] - a[0]), float64(b[1] - a[1]), float64(b[2] - a[2])}
+	e2 := [3]float64{float64(c[0] - a[0]), float64(c[1] - a[1]), float64(c[2] - a[2])}
+	n := [3]float64{
+		e1[1]*e2[2] - e1[2]*e2[1],
+		e1[2]*e2[0] - e1[0]*e2[2],
+		e1[0]*e2[1] - e1[1]*e2[0],
+	}
+	length := math.Sqrt(n[0]*n[0] + n[1]*n[1] + n[2]*n[2])
+	if length == 0 {
+		return n
+	}
+	return [3]float64{n[0] / length, n[1] / length, n[2] / length}
+}
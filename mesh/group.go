@@ -0,0 +1,77 @@
+package mesh
+
+import (
+	"sort"
+)
+
+// SortFaces stably orders faces by Group. It returns the face keep list and the
+// [count, group] runs of the new order, the layout of wld FaceMaterialGroups
+func (m *Mesh) SortFaces() ([]int, [][2]int) {
+	keep := make([]int, len(m.Faces))
+	for i := range keep {
+		keep[i] = i
+	}
+	sort.SliceStable(keep, func(a, b int) bool {
+		return m.Faces[keep[a]].Group < m.Faces[keep[b]].Group
+	})
+	m.Faces = Reorder(m.Faces, keep, len(m.Faces))
+
+	groups := make([]int, len(m.Faces))
+	for i, face := range m.Faces {
+		groups[i] = face.Group
+	}
+	return keep, Runs(groups)
+}
+
+// SortVertices stably orders vertices by groups, one key per vertex such as a bone index, and
+// remaps faces. It returns the vertex keep list and the [count, group] runs of the new order,
+// the layout of wld SkinAssignmentGroups
+func (m *Mesh) SortVertices(groups []int) ([]int, [][2]int) {
+	keep := make([]int, len(m.Positions))
+	for i := range keep {
+		keep[i] = i
+	}
+	if len(groups) != len(m.Positions) {
+		return keep, nil
+	}
+	sort.SliceStable(keep, func(a, b int) bool {
+		return groups[keep[a]] < groups[keep[b]]
+	})
+
+	remap := make([]uint32, len(keep))
+	for newIndex, old := range keep {
+		remap[old] = uint32(newIndex)
+	}
+	for _, face := range m.Faces {
+		for i := range face.Index {
+			face.Index[i] = remap[face.Index[i]]
+		}
+	}
+	m.Normals = Reorder(m.Normals, keep, len(m.Positions))
+	m.Positions = Reorder(m.Positions, keep, len(m.Positions))
+	return keep, Runs(Reorder(groups, keep, len(groups)))
+}
+
+// Runs collapses consecutive equal keys into [count, key] pairs
+func Runs(keys []int) [][2]int {
+	runs := [][2]int{}
+	for _, key := range keys {
+		if len(runs) > 0 && runs[len(runs)-1][1] == key {
+			runs[len(runs)-1][0]++
+			continue
+		}
+		runs = append(runs, [2]int{1, key})
+	}
+	return runs
+}
+
+// Expand turns [count, key] runs back into one key per element
+func Expand[T ~int16 | ~uint16 | ~int](runs [][2]T) []int {
+	keys := []int{}
+	for _, run := range runs {
+		for i := 0; i < int(run[0]); i++ {
+			keys = append(keys, int(run[1]))
+		}
+	}
+	return keys
+}
@@ -0,0 +1,87 @@
+// Package mesh is a format neutral triangle mesh toolkit used by wld and eqg models
+package mesh
+
+import (
+	"math"
+)
+
+// Mesh is an indexed triangle mesh. Callers keep any other per vertex or per face data
+// themselves, and use the keep lists returned by operations to reorder it with Reorder.
+type Mesh struct {
+	Positions [][3]float32
+	Normals   [][3]float32
+	Faces     []*Face
+}
+
+// Face is a triangle, Group is a caller defined key such as a material index
+type Face struct {
+	Index [3]uint32
+	Group int
+}
+
+// Reorder returns src rearranged so the new element i is src[keep[i]].
+// When src is not the length of the mesh data keep was built from, src is returned unchanged
+func Reorder[T any](src []T, keep []int, size int) []T {
+	if len(src) != size {
+		return src
+	}
+	dst := make([]T, len(keep))
+	for i, index := range keep {
+		dst[i] = src[index]
+	}
+	return dst
+}
+
+// Bounds returns the minimum and maximum corners of every position
+func (m *Mesh) Bounds() ([3]float32, [3]float32) {
+	if len(m.Positions) == 0 {
+		return [3]float32{}, [3]float32{}
+	}
+	min := m.Positions[0]
+	max := m.Positions[0]
+	for _, p := range m.Positions[1:] {
+		for i := 0; i < 3; i++ {
+			if p[i] < min[i] {
+				min[i] = p[i]
+			}
+			if p[i] > max[i] {
+				max[i] = p[i]
+			}
+		}
+	}
+	return min, max
+}
+
+// BoundingRadius returns the distance from center to the furthest position
+func (m *Mesh) BoundingRadius(center [3]float32) float32 {
+	radius := float64(0)
+	for _, p := range m.Positions {
+		dx := float64(p[0] - center[0])
+		dy := float64(p[1] - center[1])
+		dz := float64(p[2] - center[2])
+		radius = math.Max(radius, math.Sqrt(dx*dx+dy*dy+dz*dz))
+	}
+	return float32(radius)
+}
+
+// faceNormal returns the area weighted (unnormalized) normal of a face
+func (m *Mesh) faceNormal(face *Face) [3]float64 {
+	a := m.Positions[face.Index[0]]
+	b := m.Positions[face.Index[1]]
+	c := m.Positions[face.Index[2]]
+	e1 := [3]float64{float64(b[0] - a[0]), float64(b[1] - a[1]), float64(b[2] - a[2])}
+	e2 := [3]float64{float64(c[0] - a[0]), float64(c[1] - a[1]), float64(c[2] - a[2])}
+	return [3]float64{
+		e1[1]*e2[2] - e1[2]*e2[1],
+		e1[2]*e2[0] - e1[0]*e2[2],
+		e1[0]*e2[1] - e1[1]*e2[0],
+	}
+}
+
+func normalize(v [3]float64) [3]float32 {
+	length := math.Sqrt(v[0]*v[0] + v[1]*v[1] + v[2]*v[2])
+	if length == 0 {
+		return [3]float32{}
+	}
+	return [3]float32{float32(v[0] / length), float32(v[1] / length), float32(v[2] / length)}
+}
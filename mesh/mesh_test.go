@@ -0,0 +1,130 @@
+package mesh
+
+import (
+	"testing"
+)
+
+// quad returns two triangles sharing an edge, with the shared vertices duplicated
+func quad() *Mesh {
+	return &Mesh{
+		Positions: [][3]float32{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 0, 0}, {1, 1, 0.000001}, {0, 1, 0}},
+		Faces: []*Face{
+			{Index: [3]uint32{0, 1, 2}, Group: 1},
+			{Index: [3]uint32{3, 4, 5}, Group: 0},
+		},
+	}
+}
+
+func TestWeld(t *testing.T) {
+	m := quad()
+	keep, faceKeep := m.Weld(0.001, nil)
+	if len(m.Positions) != 4 {
+		t.Fatalf("got %d positions, want 4", len(m.Positions))
+	}
+	if len(keep) != 4 || keep[3] != 5 || len(faceKeep) != 2 {
+		t.Fatalf("unexpected keep %v face keep %v", keep, faceKeep)
+	}
+	if m.Faces[1].Index != [3]uint32{0, 2, 3} {
+		t.Fatalf("face remap got %v", m.Faces[1].Index)
+	}
+
+	m = quad()
+	m.Weld(0.001, func(a int, b int) bool { return false })
+	if len(m.Positions) != 6 {
+		t.Fatalf("isSame false should not weld, got %d positions", len(m.Positions))
+	}
+}
+
+func TestNormals(t *testing.T) {
+	m := quad()
+	m.Weld(0.001, nil)
+	m.SmoothNormals()
+	for i, n := range m.Normals {
+		if n[2] < 0.99 {
+			t.Fatalf("normal %d got %v, want +z", i, n)
+		}
+	}
+
+	keep := m.FlatNormals()
+	if len(m.Positions) != 6 || len(keep) != 6 || keep[3] != 0 {
+		t.Fatalf("flat split got %d positions keep %v", len(m.Positions), keep)
+	}
+}
+
+func TestSort(t *testing.T) {
+	m := quad()
+	faceKeep, runs := m.SortFaces()
+	if faceKeep[0] != 1 || m.Faces[0].Group != 0 || len(runs) != 2 || runs[0] != [2]int{1, 0} {
+		t.Fatalf("sort faces got keep %v runs %v", faceKeep, runs)
+	}
+
+	keep, runs := m.SortVertices([]int{2, 1, 1, 0, 2, 0})
+	if keep[0] != 3 || keep[1] != 5 || len(runs) != 3 || runs[1] != [2]int{2, 1} {
+		t.Fatalf("sort vertices got keep %v runs %v", keep, runs)
+	}
+	// face 0 was vertices 3, 4, 5 which moved to 0, 5, 1
+	if m.Faces[0].Index != [3]uint32{0, 5, 1} {
+		t.Fatalf("face remap got %v", m.Faces[0].Index)
+	}
+}
+
+func TestBounds(t *testing.T) {
+	m := quad()
+	min, max := m.Bounds()
+	if min != [3]float32{0, 0, 0} || max != [3]float32{1, 1, 0.000001} {
+		t.Fatalf("bounds got %v %v", min, max)
+	}
+	if r := m.BoundingRadius([3]float32{}); r < 1.414 || r > 1.415 {
+		t.Fatalf("radius got %f", r)
+	}
+}
+
+// grid returns a flat size x size vertex plane
+func grid(size int) *Mesh {
+	m := &Mesh{}
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			m.Positions = append(m.Positions, [3]float32{float32(x), float32(y), 0})
+		}
+	}
+	for y := 0; y < size-1; y++ {
+		for x := 0; x < size-1; x++ {
+			i := uint32(y*size + x)
+			m.Faces = append(m.Faces, &Face{Index: [3]uint32{i, i + 1, i + uint32(size) + 1}})
+			m.Faces = append(m.Faces, &Face{Index: [3]uint32{i, i + uint32(size) + 1, i + uint32(size)}})
+		}
+	}
+	return m
+}
+
+func TestDecimate(t *testing.T) {
+	m := grid(5)
+	keep, faceKeep := m.Decimate(16, nil)
+	if len(m.Faces) > 16 || len(faceKeep) != len(m.Faces) || len(keep) != len(m.Positions) {
+		t.Fatalf("decimate got %d faces %d positions", len(m.Faces), len(m.Positions))
+	}
+	for i, index := range keep {
+		if m.Positions[i] != grid(5).Positions[index] {
+			t.Fatalf("position %d does not match original %d", i, index)
+		}
+	}
+	for _, face := range m.Faces {
+		n := m.faceNormal(face)
+		if n[2] <= 0 {
+			t.Fatalf("face %v flipped", face.Index)
+		}
+	}
+
+	// open edges are locked, so only the 9 interior vertices of the 25 can go
+	m = grid(5)
+	m.Decimate(0, nil)
+	if len(m.Positions) != 16 {
+		t.Fatalf("boundary decimate got %d positions, want 16", len(m.Positions))
+	}
+
+	m = grid(5)
+	m.Decimate(0, func(vertex int) bool { return true })
+	if len(m.Faces) != 32 {
+		t.Fatalf("locked decimate got %d faces, want 32", len(m.Faces))
+	}
+}
@@ -0,0 +1,38 @@
+package mesh
+
+// SmoothNormals sets each vertex normal to the area weighted average of the faces using it
+func (m *Mesh) SmoothNormals() {
+	sums := make([][3]float64, len(m.Positions))
+	for _, face := range m.Faces {
+		n := m.faceNormal(face)
+		for _, index := range face.Index {
+			for i := 0; i < 3; i++ {
+				sums[index][i] += n[i]
+			}
+		}
+	}
+	m.Normals = make([][3]float32, len(m.Positions))
+	for i, sum := range sums {
+		m.Normals[i] = normalize(sum)
+	}
+}
+
+// FlatNormals gives every face its own three vertices facing the face direction.
+// The returned keep list maps each new vertex to the vertex it was copied from
+func (m *Mesh) FlatNormals() []int {
+	keep := make([]int, 0, len(m.Faces)*3)
+	positions := make([][3]float32, 0, len(m.Faces)*3)
+	normals := make([][3]float32, 0, len(m.Faces)*3)
+	for _, face := range m.Faces {
+		n := normalize(m.faceNormal(face))
+		for i, index := range face.Index {
+			keep = append(keep, int(index))
+			positions = append(positions, m.Positions[index])
+			normals = append(normals, n)
+			face.Index[i] = uint32(len(positions) - 1)
+		}
+	}
+	m.Positions = positions
+	m.Normals = normals
+	return keep
+}
@@ -0,0 +1,81 @@
+package mesh
+
+import (
+	"math"
+)
+
+// Weld merges vertices with positions within tolerance of each other, when isSame (optional)
+// reports the rest of their data matches. Faces are remapped and faces collapsed by welding are
+// removed. The returned keep lists map each new vertex and face to the original it came from
+func (m *Mesh) Weld(tolerance float32, isSame func(a int, b int) bool) ([]int, []int) {
+	if tolerance <= 0 {
+		tolerance = 1e-5
+	}
+
+	// bucket positions on a grid the size of tolerance, and search neighbouring cells
+	cell := func(p [3]float32) [3]int64 {
+		return [3]int64{
+			int64(math.Floor(float64(p[0] / tolerance))),
+			int64(math.Floor(float64(p[1] / tolerance))),
+			int64(math.Floor(float64(p[2] / tolerance))),
+		}
+	}
+	grid := map[[3]int64][]int{}
+
+	remap := make([]uint32, len(m.Positions))
+	keep := []int{}
+	for i, p := range m.Positions {
+		c := cell(p)
+		match := -1
+		for dx := int64(-1); dx <= 1 && match < 0; dx++ {
+			for dy := int64(-1); dy <= 1 && match < 0; dy++ {
+				for dz := int64(-1); dz <= 1 && match < 0; dz++ {
+					for _, newIndex := range grid[[3]int64{c[0] + dx, c[1] + dy, c[2] + dz}] {
+						old := keep[newIndex]
+						if !isWithin(m.Positions[old], p, tolerance) {
+							continue
+						}
+						if isSame != nil && !isSame(old, i) {
+							continue
+						}
+						match = newIndex
+						break
+					}
+				}
+			}
+		}
+		if match >= 0 {
+			remap[i] = uint32(match)
+			continue
+		}
+		remap[i] = uint32(len(keep))
+		grid[c] = append(grid[c], len(keep))
+		keep = append(keep, i)
+	}
+
+	faces := make([]*Face, 0, len(m.Faces))
+	faceKeep := make([]int, 0, len(m.Faces))
+	for faceIndex, face := range m.Faces {
+		for i := range face.Index {
+			face.Index[i] = remap[face.Index[i]]
+		}
+		if face.Index[0] == face.Index[1] || face.Index[1] == face.Index[2] || face.Index[0] == face.Index[2] {
+			continue
+		}
+		faces = append(faces, face)
+		faceKeep = append(faceKeep, faceIndex)
+	}
+	m.Faces = faces
+	m.Normals = Reorder(m.Normals, keep, len(m.Positions))
+	m.Positions = Reorder(m.Positions, keep, len(m.Positions))
+	return keep, faceKeep
+}
+
+func isWithin(a [3]float32, b [3]float32, tolerance float32) bool {
+	for i := 0; i < 3; i++ {
+		if float32(math.Abs(float64(a[i]-b[i]))) > tolerance {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,27 @@
+package quail
+
+import (
+	"fmt"
+
+	"github.com/xackery/quail/wce"
+)
+
+// BakeVertexLights recomputes baked vertex colors of the loaded zone from its lights.
+// models is the optional wld of <zone>_obj.s3d holding placed objects, eqg zones bake .lit data instead.
+func (q *Quail) BakeVertexLights(models *wce.Wce, opt *wce.LightBakeOption) error {
+	if q.Wld == nil {
+		return fmt.Errorf("no zone wld loaded")
+	}
+	if len(q.Wld.ZonDefs) > 0 {
+		err := q.Wld.BakeEqgLits(opt)
+		if err != nil {
+			return fmt.Errorf("bake eqg lits: %w", err)
+		}
+		return nil
+	}
+	err := q.Wld.BakeVertexLights(q.WldLights, q.WldObject, models, opt)
+	if err != nil {
+		return fmt.Errorf("bake vertex lights: %w", err)
+	}
+	return nil
+}
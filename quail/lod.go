@@ -0,0 +1,19 @@
+package quail
+
+import (
+	"fmt"
+
+	"github.com/xackery/quail/wce"
+)
+
+// GenerateLods adds decimated LOD models and .lod entries to the loaded eqg
+func (q *Quail) GenerateLods(opt *wce.LodOption) error {
+	if q.Wld == nil {
+		return fmt.Errorf("no wld loaded")
+	}
+	err := q.Wld.GenerateLods(opt)
+	if err != nil {
+		return fmt.Errorf("generate lods: %w", err)
+	}
+	return nil
+}
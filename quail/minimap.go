@@ -0,0 +1,164 @@
+package quail
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/xackery/quail/raw"
+	"github.com/xackery/quail/wce"
+)
+
+// MinimapOption configures how Minimap builds an in-game map
+type MinimapOption struct {
+	Levels      []float32 // z levels to slice, when empty Step is used across the zone height
+	Step        float32   // distance between z levels when Levels is empty
+	IsZonelines bool      // add labeled points for zonelines
+	IsObjects   bool      // add labeled points for placed objects
+}
+
+var (
+	minimapLineColor     = [3]uint8{0, 0, 0}
+	minimapZonelineColor = [3]uint8{240, 0, 0}
+	minimapObjectColor   = [3]uint8{0, 0, 240}
+)
+
+// Minimap slices the loaded zone geometry into an in-game map.
+// models is the optional wld of <zone>_obj.s3d holding placed objects.
+func (q *Quail) Minimap(models *wce.Wce, opt *MinimapOption) (*raw.MapTxt, error) {
+	if q.Wld == nil {
+		return nil, fmt.Errorf("no zone wld loaded")
+	}
+	if opt == nil {
+		opt = &MinimapOption{}
+	}
+
+	mesh, err := q.Wld.ZoneMesh(q.WldObject, models)
+	if err != nil {
+		return nil, fmt.Errorf("zone mesh: %w", err)
+	}
+
+	levels := opt.Levels
+	if len(levels) == 0 {
+		if opt.Step <= 0 {
+			return nil, fmt.Errorf("no levels or step provided")
+		}
+		min, max := mesh.Bounds()
+		for z := float32(math.Ceil(float64(min[2]/opt.Step))) * opt.Step; z <= max[2]; z += opt.Step {
+			levels = append(levels, z)
+		}
+	}
+
+	mt := &raw.MapTxt{}
+	seen := map[[4]int32]bool{}
+	for _, z := range levels {
+		for _, segment := range mesh.Slice(z) {
+			from := minimapPosition(segment[0])
+			to := minimapPosition(segment[1])
+			key := minimapSegmentKey(from, to)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			mt.Lines = append(mt.Lines, &raw.MapTxtLine{From: from, To: to, Color: minimapLineColor})
+		}
+	}
+
+	if opt.IsZonelines {
+		err = minimapZonelines(q.Wld, mt)
+		if err != nil {
+			return nil, fmt.Errorf("zonelines: %w", err)
+		}
+	}
+	if opt.IsObjects {
+		minimapObjects(q.Wld, q.WldObject, mt)
+	}
+	return mt, nil
+}
+
+// minimapPosition converts a server space position to map space, which has x and y negated
+func minimapPosition(v [3]float32) [3]float32 {
+	return [3]float32{-v[0], -v[1], v[2]}
+}
+
+// minimapSegmentKey returns a direction independent key of a segment on a 0.1 unit grid
+func minimapSegmentKey(from [3]float32, to [3]float32) [4]int32 {
+	a := [2]int32{int32(math.Round(float64(from[0] * 10))), int32(math.Round(float64(from[1] * 10)))}
+	b := [2]int32{int32(math.Round(float64(to[0] * 10))), int32(math.Round(float64(to[1] * 10)))}
+	if a[0] > b[0] || (a[0] == b[0] && a[1] > b[1]) {
+		a, b = b, a
+	}
+	return [4]int32{a[0], a[1], b[0], b[1]}
+}
+
+func minimapZonelines(wld *wce.Wce, mt *raw.MapTxt) error {
+	for _, zone := range wld.Zones {
+		zt, err := zone.ZoneType()
+		if err != nil {
+			return fmt.Errorf("zone %s: %w", zone.Tag, err)
+		}
+		if zt.Zoneline == nil {
+			continue
+		}
+
+		// place the label at the average center of the zoneline regions
+		center := [3]float32{}
+		count := float32(0)
+		for _, regionIndex := range zone.Regions {
+			if int(regionIndex) >= len(wld.Regions) {
+				return fmt.Errorf("zone %s region %d out of range", zone.Tag, regionIndex)
+			}
+			sphere := wld.Regions[regionIndex].Sphere
+			if !sphere.Valid {
+				continue
+			}
+			for i := 0; i < 3; i++ {
+				center[i] += sphere.Float32Slice4[i]
+			}
+			count++
+		}
+		if count == 0 {
+			continue
+		}
+		for i := 0; i < 3; i++ {
+			center[i] /= count
+		}
+
+		label := fmt.Sprintf("To zone %d", zt.Zoneline.ZoneID)
+		if zt.Zoneline.IsReference {
+			label = fmt.Sprintf("Zoneline %d", zt.Zoneline.Index)
+		}
+		mt.Points = append(mt.Points, &raw.MapTxtPoint{Position: minimapPosition(center), Color: minimapZonelineColor, Size: 3, Label: label})
+	}
+
+	for _, zon := range wld.ZonDefs {
+		for _, area := range zon.Areas {
+			if !strings.HasPrefix(strings.ToUpper(area.Name), "ATP") {
+				continue
+			}
+			position := minimapPosition(wce.EqgToServerAxis(area.Position))
+			mt.Points = append(mt.Points, &raw.MapTxtPoint{Position: position, Color: minimapZonelineColor, Size: 3, Label: area.Name})
+		}
+	}
+	return nil
+}
+
+func minimapObjects(wld *wce.Wce, objects *wce.Wce, mt *raw.MapTxt) {
+	if objects != nil {
+		for _, inst := range objects.ActorInsts {
+			if !inst.Location.Valid {
+				continue
+			}
+			loc := inst.Location.Float32Slice6
+			label := strings.TrimSuffix(inst.DefinitionTag, "_ACTORDEF")
+			mt.Points = append(mt.Points, &raw.MapTxtPoint{Position: minimapPosition([3]float32{loc[0], loc[1], loc[2]}), Color: minimapObjectColor, Size: 1, Label: label})
+		}
+	}
+
+	for _, zon := range wld.ZonDefs {
+		for _, inst := range zon.Instances {
+			position := minimapPosition(wce.EqgToServerAxis(inst.Translation))
+			mt.Points = append(mt.Points, &raw.MapTxtPoint{Position: position, Color: minimapObjectColor, Size: 1, Label: inst.ModelTag})
+		}
+	}
+}
@@ -17,6 +17,7 @@ func (e *Quail) PfsWrite(fileVersion uint32, pfsVersion int, path string) error
 	if len(path) == 0 {
 		return fmt.Errorf("path is empty")
 	}
+	e.ConvertNotes = nil
 	ext := strings.ToLower(filepath.Ext(path))
 
 	switch ext {
@@ -45,7 +46,13 @@ func (e *Quail) EQGExport(fileVersion uint32, pfsVersion int, path string) error
 		return fmt.Errorf("no wld found")
 	}
 
-	err = e.Wld.WriteEqgRaw(archive)
+	eqg, notes, err := e.Wld.ConvertWldToEQG()
+	if err != nil {
+		return fmt.Errorf("convert wld: %w", err)
+	}
+	e.ConvertNotes = append(e.ConvertNotes, notes...)
+
+	err = eqg.WriteEqgRaw(archive)
 	if err != nil {
 		return fmt.Errorf("write eqg: %w", err)
 	}
@@ -73,7 +80,7 @@ func (e *Quail) EQGExport(fileVersion uint32, pfsVersion int, path string) error
 
 	sideFileOut := ""
 	numSideFiles := 0
-	ok, err := e.Wld.WriteSingleFile(wce.SideFileZon, filepath.Join(dirPath, noExtBaseName+".zon"))
+	ok, err := eqg.WriteSingleFile(wce.SideFileZon, filepath.Join(dirPath, noExtBaseName+".zon"))
 	if err != nil {
 		return fmt.Errorf("write side file .zon: %w", err)
 	}
@@ -82,6 +89,24 @@ func (e *Quail) EQGExport(fileVersion uint32, pfsVersion int, path string) error
 		numSideFiles++
 	}
 
+	ok, err = eqg.WriteSingleFile(wce.SideFileOnDemand, filepath.Join(dirPath, noExtBaseName+"_ondemand.txt"))
+	if err != nil {
+		return fmt.Errorf("write side file _ondemand.txt: %w", err)
+	}
+	if ok {
+		sideFileOut += "_ondemand.txt, "
+		numSideFiles++
+	}
+
+	ok, err = eqg.WriteSingleFile(wce.SideFileEdd, filepath.Join(dirPath, noExtBaseName+".edd"))
+	if err != nil {
+		return fmt.Errorf("write side file .edd: %w", err)
+	}
+	if ok {
+		sideFileOut += ".edd, "
+		numSideFiles++
+	}
+
 	if len(sideFileOut) > 0 {
 		sideFileOut = strings.TrimSuffix(sideFileOut, ", ")
 		sideFileOut = fmt.Sprintf(" and side file%s: %s", helper.Pluralize(numSideFiles), sideFileOut)
@@ -100,14 +125,38 @@ func (e *Quail) S3DExport(fileVersion uint32, pfsVersion int, path string) error
 	}
 	defer archive.Close()
 
+	wldObject := e.WldObject
+	wldLights := e.WldLights
+	if e.Wld != nil && len(e.Wld.ZonDefs) > 0 && wldObject == nil && wldLights == nil {
+		var models *wce.Wce
+		var notes []string
+		wldObject, wldLights, models, notes, err = e.Wld.ConvertEQGZone()
+		if err != nil {
+			return fmt.Errorf("convert eqg zone: %w", err)
+		}
+		e.ConvertNotes = append(e.ConvertNotes, notes...)
+		if len(models.ActorDefs) > 0 {
+			err = e.s3dExportModels(fileVersion, pfsVersion, path, models)
+			if err != nil {
+				return fmt.Errorf("export models: %w", err)
+			}
+		}
+	}
+
 	isSomethingWritten := false
 	if e.Wld != nil {
 		base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
 		e.Wld.FileName = base + ".wld"
 
+		wld, notes, err := e.Wld.ConvertEQGToWld()
+		if err != nil {
+			return fmt.Errorf("convert eqg: %w", err)
+		}
+		e.ConvertNotes = append(e.ConvertNotes, notes...)
+
 		buf := &bytes.Buffer{}
 
-		err := e.Wld.WriteWldRaw(buf)
+		err = wld.WriteWldRaw(buf)
 		if err != nil {
 			return fmt.Errorf("write s3d: %w", err)
 		}
@@ -119,10 +168,10 @@ func (e *Quail) S3DExport(fileVersion uint32, pfsVersion int, path string) error
 		isSomethingWritten = true
 	}
 
-	if e.WldObject != nil {
+	if wldObject != nil {
 		buf := &bytes.Buffer{}
 
-		err := e.WldObject.WriteWldRaw(buf)
+		err := wldObject.WriteWldRaw(buf)
 		if err != nil {
 			return fmt.Errorf("write s3d object: %w", err)
 		}
@@ -134,10 +183,10 @@ func (e *Quail) S3DExport(fileVersion uint32, pfsVersion int, path string) error
 		isSomethingWritten = true
 	}
 
-	if e.WldLights != nil {
+	if wldLights != nil {
 		buf := &bytes.Buffer{}
 
-		err := e.WldLights.WriteWldRaw(buf)
+		err := wldLights.WriteWldRaw(buf)
 		if err != nil {
 			return fmt.Errorf("write s3d lights: %w", err)
 		}
@@ -175,3 +224,30 @@ func (e *Quail) S3DExport(fileVersion uint32, pfsVersion int, path string) error
 
 	return nil
 }
+
+// s3dExportModels writes the object models of a converted eqg zone to <zone>_obj.s3d beside
+// path, with the textures they use
+func (e *Quail) s3dExportModels(fileVersion uint32, pfsVersion int, path string, models *wce.Wce) error {
+	textures := map[string]bool{}
+	for _, sprite := range models.SimpleSpriteDefs {
+		for _, frame := range sprite.SimpleSpriteFrames {
+			for _, file := range frame.TextureFiles {
+				textures[strings.ToLower(file)] = true
+			}
+		}
+	}
+
+	q := &Quail{
+		Wld:    models,
+		Assets: map[string][]byte{},
+	}
+	for fileName, assetData := range e.Assets {
+		if !textures[strings.ToLower(fileName)] {
+			continue
+		}
+		q.Assets[fileName] = assetData
+	}
+
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return q.S3DExport(fileVersion, pfsVersion, filepath.Join(filepath.Dir(path), base+"_obj.s3d"))
+}
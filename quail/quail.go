@@ -17,6 +17,7 @@ type Quail struct {
 	Wld                    *wce.Wce
 	WldObject              *wce.Wce
 	WldLights              *wce.Wce
+	ConvertNotes           []string // what format conversions of the last PfsWrite could not keep
 	//SpellEffects           *wce.Wce
 	Assets     map[string][]byte
 	StatFS     fs.StatFS
@@ -0,0 +1,212 @@
+package quail
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xackery/quail/raw"
+	"github.com/xackery/quail/wce"
+)
+
+// servermapWaterDepth is how far below a water.dat sheet the generated water volume extends
+const servermapWaterDepth = 1000
+
+// ServerMap builds EQEmu server collision (.map) and water (.wtr) data from the loaded zone.
+// models is the optional wld of <zone>_obj.s3d holding placed objects, water is the optional
+// water.dat of an eqg zone.
+func (q *Quail) ServerMap(models *wce.Wce, water *raw.DatWtr) (*raw.EmuMap, *raw.EmuWtr, error) {
+	if q.Wld == nil {
+		return nil, nil, fmt.Errorf("no zone wld loaded")
+	}
+
+	mesh, err := q.Wld.ZoneMesh(q.WldObject, models)
+	if err != nil {
+		return nil, nil, fmt.Errorf("zone mesh: %w", err)
+	}
+	emuMap := servermapFromMesh(mesh)
+
+	var emuWtr *raw.EmuWtr
+	if len(q.Wld.WorldTrees) > 0 {
+		emuWtr, err = servermapWtrBsp(q.Wld)
+	} else {
+		emuWtr, err = servermapWtrEqg(q.Wld, water)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("water: %w", err)
+	}
+	return emuMap, emuWtr, nil
+}
+
+// servermapFromMesh splits a zone mesh into collidable and non collidable geometry
+func servermapFromMesh(mesh *wce.ZoneMesh) *raw.EmuMap {
+	emuMap := &raw.EmuMap{Version: raw.EmuMapVersion2}
+	collideRemap := map[uint32]uint32{}
+	nonCollideRemap := map[uint32]uint32{}
+
+	for _, tri := range mesh.Triangles {
+		for _, index := range tri.Index {
+			if tri.Passable {
+				newIndex, ok := nonCollideRemap[index]
+				if !ok {
+					newIndex = uint32(len(emuMap.NonCollideVertices))
+					nonCollideRemap[index] = newIndex
+					emuMap.NonCollideVertices = append(emuMap.NonCollideVertices, mesh.Vertices[index])
+				}
+				emuMap.NonCollideIndices = append(emuMap.NonCollideIndices, newIndex)
+				continue
+			}
+			newIndex, ok := collideRemap[index]
+			if !ok {
+				newIndex = uint32(len(emuMap.Vertices))
+				collideRemap[index] = newIndex
+				emuMap.Vertices = append(emuMap.Vertices, mesh.Vertices[index])
+			}
+			emuMap.Indices = append(emuMap.Indices, newIndex)
+		}
+	}
+	return emuMap
+}
+
+// servermapRegionType converts a typed zone to an EQEmu region type
+func servermapRegionType(zt *wce.ZoneType) raw.EmuWtrRegionType {
+	switch {
+	case zt.Flags&wce.ZoneFlagZoneline != 0:
+		return raw.EmuWtrRegionTypeZoneLine
+	case zt.Flags&wce.ZoneFlagWater != 0:
+		return raw.EmuWtrRegionTypeWater
+	case zt.Flags&wce.ZoneFlagLava != 0:
+		return raw.EmuWtrRegionTypeLava
+	case zt.Flags&wce.ZoneFlagPvP != 0:
+		return raw.EmuWtrRegionTypePVP
+	case zt.Flags&wce.ZoneFlagSlime != 0:
+		return raw.EmuWtrRegionTypeSlime
+	case zt.Flags&wce.ZoneFlagSlippery != 0:
+		return raw.EmuWtrRegionTypeIce
+	case zt.Flags&wce.ZoneFlagFreezingWater != 0:
+		return raw.EmuWtrRegionTypeVWater
+	}
+	return raw.EmuWtrRegionTypeNormal
+}
+
+// servermapWtrBsp builds a version 1 water map from the s3d world tree and typed zones
+func servermapWtrBsp(wld *wce.Wce) (*raw.EmuWtr, error) {
+	emuWtr := &raw.EmuWtr{Version: 1}
+
+	// regionTypes is keyed by 1 based region number
+	regionTypes := map[int32]raw.EmuWtrRegionType{}
+	for _, zone := range wld.Zones {
+		zt, err := zone.ZoneType()
+		if err != nil {
+			return nil, fmt.Errorf("zone %s: %w", zone.Tag, err)
+		}
+		regionType := servermapRegionType(zt)
+		if regionType == raw.EmuWtrRegionTypeNormal {
+			continue
+		}
+		for _, region := range zone.Regions {
+			regionTypes[int32(region)+1] = regionType
+		}
+	}
+
+	tree := wld.WorldTrees[0]
+	for i, node := range tree.WorldNodes {
+		regionNumber, err := servermapRegionNumber(node.WorldRegionTag)
+		if err != nil {
+			return nil, fmt.Errorf("node %d: %w", i+1, err)
+		}
+		emuWtr.Nodes = append(emuWtr.Nodes, &raw.EmuWtrNode{
+			NodeNumber:    int32(i + 1),
+			Normal:        [3]float32{node.Normals[0], node.Normals[1], node.Normals[2]},
+			SplitDistance: node.Normals[3],
+			Region:        regionNumber,
+			Special:       regionTypes[regionNumber],
+			Left:          int32(node.FrontTree),
+			Right:         int32(node.BackTree),
+		})
+	}
+	return emuWtr, nil
+}
+
+// servermapRegionNumber returns the 1 based region number of a R###### world node region tag, 0 if none
+func servermapRegionNumber(tag string) (int32, error) {
+	if tag == "" {
+		return 0, nil
+	}
+	if !strings.HasPrefix(tag, "R") {
+		return 0, fmt.Errorf("invalid region tag (needs R Prefix): %s", tag)
+	}
+	val, err := strconv.Atoi(tag[1:])
+	if err != nil {
+		return 0, fmt.Errorf("invalid region tag (Should be R########): %s", tag)
+	}
+	return int32(val), nil
+}
+
+// servermapAreaType converts an eqg zon area name prefix to an EQEmu region type
+func servermapAreaType(name string) (raw.EmuWtrRegionType, bool) {
+	name = strings.ToUpper(name)
+	switch {
+	case strings.HasPrefix(name, "AWT"):
+		return raw.EmuWtrRegionTypeWater, true
+	case strings.HasPrefix(name, "ALV"):
+		return raw.EmuWtrRegionTypeLava, true
+	case strings.HasPrefix(name, "APK"):
+		return raw.EmuWtrRegionTypePVP, true
+	case strings.HasPrefix(name, "ATP"):
+		return raw.EmuWtrRegionTypeZoneLine, true
+	case strings.HasPrefix(name, "ASL"):
+		return raw.EmuWtrRegionTypeSlime, true
+	case strings.HasPrefix(name, "AVW"):
+		return raw.EmuWtrRegionTypeVWater, true
+	case strings.HasPrefix(name, "APV"):
+		return raw.EmuWtrRegionTypePreferPathing, true
+	case strings.HasPrefix(name, "ADN"):
+		return raw.EmuWtrRegionTypeDisableNavMesh, true
+	}
+	return raw.EmuWtrRegionTypeNormal, false
+}
+
+// servermapWtrEqg builds a version 2 water map from eqg zon areas and water.dat sheets
+func servermapWtrEqg(wld *wce.Wce, water *raw.DatWtr) (*raw.EmuWtr, error) {
+	emuWtr := &raw.EmuWtr{Version: 2}
+
+	for _, zon := range wld.ZonDefs {
+		for _, area := range zon.Areas {
+			regionType, ok := servermapAreaType(area.Name)
+			if !ok {
+				continue
+			}
+			emuWtr.Regions = append(emuWtr.Regions, &raw.EmuWtrRegion{
+				Type:     regionType,
+				Position: wce.EqgToServerAxis(area.Position),
+				Rotation: area.Orientation,
+				Scale:    [3]float32{1, 1, 1},
+				Extents:  wce.EqgToServerAxis(area.Extents),
+			})
+		}
+	}
+
+	if water == nil {
+		return emuWtr, nil
+	}
+	for _, sheet := range water.Watersheets {
+		position := [3]float32{
+			float32(sheet.MinX+sheet.MaxX) / 2,
+			float32(sheet.MinY+sheet.MaxY) / 2,
+			float32(sheet.ZHeight) - servermapWaterDepth/2,
+		}
+		extents := [3]float32{
+			float32(sheet.MaxX-sheet.MinX) / 2,
+			float32(sheet.MaxY-sheet.MinY) / 2,
+			servermapWaterDepth / 2,
+		}
+		emuWtr.Regions = append(emuWtr.Regions, &raw.EmuWtrRegion{
+			Type:     raw.EmuWtrRegionTypeWater,
+			Position: wce.EqgToServerAxis(position),
+			Scale:    [3]float32{1, 1, 1},
+			Extents:  wce.EqgToServerAxis(extents),
+		})
+	}
+	return emuWtr, nil
+}
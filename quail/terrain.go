@@ -0,0 +1,230 @@
+package quail
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/xackery/quail/raw"
+)
+
+// TerrainImages are zone wide images stitched from v4 zone .dat tiles.
+// Tiles are placed with latitude along x and longitude along y, higher longitudes at the top.
+// Neighbouring tiles share their edge vertices, so the height and color images are
+// tiles*QuadsPerTile+1 pixels wide, while masks are tiles*MaskDim pixels wide
+type TerrainImages struct {
+	Height    *image.Gray16
+	HeightMin float32 // height of a 0 pixel
+	HeightMax float32 // height of a 65535 pixel
+	Color     *image.NRGBA
+	MaskDim   int                    // mask pixels per tile
+	Masks     map[string]*image.Gray // layer detail masks keyed by material
+}
+
+// terrainGrid places tiles of a dat inside the stitched images
+type terrainGrid struct {
+	minLng       int32
+	minLat       int32
+	maxLng       int32
+	quadsPerTile int
+	width        int // tiles along x
+	height       int // tiles along y
+}
+
+func newTerrainGrid(dat *raw.DatZon) (*terrainGrid, error) {
+	if dat.QuadsPerTile < 1 {
+		return nil, fmt.Errorf("quads per tile %d must be at least 1", dat.QuadsPerTile)
+	}
+	if len(dat.Tiles) == 0 {
+		return nil, fmt.Errorf("no tiles")
+	}
+	grid := &terrainGrid{
+		minLng:       dat.Tiles[0].Lng,
+		minLat:       dat.Tiles[0].Lat,
+		maxLng:       dat.Tiles[0].Lng,
+		quadsPerTile: dat.QuadsPerTile,
+	}
+	maxLat := dat.Tiles[0].Lat
+	vertCount := (dat.QuadsPerTile + 1) * (dat.QuadsPerTile + 1)
+	for _, tile := range dat.Tiles {
+		if len(tile.Floats) != vertCount || len(tile.Colors) != vertCount {
+			return nil, fmt.Errorf("tile %d,%d has %d heights and %d colors, wanted %d", tile.Lng, tile.Lat, len(tile.Floats), len(tile.Colors), vertCount)
+		}
+		grid.minLng = min(grid.minLng, tile.Lng)
+		grid.maxLng = max(grid.maxLng, tile.Lng)
+		grid.minLat = min(grid.minLat, tile.Lat)
+		maxLat = max(maxLat, tile.Lat)
+	}
+	grid.width = int(maxLat-grid.minLat) + 1
+	grid.height = int(grid.maxLng-grid.minLng) + 1
+	return grid, nil
+}
+
+// vertex returns the pixel of vertex row, col of a tile in the height and color images
+func (g *terrainGrid) vertex(tile *raw.DatZonTile, row int, col int) (int, int) {
+	x := int(tile.Lat-g.minLat)*g.quadsPerTile + col
+	y := int(g.maxLng-tile.Lng)*g.quadsPerTile + (g.quadsPerTile - row)
+	return x, y
+}
+
+// origin returns the top left pixel of a tile in a mask
+func (g *terrainGrid) origin(tile *raw.DatZonTile, maskDim int) (int, int) {
+	return int(tile.Lat-g.minLat) * maskDim, int(g.maxLng-tile.Lng) * maskDim
+}
+
+// TerrainExport stitches the heights, vertex colors and layer masks of every dat tile
+func TerrainExport(dat *raw.DatZon) (*TerrainImages, error) {
+	grid, err := newTerrainGrid(dat)
+	if err != nil {
+		return nil, err
+	}
+	q := dat.QuadsPerTile
+
+	images := &TerrainImages{
+		HeightMin: float32(math.Inf(1)),
+		HeightMax: float32(math.Inf(-1)),
+		Masks:     map[string]*image.Gray{},
+	}
+	for _, tile := range dat.Tiles {
+		for _, h := range tile.Floats {
+			images.HeightMin = min(images.HeightMin, h)
+			images.HeightMax = max(images.HeightMax, h)
+		}
+		for _, layer := range tile.Layers {
+			images.MaskDim = max(images.MaskDim, int(layer.DetailMaskDim))
+		}
+	}
+	if !(images.HeightMin <= images.HeightMax) {
+		return nil, fmt.Errorf("terrain has no valid heights")
+	}
+	// a flat zone still needs a span for edited pixels to map back to heights
+	if images.HeightMax == images.HeightMin {
+		images.HeightMax = images.HeightMin + 1
+	}
+
+	bounds := image.Rect(0, 0, grid.width*q+1, grid.height*q+1)
+	images.Height = images.heightImage(dat, grid)
+	images.Color = image.NewNRGBA(bounds)
+	for _, tile := range dat.Tiles {
+		for row := 0; row <= q; row++ {
+			for col := 0; col <= q; col++ {
+				index := row*(q+1) + col
+				x, y := grid.vertex(tile, row, col)
+				c := tile.Colors[index]
+				images.Color.SetNRGBA(x, y, color.NRGBA{R: uint8(c >> 16), G: uint8(c >> 8), B: uint8(c), A: uint8(c >> 24)})
+			}
+		}
+
+		for _, layer := range tile.Layers {
+			dim := int(layer.DetailMaskDim)
+			if dim == 0 || len(layer.DetailMaskDims) != dim*dim {
+				continue
+			}
+			mask, ok := images.Masks[layer.Material]
+			if !ok {
+				mask = image.NewGray(image.Rect(0, 0, grid.width*images.MaskDim, grid.height*images.MaskDim))
+				images.Masks[layer.Material] = mask
+			}
+			ox, oy := grid.origin(tile, images.MaskDim)
+			for y := 0; y < images.MaskDim; y++ {
+				row := (images.MaskDim - 1 - y) * dim / images.MaskDim
+				for x := 0; x < images.MaskDim; x++ {
+					col := x * dim / images.MaskDim
+					mask.SetGray(ox+x, oy+y, color.Gray{Y: layer.DetailMaskDims[row*dim+col]})
+				}
+			}
+		}
+	}
+	return images, nil
+}
+
+// TerrainImport writes edited images back into the dat tiles. Heights are only replaced where
+// the pixel differs from the exported one, so untouched terrain keeps its full precision,
+// including edge vertices neighbouring tiles disagree on.
+// Masks update layers the tiles already have, new layers are not added
+func TerrainImport(dat *raw.DatZon, images *TerrainImages) error {
+	grid, err := newTerrainGrid(dat)
+	if err != nil {
+		return err
+	}
+	q := dat.QuadsPerTile
+
+	bounds := image.Rect(0, 0, grid.width*q+1, grid.height*q+1)
+	if images.Height != nil && images.Height.Bounds() != bounds {
+		return fmt.Errorf("height image is %s, wanted %s", images.Height.Bounds().Size(), bounds.Size())
+	}
+	if images.Color != nil && images.Color.Bounds() != bounds {
+		return fmt.Errorf("color image is %s, wanted %s", images.Color.Bounds().Size(), bounds.Size())
+	}
+	maskBounds := image.Rect(0, 0, grid.width*images.MaskDim, grid.height*images.MaskDim)
+	for material, mask := range images.Masks {
+		if mask.Bounds() != maskBounds {
+			return fmt.Errorf("mask %s is %s, wanted %s", material, mask.Bounds().Size(), maskBounds.Size())
+		}
+	}
+
+	var exported *image.Gray16
+	if images.Height != nil {
+		exported = images.heightImage(dat, grid)
+	}
+	for _, tile := range dat.Tiles {
+		for row := 0; row <= q; row++ {
+			for col := 0; col <= q; col++ {
+				index := row*(q+1) + col
+				x, y := grid.vertex(tile, row, col)
+				if images.Height != nil {
+					pixel := images.Height.Gray16At(x, y).Y
+					if pixel != exported.Gray16At(x, y).Y {
+						tile.Floats[index] = images.HeightMin + float32(pixel)/65535*(images.HeightMax-images.HeightMin)
+					}
+				}
+				if images.Color != nil {
+					c := images.Color.NRGBAAt(x, y)
+					tile.Colors[index] = uint32(c.A)<<24 | uint32(c.R)<<16 | uint32(c.G)<<8 | uint32(c.B)
+				}
+			}
+		}
+
+		for _, layer := range tile.Layers {
+			mask, ok := images.Masks[layer.Material]
+			dim := int(layer.DetailMaskDim)
+			if !ok || dim == 0 || len(layer.DetailMaskDims) != dim*dim {
+				continue
+			}
+			ox, oy := grid.origin(tile, images.MaskDim)
+			for row := 0; row < dim; row++ {
+				y := images.MaskDim - 1 - (2*row+1)*images.MaskDim/(2*dim)
+				for col := 0; col < dim; col++ {
+					x := (2*col + 1) * images.MaskDim / (2 * dim)
+					layer.DetailMaskDims[row*dim+col] = mask.GrayAt(ox+x, oy+y).Y
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// heightImage returns the height image as exported, where the last tile wins a shared edge vertex
+func (images *TerrainImages) heightImage(dat *raw.DatZon, grid *terrainGrid) *image.Gray16 {
+	q := dat.QuadsPerTile
+	height := image.NewGray16(image.Rect(0, 0, grid.width*q+1, grid.height*q+1))
+	for _, tile := range dat.Tiles {
+		for row := 0; row <= q; row++ {
+			for col := 0; col <= q; col++ {
+				x, y := grid.vertex(tile, row, col)
+				height.SetGray16(x, y, color.Gray16{Y: images.heightPixel(tile.Floats[row*(q+1)+col])})
+			}
+		}
+	}
+	return height
+}
+
+// heightPixel maps a height onto the 16 bit range of the height image
+func (images *TerrainImages) heightPixel(h float32) uint16 {
+	span := images.HeightMax - images.HeightMin
+	if span <= 0 {
+		return 0
+	}
+	return uint16(math.Round(float64((h - images.HeightMin) / span * 65535)))
+}
@@ -0,0 +1,118 @@
+package quail
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+
+	"github.com/xackery/quail/raw"
+)
+
+func terrainTestDat() *raw.DatZon {
+	dat := &raw.DatZon{QuadsPerTile: 2}
+	for i, lat := range []int32{100000, 100001} {
+		tile := &raw.DatZonTile{Lng: 100000, Lat: lat}
+		for j := 0; j < 9; j++ {
+			tile.Floats = append(tile.Floats, float32(i*10+j)*1.37)
+			tile.Colors = append(tile.Colors, 0xff000000|uint32(j*20)<<16|uint32(i*100))
+		}
+		dim := 2 + i*2
+		layer := &raw.DatZonLayer{Material: "grass", DetailMaskDim: uint32(dim)}
+		for j := 0; j < dim*dim; j++ {
+			layer.DetailMaskDims = append(layer.DetailMaskDims, uint8(j*15+i))
+		}
+		tile.Layers = append(tile.Layers, layer)
+		dat.Tiles = append(dat.Tiles, tile)
+	}
+	// shared edge vertices must agree
+	for row := 0; row < 3; row++ {
+		dat.Tiles[1].Floats[row*3] = dat.Tiles[0].Floats[row*3+2]
+		dat.Tiles[1].Colors[row*3] = dat.Tiles[0].Colors[row*3+2]
+	}
+	return dat
+}
+
+func TestTerrainExportImport(t *testing.T) {
+	dat := terrainTestDat()
+	images, err := TerrainExport(dat)
+	if err != nil {
+		t.Fatalf("export: %s", err.Error())
+	}
+	if images.Height.Bounds().Dx() != 5 || images.Height.Bounds().Dy() != 3 {
+		t.Fatalf("height size got %s", images.Height.Bounds().Size())
+	}
+	if images.MaskDim != 4 || images.Masks["grass"].Bounds().Dx() != 8 {
+		t.Fatalf("mask dim got %d", images.MaskDim)
+	}
+
+	// row 0 is the bottom of the image
+	if images.Height.Gray16At(0, 2).Y != 0 || images.Height.Gray16At(4, 0).Y != 65535 {
+		t.Fatalf("height corners got %d %d", images.Height.Gray16At(0, 2).Y, images.Height.Gray16At(4, 0).Y)
+	}
+
+	edited := terrainTestDat()
+	err = TerrainImport(edited, images)
+	if err != nil {
+		t.Fatalf("import: %s", err.Error())
+	}
+	if !reflect.DeepEqual(dat, edited) {
+		t.Fatalf("unedited import changed the dat")
+	}
+
+	images.Height.SetGray16(1, 1, color.Gray16{Y: 65535})
+	// a 2x2 tile mask stretched to 4x4 samples row 0 col 0 at pixel 1, 2
+	images.Masks["grass"].SetGray(1, 2, color.Gray{Y: 200})
+	err = TerrainImport(edited, images)
+	if err != nil {
+		t.Fatalf("import: %s", err.Error())
+	}
+	if edited.Tiles[0].Floats[4] != images.HeightMax || edited.Tiles[0].Floats[3] != dat.Tiles[0].Floats[3] {
+		t.Fatalf("height edit got %v", edited.Tiles[0].Floats)
+	}
+	if edited.Tiles[0].Layers[0].DetailMaskDims[0] != 200 {
+		t.Fatalf("mask edit got %v", edited.Tiles[0].Layers[0].DetailMaskDims)
+	}
+}
+
+func TestTerrainEdges(t *testing.T) {
+	// tile 0 disagrees with tile 1 on a shared edge vertex, tile 1 wins the exported pixel
+	dat := terrainTestDat()
+	dat.Tiles[0].Floats[2] += 0.5
+	images, err := TerrainExport(dat)
+	if err != nil {
+		t.Fatalf("export: %s", err.Error())
+	}
+	edited := terrainTestDat()
+	edited.Tiles[0].Floats[2] += 0.5
+	err = TerrainImport(edited, images)
+	if err != nil {
+		t.Fatalf("import: %s", err.Error())
+	}
+	if !reflect.DeepEqual(dat, edited) {
+		t.Fatalf("unedited import changed edge %v", edited.Tiles[0].Floats[2])
+	}
+
+	// a flat zone keeps a span so edits map back to heights
+	for _, tile := range dat.Tiles {
+		for i := range tile.Floats {
+			tile.Floats[i] = 12
+		}
+	}
+	images, err = TerrainExport(dat)
+	if err != nil {
+		t.Fatalf("export: %s", err.Error())
+	}
+	images.Height.SetGray16(1, 1, color.Gray16{Y: 65535})
+	err = TerrainImport(dat, images)
+	if err != nil {
+		t.Fatalf("import: %s", err.Error())
+	}
+	if dat.Tiles[0].Floats[4] != 13 || dat.Tiles[0].Floats[3] != 12 {
+		t.Fatalf("flat edit got %v", dat.Tiles[0].Floats)
+	}
+
+	_, err = TerrainExport(&raw.DatZon{QuadsPerTile: 2})
+	if err == nil {
+		t.Fatalf("expected empty terrain error")
+	}
+}
@@ -2,7 +2,6 @@ package raw
 
 import (
 	"bytes"
-	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -1,23 +1,168 @@
 package raw
 
 import (
+	"bytes"
+	"fmt"
 	"io"
+	"regexp"
+	"strings"
 )
 
+// Eco is a v4 zone ecology file. It is text made of *KEY value (or *KEY=value) properties
+// grouped in *BEGIN_<TYPE> / *END_<TYPE> blocks, describing terrain layers, flora and blends.
+// Typical usage is like so:
+/*
+*BEGIN_LAYER
+	*NAME		farstone_grass
+	*MATERIAL	t_grass01
+*END_LAYER
+*BEGIN_FLORA
+	*NAME		farstone_fern
+	*MODEL		obj_fern01
+	*DENSITY	0.5
+*END_FLORA
+*/
 type Eco struct {
-	MetaFileName string
+	MetaFileName      string
+	IsCRLF            bool // lines end in \r\n
+	IsTrailingNewline bool // last line ends in a newline
+	Entries           []*EcoEntry
 }
 
+// EcoEntry is a block of properties. Type is the block name such as LAYER, FLORA or BLEND,
+// properties outside of any block are kept in order as entries with an empty Type
+type EcoEntry struct {
+	Type       string
+	Properties []*EcoProperty
+	Begin      string // source lines of the block, written as is while they match Type
+	End        string
+}
+
+// EcoProperty is a *KEY value line. Lines that are not properties have an empty Key and
+// are kept verbatim in Value
+type EcoProperty struct {
+	Key   string
+	Value string
+	Line  string // source line, written as is while Key and Value are unchanged
+}
+
+var (
+	ecoBlockRegex    = regexp.MustCompile(`^\s*\*(BEGIN|END)_(\S+)\s*$`)
+	ecoPropertyRegex = regexp.MustCompile(`^\s*\*([^\s=]+)(?:=|\s+)(.*?)\s*$`)
+)
+
 // Identity returns the type of the struct
 func (e *Eco) Identity() string {
 	return "eco"
 }
 
 func (e *Eco) Read(r io.ReadSeeker) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return fmt.Errorf("binary eco is not supported")
+	}
+
+	e.Entries = []*EcoEntry{}
+	if len(data) == 0 {
+		return nil
+	}
+
+	text := string(data)
+	e.IsCRLF = strings.Contains(text, "\r\n")
+	e.IsTrailingNewline = strings.HasSuffix(text, "\n")
+	text = strings.TrimSuffix(text, "\n")
+	if e.IsCRLF {
+		text = strings.TrimSuffix(text, "\r")
+	}
+	lineEnd := "\n"
+	if e.IsCRLF {
+		lineEnd = "\r\n"
+	}
+
+	var block *EcoEntry
+	var loose *EcoEntry
+	for lineNumber, line := range strings.Split(text, lineEnd) {
+		match := ecoBlockRegex.FindStringSubmatch(line)
+		if match != nil && match[1] == "BEGIN" {
+			if block != nil {
+				return fmt.Errorf("line %d: *BEGIN_%s inside *BEGIN_%s", lineNumber+1, match[2], block.Type)
+			}
+			block = &EcoEntry{Type: match[2], Begin: line}
+			e.Entries = append(e.Entries, block)
+			loose = nil
+			continue
+		}
+		if match != nil {
+			if block == nil || match[2] != block.Type {
+				return fmt.Errorf("line %d: unexpected *END_%s", lineNumber+1, match[2])
+			}
+			block.End = line
+			block = nil
+			continue
+		}
+
+		property := &EcoProperty{Value: line, Line: line}
+		match = ecoPropertyRegex.FindStringSubmatch(line)
+		if match != nil {
+			property.Key = match[1]
+			property.Value = match[2]
+		}
+
+		if block != nil {
+			block.Properties = append(block.Properties, property)
+			continue
+		}
+		if loose == nil {
+			loose = &EcoEntry{}
+			e.Entries = append(e.Entries, loose)
+		}
+		loose.Properties = append(loose.Properties, property)
+	}
+	if block != nil {
+		return fmt.Errorf("*BEGIN_%s has no *END_%s", block.Type, block.Type)
+	}
 
 	return nil
 }
 
+// Layers returns the LAYER entries
+func (e *Eco) Layers() []*EcoEntry {
+	return e.entriesByType("LAYER")
+}
+
+// Floras returns the FLORA entries
+func (e *Eco) Floras() []*EcoEntry {
+	return e.entriesByType("FLORA")
+}
+
+// Blends returns the BLEND entries
+func (e *Eco) Blends() []*EcoEntry {
+	return e.entriesByType("BLEND")
+}
+
+func (e *Eco) entriesByType(entryType string) []*EcoEntry {
+	entries := []*EcoEntry{}
+	for _, entry := range e.Entries {
+		if strings.EqualFold(entry.Type, entryType) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Value returns the value of the first property named key, or "" if there is none
+func (e *EcoEntry) Value(key string) string {
+	for _, property := range e.Properties {
+		if strings.EqualFold(property.Key, key) {
+			return property.Value
+		}
+	}
+	return ""
+}
+
 // SetFileName sets the name of the file
 func (e *Eco) SetFileName(name string) {
 	e.MetaFileName = name
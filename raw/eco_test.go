@@ -65,3 +65,46 @@ func TestEcoWrite(t *testing.T) {
 		})
 	}
 }
+
+func TestEcoReadWrite(t *testing.T) {
+	data := []byte("*VERSION 1\r\n*BEGIN_LAYER\r\n\t*NAME\t\tfarstone_grass\r\n\t*MATERIAL\tt_grass01\r\n*END_LAYER\r\n" +
+		"*BEGIN_FLORA\r\n\t*NAME=farstone_fern\r\n\t*DENSITY   0.5\r\n\t// hand edited\r\n*END_FLORA\r\n" +
+		"*BEGIN_BLEND\r\n*FROM farstone_grass\r\n*TO farstone_dirt\r\n*END_BLEND\r\n")
+
+	eco := &Eco{}
+	err := eco.Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to read: %s", err.Error())
+	}
+	if len(eco.Entries) != 4 || len(eco.Layers()) != 1 || len(eco.Floras()) != 1 || len(eco.Blends()) != 1 {
+		t.Fatalf("got %d entries", len(eco.Entries))
+	}
+	if eco.Layers()[0].Value("material") != "t_grass01" || eco.Floras()[0].Value("NAME") != "farstone_fern" || eco.Floras()[0].Value("DENSITY") != "0.5" {
+		t.Fatalf("unexpected values %+v", eco.Layers()[0].Properties)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	err = eco.Write(buf)
+	if err != nil {
+		t.Fatalf("failed to write: %s", err.Error())
+	}
+	err = helper.ByteCompareTest(data, buf.Bytes())
+	if err != nil {
+		t.Fatalf("byteCompare: %s", err)
+	}
+
+	eco.Floras()[0].Properties[1].Value = "0.75"
+	buf.Reset()
+	err = eco.Write(buf)
+	if err != nil {
+		t.Fatalf("failed to write: %s", err.Error())
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("\t*DENSITY\t0.75\r\n")) {
+		t.Fatalf("edited property not written: %q", buf.String())
+	}
+
+	err = eco.Read(bytes.NewReader([]byte("*BEGIN_LAYER\n*NAME a\n")))
+	if err == nil {
+		t.Fatalf("expected unterminated block error")
+	}
+}
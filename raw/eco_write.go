@@ -1,10 +1,63 @@
 package raw
 
 import (
+	"fmt"
 	"io"
+	"strings"
 )
 
 func (e *Eco) Write(w io.Writer) error {
+	lineEnd := "\n"
+	if e.IsCRLF {
+		lineEnd = "\r\n"
+	}
 
+	lines := []string{}
+	for _, entry := range e.Entries {
+		indent := ""
+		if entry.Type != "" {
+			lines = append(lines, ecoBlockLine(entry.Begin, "BEGIN", entry.Type))
+			indent = "\t"
+		}
+		for _, property := range entry.Properties {
+			lines = append(lines, property.text(indent))
+		}
+		if entry.Type != "" {
+			lines = append(lines, ecoBlockLine(entry.End, "END", entry.Type))
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	text := strings.Join(lines, lineEnd)
+	if e.IsTrailingNewline {
+		text += lineEnd
+	}
+	_, err := w.Write([]byte(text))
+	if err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
 	return nil
 }
+
+// ecoBlockLine returns the source line of a block boundary if it still matches its type
+func ecoBlockLine(line string, boundary string, entryType string) string {
+	match := ecoBlockRegex.FindStringSubmatch(line)
+	if match != nil && match[1] == boundary && match[2] == entryType {
+		return line
+	}
+	return "*" + boundary + "_" + entryType
+}
+
+// text returns the source line of a property while its key and value are unchanged
+func (p *EcoProperty) text(indent string) string {
+	if p.Key == "" {
+		return p.Value
+	}
+	match := ecoPropertyRegex.FindStringSubmatch(p.Line)
+	if match != nil && match[1] == p.Key && match[2] == p.Value {
+		return p.Line
+	}
+	return fmt.Sprintf("%s*%s\t%s", indent, p.Key, p.Value)
+}
@@ -0,0 +1,183 @@
+package raw
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/xackery/encdec"
+)
+
+// EmuMapVersion2 is the only EQEmu server map version quail reads and writes
+const EmuMapVersion2 = 0x02000000
+
+// EmuMap is an EQEmu server side collision map (.map)
+type EmuMap struct {
+	MetaFileName       string
+	Version            uint32
+	Vertices           [][3]float32
+	Indices            []uint32
+	NonCollideVertices [][3]float32
+	NonCollideIndices  []uint32
+	Models             []*EmuMapModel
+	Placeables         []*EmuMapPlaceable
+	PlaceableGroups    []*EmuMapPlaceableGroup
+	QuadsPerTile       uint32
+	UnitsPerVertex     float32
+}
+
+// EmuMapModel is a named model referenced by placeables
+type EmuMapModel struct {
+	Name     string
+	Vertices [][3]float32
+	Polys    []*EmuMapPoly
+}
+
+// EmuMapPoly is a model triangle, Visible 0 means non collidable
+type EmuMapPoly struct {
+	Index   [3]uint32
+	Visible uint8
+}
+
+// EmuMapPlaceable is an instance of a model
+type EmuMapPlaceable struct {
+	Name        string
+	Translation [3]float32
+	Rotation    [3]float32
+	Scale       [3]float32
+}
+
+// EmuMapPlaceableGroup is a transformed group of placeables, Tile is added to Translation
+type EmuMapPlaceableGroup struct {
+	Translation [3]float32
+	Rotation    [3]float32
+	Scale       [3]float32
+	Tile        [3]float32
+	Placeables  []*EmuMapPlaceable
+}
+
+// Identity returns the type of the struct
+func (emu *EmuMap) Identity() string {
+	return "emumap"
+}
+
+// Read reads an EQEmu V2 map file
+func (emu *EmuMap) Read(r io.ReadSeeker) error {
+	dec := encdec.NewDecoder(r, binary.LittleEndian)
+
+	emu.Version = dec.Uint32()
+	if emu.Version != EmuMapVersion2 {
+		return fmt.Errorf("version 0x%x unsupported, wanted 0x%x", emu.Version, EmuMapVersion2)
+	}
+	dataSize := dec.Uint32()
+	bufferSize := dec.Uint32()
+	data := dec.Bytes(int(dataSize))
+	if dec.Error() != nil {
+		return fmt.Errorf("read header: %w", dec.Error())
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("zlib: %w", err)
+	}
+	defer zr.Close()
+	buf, err := io.ReadAll(zr)
+	if err != nil {
+		return fmt.Errorf("inflate: %w", err)
+	}
+	if len(buf) != int(bufferSize) {
+		return fmt.Errorf("inflated size %d, wanted %d", len(buf), bufferSize)
+	}
+
+	dec = encdec.NewDecoder(bytes.NewReader(buf), binary.LittleEndian)
+	vertCount := dec.Uint32()
+	indCount := dec.Uint32()
+	ncVertCount := dec.Uint32()
+	ncIndCount := dec.Uint32()
+	modelCount := dec.Uint32()
+	placeableCount := dec.Uint32()
+	groupCount := dec.Uint32()
+	tileCount := dec.Uint32()
+	emu.QuadsPerTile = dec.Uint32()
+	emu.UnitsPerVertex = dec.Float32()
+	if tileCount > 0 {
+		return fmt.Errorf("%d terrain tiles found, terrain tiles are not supported", tileCount)
+	}
+
+	emu.Vertices = emuMapReadVertices(dec, vertCount)
+	emu.Indices = emuMapReadIndices(dec, indCount)
+	emu.NonCollideVertices = emuMapReadVertices(dec, ncVertCount)
+	emu.NonCollideIndices = emuMapReadIndices(dec, ncIndCount)
+
+	for i := uint32(0); i < modelCount; i++ {
+		model := &EmuMapModel{Name: dec.StringZero()}
+		modelVertCount := dec.Uint32()
+		polyCount := dec.Uint32()
+		model.Vertices = emuMapReadVertices(dec, modelVertCount)
+		for j := uint32(0); j < polyCount; j++ {
+			poly := &EmuMapPoly{}
+			poly.Index = [3]uint32{dec.Uint32(), dec.Uint32(), dec.Uint32()}
+			poly.Visible = dec.Uint8()
+			model.Polys = append(model.Polys, poly)
+		}
+		emu.Models = append(emu.Models, model)
+	}
+
+	for i := uint32(0); i < placeableCount; i++ {
+		emu.Placeables = append(emu.Placeables, emuMapReadPlaceable(dec))
+	}
+
+	for i := uint32(0); i < groupCount; i++ {
+		group := &EmuMapPlaceableGroup{}
+		group.Translation = [3]float32{dec.Float32(), dec.Float32(), dec.Float32()}
+		group.Rotation = [3]float32{dec.Float32(), dec.Float32(), dec.Float32()}
+		group.Scale = [3]float32{dec.Float32(), dec.Float32(), dec.Float32()}
+		group.Tile = [3]float32{dec.Float32(), dec.Float32(), dec.Float32()}
+		count := dec.Uint32()
+		for j := uint32(0); j < count; j++ {
+			group.Placeables = append(group.Placeables, emuMapReadPlaceable(dec))
+		}
+		emu.PlaceableGroups = append(emu.PlaceableGroups, group)
+	}
+
+	if dec.Error() != nil {
+		return fmt.Errorf("read: %w", dec.Error())
+	}
+	return nil
+}
+
+func emuMapReadVertices(dec *encdec.Decoder, count uint32) [][3]float32 {
+	verts := make([][3]float32, 0, count)
+	for i := uint32(0); i < count; i++ {
+		verts = append(verts, [3]float32{dec.Float32(), dec.Float32(), dec.Float32()})
+	}
+	return verts
+}
+
+func emuMapReadIndices(dec *encdec.Decoder, count uint32) []uint32 {
+	inds := make([]uint32, 0, count)
+	for i := uint32(0); i < count; i++ {
+		inds = append(inds, dec.Uint32())
+	}
+	return inds
+}
+
+func emuMapReadPlaceable(dec *encdec.Decoder) *EmuMapPlaceable {
+	p := &EmuMapPlaceable{Name: dec.StringZero()}
+	p.Translation = [3]float32{dec.Float32(), dec.Float32(), dec.Float32()}
+	p.Rotation = [3]float32{dec.Float32(), dec.Float32(), dec.Float32()}
+	p.Scale = [3]float32{dec.Float32(), dec.Float32(), dec.Float32()}
+	return p
+}
+
+// SetFileName sets the name of the file
+func (emu *EmuMap) SetFileName(name string) {
+	emu.MetaFileName = name
+}
+
+// FileName returns the name of the file
+func (emu *EmuMap) FileName() string {
+	return emu.MetaFileName
+}
@@ -0,0 +1,205 @@
+package raw
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xackery/quail/helper"
+)
+
+func TestEmuMapWrite(t *testing.T) {
+	src := &EmuMap{
+		Vertices:           [][3]float32{{0, 0, 0}, {10, 0, 0}, {0, 10, 5}},
+		Indices:            []uint32{0, 1, 2},
+		NonCollideVertices: [][3]float32{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}},
+		NonCollideIndices:  []uint32{2, 1, 0},
+		Models: []*EmuMapModel{
+			{Name: "box", Vertices: [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}, Polys: []*EmuMapPoly{{Index: [3]uint32{0, 1, 2}, Visible: 1}}},
+		},
+		Placeables: []*EmuMapPlaceable{
+			{Name: "box", Translation: [3]float32{1, 2, 3}, Rotation: [3]float32{0, 0, 90}, Scale: [3]float32{1, 1, 1}},
+		},
+		PlaceableGroups: []*EmuMapPlaceableGroup{
+			{Scale: [3]float32{1, 1, 1}, Tile: [3]float32{16, 32, 0}, Placeables: []*EmuMapPlaceable{{Name: "box", Scale: [3]float32{2, 2, 2}}}},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	err := src.Write(buf)
+	if err != nil {
+		t.Fatalf("write: %s", err)
+	}
+
+	dst := &EmuMap{}
+	err = dst.Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("read: %s", err)
+	}
+	if len(dst.Vertices) != 3 || dst.Vertices[2] != src.Vertices[2] {
+		t.Fatalf("vertices mismatch: %v", dst.Vertices)
+	}
+	if len(dst.NonCollideIndices) != 3 || dst.NonCollideIndices[0] != 2 {
+		t.Fatalf("non collide indices mismatch: %v", dst.NonCollideIndices)
+	}
+	if len(dst.Models) != 1 || dst.Models[0].Name != "box" || len(dst.Models[0].Polys) != 1 {
+		t.Fatalf("models mismatch")
+	}
+	if len(dst.PlaceableGroups) != 1 || dst.PlaceableGroups[0].Tile[1] != 32 || dst.PlaceableGroups[0].Placeables[0].Scale[0] != 2 {
+		t.Fatalf("placeable groups mismatch")
+	}
+
+	buf2 := &bytes.Buffer{}
+	err = dst.Write(buf2)
+	if err != nil {
+		t.Fatalf("write2: %s", err)
+	}
+	err = helper.ByteCompareTest(buf.Bytes(), buf2.Bytes())
+	if err != nil {
+		t.Fatalf("byteCompare: %s", err)
+	}
+}
+
+// emuMapLoadV2Body returns a map body laid out field by field as EQEmu's Map::LoadV2 reads it
+func emuMapLoadV2Body(t *testing.T) []byte {
+	body := &bytes.Buffer{}
+	write := func(values ...interface{}) {
+		for _, v := range values {
+			err := binary.Write(body, binary.LittleEndian, v)
+			if err != nil {
+				t.Fatalf("write fixture: %s", err)
+			}
+		}
+	}
+	// vert_count, ind_count, nc_vert_count, nc_ind_count, model_count, plac_count, plac_group_count
+	write(uint32(3), uint32(3), uint32(0), uint32(0), uint32(1), uint32(1), uint32(1))
+	// tile_count, quads_per_tile, units_per_vertex
+	write(uint32(0), uint32(32), float32(4))
+	write([9]float32{0, 0, 0, 10, 0, 0, 0, 10, 5})
+	write([3]uint32{0, 1, 2})
+	// model
+	write([]byte("box\x00"), uint32(3), uint32(1))
+	write([9]float32{0, 0, 0, 1, 0, 0, 0, 1, 0})
+	write([3]uint32{0, 1, 2}, uint8(1))
+	// placeable
+	write([]byte("box\x00"), [9]float32{1, 2, 3, 0, 0, 90, 1, 1, 1})
+	// placeable group, x y z, rotation, scale, then x_tile y_tile z_tile
+	write([12]float32{100, 200, 0, 0, 0, 0, 1, 1, 1, 16, 32, 0})
+	write(uint32(1), []byte("box\x00"), [9]float32{0, 0, 0, 0, 0, 0, 2, 2, 2})
+	return body.Bytes()
+}
+
+// emuMapBody returns the inflated body of a map file
+func emuMapBody(t *testing.T, data []byte) []byte {
+	if len(data) < 12 {
+		t.Fatalf("map is %d bytes", len(data))
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(data[12:]))
+	if err != nil {
+		t.Fatalf("zlib: %s", err)
+	}
+	defer zr.Close()
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("inflate: %s", err)
+	}
+	return body
+}
+
+func TestEmuMapReadLoadV2(t *testing.T) {
+	body := emuMapLoadV2Body(t)
+	deflated := &bytes.Buffer{}
+	zw := zlib.NewWriter(deflated)
+	_, err := zw.Write(body)
+	if err != nil {
+		t.Fatalf("deflate: %s", err)
+	}
+	err = zw.Close()
+	if err != nil {
+		t.Fatalf("deflate close: %s", err)
+	}
+	data := &bytes.Buffer{}
+	for _, v := range []uint32{EmuMapVersion2, uint32(deflated.Len()), uint32(len(body))} {
+		err = binary.Write(data, binary.LittleEndian, v)
+		if err != nil {
+			t.Fatalf("write header: %s", err)
+		}
+	}
+	data.Write(deflated.Bytes())
+
+	emu := &EmuMap{}
+	err = emu.Read(bytes.NewReader(data.Bytes()))
+	if err != nil {
+		t.Fatalf("read: %s", err)
+	}
+	if emu.QuadsPerTile != 32 || emu.UnitsPerVertex != 4 {
+		t.Fatalf("got quads per tile %d units per vertex %f", emu.QuadsPerTile, emu.UnitsPerVertex)
+	}
+	if len(emu.Vertices) != 3 || emu.Vertices[2] != [3]float32{0, 10, 5} {
+		t.Fatalf("vertices mismatch: %v", emu.Vertices)
+	}
+	if len(emu.Placeables) != 1 || emu.Placeables[0].Rotation[2] != 90 {
+		t.Fatalf("placeables mismatch")
+	}
+	if len(emu.PlaceableGroups) != 1 || emu.PlaceableGroups[0].Tile != [3]float32{16, 32, 0} || emu.PlaceableGroups[0].Placeables[0].Scale[0] != 2 {
+		t.Fatalf("placeable groups mismatch")
+	}
+
+	buf := &bytes.Buffer{}
+	err = emu.Write(buf)
+	if err != nil {
+		t.Fatalf("write: %s", err)
+	}
+	err = helper.ByteCompareTest(body, emuMapBody(t, buf.Bytes()))
+	if err != nil {
+		t.Fatalf("byteCompare: %s", err)
+	}
+}
+
+func TestEmuMapReadEQEmu(t *testing.T) {
+	if os.Getenv("SINGLE_TEST") != "1" {
+		t.Skip("skipping test; SINGLE_TEST not set")
+	}
+
+	// the maps folder of an EQEmu server
+	mapPath := os.Getenv("EQEMU_MAP_PATH")
+	if mapPath == "" {
+		t.Skip("EQEMU_MAP_PATH not set")
+	}
+
+	tests := []struct {
+		file string
+	}{
+		// Add more as desired
+		{file: "base/qeynos2.map"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join(mapPath, tt.file))
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+
+			emu := &EmuMap{}
+			err = emu.Read(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+
+			buf := &bytes.Buffer{}
+			err = emu.Write(buf)
+			if err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			err = helper.ByteCompareTest(emuMapBody(t, data), emuMapBody(t, buf.Bytes()))
+			if err != nil {
+				t.Fatalf("byteCompare: %s", err)
+			}
+		})
+	}
+}
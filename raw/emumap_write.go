@@ -0,0 +1,101 @@
+package raw
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/xackery/encdec"
+)
+
+// Write writes an EQEmu V2 map file
+func (emu *EmuMap) Write(w io.Writer) error {
+	buf := &bytes.Buffer{}
+	enc := encdec.NewEncoder(buf, binary.LittleEndian)
+
+	enc.Uint32(uint32(len(emu.Vertices)))
+	enc.Uint32(uint32(len(emu.Indices)))
+	enc.Uint32(uint32(len(emu.NonCollideVertices)))
+	enc.Uint32(uint32(len(emu.NonCollideIndices)))
+	enc.Uint32(uint32(len(emu.Models)))
+	enc.Uint32(uint32(len(emu.Placeables)))
+	enc.Uint32(uint32(len(emu.PlaceableGroups)))
+	// terrain tiles are always flattened into vertices
+	enc.Uint32(0)
+	enc.Uint32(emu.QuadsPerTile)
+	enc.Float32(emu.UnitsPerVertex)
+
+	emuMapWriteVertices(enc, emu.Vertices)
+	for _, ind := range emu.Indices {
+		enc.Uint32(ind)
+	}
+	emuMapWriteVertices(enc, emu.NonCollideVertices)
+	for _, ind := range emu.NonCollideIndices {
+		enc.Uint32(ind)
+	}
+
+	for _, model := range emu.Models {
+		enc.StringZero(model.Name)
+		enc.Uint32(uint32(len(model.Vertices)))
+		enc.Uint32(uint32(len(model.Polys)))
+		emuMapWriteVertices(enc, model.Vertices)
+		for _, poly := range model.Polys {
+			enc.Uint32(poly.Index[0])
+			enc.Uint32(poly.Index[1])
+			enc.Uint32(poly.Index[2])
+			enc.Uint8(poly.Visible)
+		}
+	}
+
+	for _, p := range emu.Placeables {
+		emuMapWritePlaceable(enc, p)
+	}
+
+	for _, group := range emu.PlaceableGroups {
+		emuMapWriteVertices(enc, [][3]float32{group.Translation, group.Rotation, group.Scale, group.Tile})
+		enc.Uint32(uint32(len(group.Placeables)))
+		for _, p := range group.Placeables {
+			emuMapWritePlaceable(enc, p)
+		}
+	}
+
+	if enc.Error() != nil {
+		return fmt.Errorf("write body: %w", enc.Error())
+	}
+
+	data := &bytes.Buffer{}
+	zw := zlib.NewWriter(data)
+	_, err := zw.Write(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("deflate: %w", err)
+	}
+	err = zw.Close()
+	if err != nil {
+		return fmt.Errorf("deflate close: %w", err)
+	}
+
+	enc = encdec.NewEncoder(w, binary.LittleEndian)
+	enc.Uint32(EmuMapVersion2)
+	enc.Uint32(uint32(data.Len()))
+	enc.Uint32(uint32(buf.Len()))
+	enc.Bytes(data.Bytes())
+	if enc.Error() != nil {
+		return fmt.Errorf("write: %w", enc.Error())
+	}
+	return nil
+}
+
+func emuMapWriteVertices(enc *encdec.Encoder, verts [][3]float32) {
+	for _, v := range verts {
+		enc.Float32(v[0])
+		enc.Float32(v[1])
+		enc.Float32(v[2])
+	}
+}
+
+func emuMapWritePlaceable(enc *encdec.Encoder, p *EmuMapPlaceable) {
+	enc.StringZero(p.Name)
+	emuMapWriteVertices(enc, [][3]float32{p.Translation, p.Rotation, p.Scale})
+}
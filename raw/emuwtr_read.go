@@ -0,0 +1,114 @@
+package raw
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/xackery/encdec"
+)
+
+// EmuWtrRegionType is the region type used by EQEmu water maps
+type EmuWtrRegionType int32
+
+const (
+	EmuWtrRegionTypeNormal EmuWtrRegionType = iota
+	EmuWtrRegionTypeWater
+	EmuWtrRegionTypeLava
+	EmuWtrRegionTypeZoneLine
+	EmuWtrRegionTypePVP
+	EmuWtrRegionTypeSlime
+	EmuWtrRegionTypeIce
+	EmuWtrRegionTypeVWater
+	EmuWtrRegionTypeGeneralArea
+	EmuWtrRegionTypePreferPathing
+	EmuWtrRegionTypeDisableNavMesh
+)
+
+// EmuWtr is an EQEmu server side water map (.wtr)
+// Version 1 is a bsp tree used by s3d zones, version 2 is a list of oriented boxes used by eqg zones
+type EmuWtr struct {
+	MetaFileName string
+	Version      uint32
+	Nodes        []*EmuWtrNode
+	Regions      []*EmuWtrRegion
+}
+
+// EmuWtrNode is a version 1 bsp node, Left and Right are 1 based node numbers, 0 is a leaf
+type EmuWtrNode struct {
+	NodeNumber    int32
+	Normal        [3]float32
+	SplitDistance float32
+	Region        int32
+	Special       EmuWtrRegionType
+	Left          int32
+	Right         int32
+}
+
+// EmuWtrRegion is a version 2 oriented bounding box
+type EmuWtrRegion struct {
+	Type     EmuWtrRegionType
+	Position [3]float32
+	Rotation [3]float32
+	Scale    [3]float32
+	Extents  [3]float32
+}
+
+// Identity returns the type of the struct
+func (emu *EmuWtr) Identity() string {
+	return "emuwtr"
+}
+
+// Read reads an EQEmu water map file
+func (emu *EmuWtr) Read(r io.ReadSeeker) error {
+	dec := encdec.NewDecoder(r, binary.LittleEndian)
+
+	header := dec.StringFixed(10)
+	if header != "EQEMUWATER" {
+		return fmt.Errorf("invalid header %s, wanted EQEMUWATER", header)
+	}
+	emu.Version = dec.Uint32()
+	switch emu.Version {
+	case 1:
+		count := dec.Uint32()
+		for i := uint32(0); i < count; i++ {
+			node := &EmuWtrNode{}
+			node.NodeNumber = dec.Int32()
+			node.Normal = [3]float32{dec.Float32(), dec.Float32(), dec.Float32()}
+			node.SplitDistance = dec.Float32()
+			node.Region = dec.Int32()
+			node.Special = EmuWtrRegionType(dec.Int32())
+			node.Left = dec.Int32()
+			node.Right = dec.Int32()
+			emu.Nodes = append(emu.Nodes, node)
+		}
+	case 2:
+		count := dec.Uint32()
+		for i := uint32(0); i < count; i++ {
+			region := &EmuWtrRegion{}
+			region.Type = EmuWtrRegionType(dec.Uint32())
+			region.Position = [3]float32{dec.Float32(), dec.Float32(), dec.Float32()}
+			region.Rotation = [3]float32{dec.Float32(), dec.Float32(), dec.Float32()}
+			region.Scale = [3]float32{dec.Float32(), dec.Float32(), dec.Float32()}
+			region.Extents = [3]float32{dec.Float32(), dec.Float32(), dec.Float32()}
+			emu.Regions = append(emu.Regions, region)
+		}
+	default:
+		return fmt.Errorf("version %d unsupported", emu.Version)
+	}
+
+	if dec.Error() != nil {
+		return fmt.Errorf("read: %w", dec.Error())
+	}
+	return nil
+}
+
+// SetFileName sets the name of the file
+func (emu *EmuWtr) SetFileName(name string) {
+	emu.MetaFileName = name
+}
+
+// FileName returns the name of the file
+func (emu *EmuWtr) FileName() string {
+	return emu.MetaFileName
+}
@@ -0,0 +1,50 @@
+package raw
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/xackery/quail/helper"
+)
+
+func TestEmuWtrWrite(t *testing.T) {
+	tests := []struct {
+		name string
+		wtr  *EmuWtr
+	}{
+		{name: "bsp", wtr: &EmuWtr{Version: 1, Nodes: []*EmuWtrNode{
+			{NodeNumber: 1, Normal: [3]float32{0, 0, 1}, SplitDistance: -5, Left: 2, Right: 3},
+			{NodeNumber: 2, Region: 1, Special: EmuWtrRegionTypeWater},
+			{NodeNumber: 3, Region: 2, Special: EmuWtrRegionTypeNormal},
+		}}},
+		{name: "boxes", wtr: &EmuWtr{Version: 2, Regions: []*EmuWtrRegion{
+			{Type: EmuWtrRegionTypeLava, Position: [3]float32{1, 2, 3}, Scale: [3]float32{1, 1, 1}, Extents: [3]float32{10, 10, 4}},
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := tt.wtr.Write(buf)
+			if err != nil {
+				t.Fatalf("write: %s", err)
+			}
+			dst := &EmuWtr{}
+			err = dst.Read(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatalf("read: %s", err)
+			}
+			if len(dst.Nodes) != len(tt.wtr.Nodes) || len(dst.Regions) != len(tt.wtr.Regions) {
+				t.Fatalf("count mismatch")
+			}
+			buf2 := &bytes.Buffer{}
+			err = dst.Write(buf2)
+			if err != nil {
+				t.Fatalf("write2: %s", err)
+			}
+			err = helper.ByteCompareTest(buf.Bytes(), buf2.Bytes())
+			if err != nil {
+				t.Fatalf("byteCompare: %s", err)
+			}
+		})
+	}
+}
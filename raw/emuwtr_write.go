@@ -0,0 +1,49 @@
+package raw
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/xackery/encdec"
+)
+
+// Write writes an EQEmu water map file
+func (emu *EmuWtr) Write(w io.Writer) error {
+	enc := encdec.NewEncoder(w, binary.LittleEndian)
+
+	enc.StringFixed("EQEMUWATER", 10)
+	enc.Uint32(emu.Version)
+	switch emu.Version {
+	case 1:
+		enc.Uint32(uint32(len(emu.Nodes)))
+		for _, node := range emu.Nodes {
+			enc.Int32(node.NodeNumber)
+			enc.Float32(node.Normal[0])
+			enc.Float32(node.Normal[1])
+			enc.Float32(node.Normal[2])
+			enc.Float32(node.SplitDistance)
+			enc.Int32(node.Region)
+			enc.Int32(int32(node.Special))
+			enc.Int32(node.Left)
+			enc.Int32(node.Right)
+		}
+	case 2:
+		enc.Uint32(uint32(len(emu.Regions)))
+		for _, region := range emu.Regions {
+			enc.Uint32(uint32(region.Type))
+			for _, vals := range [][3]float32{region.Position, region.Rotation, region.Scale, region.Extents} {
+				enc.Float32(vals[0])
+				enc.Float32(vals[1])
+				enc.Float32(vals[2])
+			}
+		}
+	default:
+		return fmt.Errorf("version %d unsupported", emu.Version)
+	}
+
+	if enc.Error() != nil {
+		return fmt.Errorf("write: %w", enc.Error())
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package raw
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// MapTxt is an in-game (brewall style) map file, e.g. maps/crushbone_1.txt
+// Typical usage is like so:
+/*
+L 100.0000, 200.0000, 0.0000, 150.0000, 200.0000, 0.0000, 0, 0, 0
+P 120.0000, 210.0000, 0.0000, 240, 0, 0, 3, To_Butcherblock
+*/
+// Coordinates are stored with x and y negated compared to world space.
+type MapTxt struct {
+	MetaFileName string
+	Lines        []*MapTxtLine
+	Points       []*MapTxtPoint
+}
+
+// MapTxtLine is a L record
+type MapTxtLine struct {
+	From  [3]float32
+	To    [3]float32
+	Color [3]uint8
+}
+
+// MapTxtPoint is a P record, spaces in Label are stored as underscores
+type MapTxtPoint struct {
+	Position [3]float32
+	Color    [3]uint8
+	Size     int
+	Label    string
+}
+
+// Identity returns the type of the struct
+func (mt *MapTxt) Identity() string {
+	return "maptxt"
+}
+
+// Read reads a map txt file
+func (mt *MapTxt) Read(r io.ReadSeeker) error {
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) < 2 {
+			continue
+		}
+
+		records := strings.Split(line[2:], ",")
+		for i := range records {
+			records[i] = strings.TrimSpace(records[i])
+		}
+
+		switch line[0] {
+		case 'L':
+			if len(records) != 9 {
+				return fmt.Errorf("line %d: L wanted 9 records, got %d", lineNumber, len(records))
+			}
+			entry := &MapTxtLine{}
+			err := mapTxtParseFloats(records[0:3], entry.From[:])
+			if err != nil {
+				return fmt.Errorf("line %d from: %w", lineNumber, err)
+			}
+			err = mapTxtParseFloats(records[3:6], entry.To[:])
+			if err != nil {
+				return fmt.Errorf("line %d to: %w", lineNumber, err)
+			}
+			err = mapTxtParseColor(records[6:9], &entry.Color)
+			if err != nil {
+				return fmt.Errorf("line %d color: %w", lineNumber, err)
+			}
+			mt.Lines = append(mt.Lines, entry)
+		case 'P':
+			if len(records) < 8 {
+				return fmt.Errorf("line %d: P wanted 8 records, got %d", lineNumber, len(records))
+			}
+			entry := &MapTxtPoint{}
+			err := mapTxtParseFloats(records[0:3], entry.Position[:])
+			if err != nil {
+				return fmt.Errorf("line %d position: %w", lineNumber, err)
+			}
+			err = mapTxtParseColor(records[3:6], &entry.Color)
+			if err != nil {
+				return fmt.Errorf("line %d color: %w", lineNumber, err)
+			}
+			entry.Size, err = strconv.Atoi(records[6])
+			if err != nil {
+				return fmt.Errorf("line %d size: %w", lineNumber, err)
+			}
+			// labels may contain commas
+			entry.Label = strings.ReplaceAll(strings.Join(records[7:], ","), "_", " ")
+			mt.Points = append(mt.Points, entry)
+		default:
+			return fmt.Errorf("line %d: unknown record %c", lineNumber, line[0])
+		}
+	}
+	err := scanner.Err()
+	if err != nil {
+		return fmt.Errorf("scan: %w", err)
+	}
+	return nil
+}
+
+func mapTxtParseFloats(records []string, dst []float32) error {
+	for i, record := range records {
+		val, err := strconv.ParseFloat(record, 32)
+		if err != nil {
+			return fmt.Errorf("%d: %w", i, err)
+		}
+		dst[i] = float32(val)
+	}
+	return nil
+}
+
+func mapTxtParseColor(records []string, dst *[3]uint8) error {
+	for i, record := range records {
+		val, err := strconv.ParseUint(record, 10, 8)
+		if err != nil {
+			return fmt.Errorf("%d: %w", i, err)
+		}
+		dst[i] = uint8(val)
+	}
+	return nil
+}
+
+// SetFileName sets the name of the file
+func (mt *MapTxt) SetFileName(name string) {
+	mt.MetaFileName = name
+}
+
+// FileName returns the name of the file
+func (mt *MapTxt) FileName() string {
+	return mt.MetaFileName
+}
@@ -0,0 +1,36 @@
+package raw
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/xackery/quail/helper"
+)
+
+func TestMapTxtWrite(t *testing.T) {
+	src := []byte("L 100.0000, -200.5000, 3.0000, 150.0000, -200.5000, 3.0000, 0, 0, 0\r\n" +
+		"L 150.0000, -200.5000, 3.0000, 150.0000, 10.0000, 3.0000, 127, 64, 0\r\n" +
+		"P 120.0000, 210.0000, 0.0000, 240, 0, 0, 3, To_Butcherblock_Mountains\r\n")
+
+	mt := &MapTxt{}
+	err := mt.Read(bytes.NewReader(src))
+	if err != nil {
+		t.Fatalf("read: %s", err)
+	}
+	if len(mt.Lines) != 2 || len(mt.Points) != 1 {
+		t.Fatalf("got %d lines %d points, want 2 and 1", len(mt.Lines), len(mt.Points))
+	}
+	if mt.Points[0].Label != "To Butcherblock Mountains" {
+		t.Fatalf("label got %q", mt.Points[0].Label)
+	}
+
+	buf := &bytes.Buffer{}
+	err = mt.Write(buf)
+	if err != nil {
+		t.Fatalf("write: %s", err)
+	}
+	err = helper.ByteCompareTest(src, buf.Bytes())
+	if err != nil {
+		t.Fatalf("byteCompare: %s", err)
+	}
+}
@@ -0,0 +1,33 @@
+package raw
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Write writes a map txt file
+func (mt *MapTxt) Write(w io.Writer) error {
+	writer := bufio.NewWriter(w)
+
+	for _, line := range mt.Lines {
+		fmt.Fprintf(writer, "L %0.4f, %0.4f, %0.4f, %0.4f, %0.4f, %0.4f, %d, %d, %d\r\n",
+			line.From[0], line.From[1], line.From[2],
+			line.To[0], line.To[1], line.To[2],
+			line.Color[0], line.Color[1], line.Color[2])
+	}
+
+	for _, point := range mt.Points {
+		fmt.Fprintf(writer, "P %0.4f, %0.4f, %0.4f, %d, %d, %d, %d, %s\r\n",
+			point.Position[0], point.Position[1], point.Position[2],
+			point.Color[0], point.Color[1], point.Color[2],
+			point.Size, strings.ReplaceAll(point.Label, " ", "_"))
+	}
+
+	err := writer.Flush()
+	if err != nil {
+		return fmt.Errorf("flush writer: %w", err)
+	}
+	return nil
+}
@@ -55,6 +55,8 @@ func New(name string) ReadWriter {
 		return &Lit{}
 	case ".lod":
 		return &Lod{}
+	case ".map":
+		return &EmuMap{}
 	case ".mds":
 		return &Mds{}
 	case ".mod":
@@ -71,6 +73,8 @@ func New(name string) ReadWriter {
 		return &Tog{}
 	case ".wld":
 		return &Wld{}
+	case ".wtr":
+		return &EmuWtr{}
 	case ".zon":
 		return &Zon{}
 	case ".env":
@@ -1,20 +1,97 @@
 package raw
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
 	"io"
+
+	"github.com/xackery/encdec"
 )
 
+// Rfd is radial flora data, which scatters flora of a v4 zone ecology around points.
+// floraexclusion.dat areas remove flora placed by these files.
+// The entry layout is not yet checked against shipped files, so a file it does not fit is kept in Data
 type Rfd struct {
 	MetaFileName string
+	Version      uint32
+	Entries      []*RfdEntry
+	Data         string // base64 of a file that did not parse, written back as is
+}
+
+// RfdEntry scatters one flora type in a circle
+type RfdEntry struct {
+	Flora    string     // flora name in the zone .eco
+	Position [3]float32 // center
+	Radius   float32
+	Density  float32 // flora per square unit
+	ScaleMin float32
+	ScaleMax float32
+	Seed     uint32
 }
 
 // Identity returns the type of the struct
 func (e *Rfd) Identity() string {
-	return "rdf"
+	return "rfd"
 }
 
+// Read reads a RFD file, keeping the bytes in Data if they do not fit the entry layout
 func (e *Rfd) Read(r io.ReadSeeker) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+	e.Data = ""
+	err = e.readEntries(bytes.NewReader(data))
+	if err != nil {
+		e.Version = 0
+		e.Entries = nil
+		e.Data = base64.StdEncoding.EncodeToString(data)
+	}
+	return nil
+}
+
+func (e *Rfd) readEntries(r io.ReadSeeker) error {
+	dec := encdec.NewDecoder(r, binary.LittleEndian)
+
+	e.Version = dec.Uint32()
+	entryCount := dec.Uint32()
+	if entryCount > 999999 {
+		return fmt.Errorf("entry count %d is too high", entryCount)
+	}
+
+	e.Entries = make([]*RfdEntry, 0, entryCount)
+	for i := 0; i < int(entryCount); i++ {
+		entry := &RfdEntry{}
+		entry.Flora = dec.StringZero()
+		entry.Position[0] = dec.Float32()
+		entry.Position[1] = dec.Float32()
+		entry.Position[2] = dec.Float32()
+		entry.Radius = dec.Float32()
+		entry.Density = dec.Float32()
+		entry.ScaleMin = dec.Float32()
+		entry.ScaleMax = dec.Float32()
+		entry.Seed = dec.Uint32()
+		e.Entries = append(e.Entries, entry)
+	}
+
+	pos := dec.Pos()
+	endPos, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("seek end: %w", err)
+	}
+	if pos < endPos {
+		return fmt.Errorf("%d bytes remaining (%d total)", endPos-pos, endPos)
+	}
+	if pos > endPos {
+		return fmt.Errorf("read past end of file")
+	}
 
+	err = dec.Error()
+	if err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
 	return nil
 }
 
@@ -19,12 +19,9 @@ func TestRfdWrite(t *testing.T) {
 	dirTest := helper.DirTest()
 
 	tests := []struct {
-		name    string
-		lodName string
+		name string
 	}{
-		{name: "alkabormare.eqg", lodName: "obp_treelg.lod"},
-
-		//{name: "ggy.eqg", lodName: "ggy.lod"},
+		{name: "alkabormare.eqg"},
 	}
 
 	for _, tt := range tests {
@@ -34,51 +31,82 @@ func TestRfdWrite(t *testing.T) {
 				t.Fatalf("failed to open eqg %s: %s", tt.name, err.Error())
 			}
 			for _, file := range pfs.Files() {
-				if filepath.Ext(file.Name()) != ".lod" {
+				if filepath.Ext(file.Name()) != ".rfd" {
 					continue
 				}
-				lod := &Lod{}
+				rfd := &Rfd{}
 
-				err = lod.Read(bytes.NewReader(file.Data()))
+				err = rfd.Read(bytes.NewReader(file.Data()))
 				os.WriteFile(fmt.Sprintf("%s/%s", dirTest, file.Name()), file.Data(), 0644)
 				if err != nil {
-					t.Fatalf("failed to read %s: %s", tt.name, err.Error())
+					t.Fatalf("failed to read %s: %s", file.Name(), err.Error())
 				}
 
 				buf := bytes.NewBuffer(nil)
-				err = lod.Write(buf)
+				err = rfd.Write(buf)
 				if err != nil {
-					t.Fatalf("failed to write %s: %s", tt.name, err.Error())
+					t.Fatalf("failed to write %s: %s", file.Name(), err.Error())
 				}
 
-				lod2 := &Lod{}
-				err = lod2.Read(bytes.NewReader(buf.Bytes()))
+				err = helper.ByteCompareTest(file.Data(), buf.Bytes())
 				if err != nil {
-					t.Fatalf("failed to read %s: %s", tt.name, err.Error())
+					t.Fatalf("%s byteCompare: %s", file.Name(), err)
 				}
+			}
+		})
+	}
+}
 
-				if len(lod.Entries) != len(lod2.Entries) {
-					t.Fatalf("lod mismatch: %d != %d", len(lod.Entries), len(lod2.Entries))
-				}
+func TestRfdReadWrite(t *testing.T) {
+	rfd := &Rfd{
+		Version: 1,
+		Entries: []*RfdEntry{
+			{Flora: "farstone_fern", Position: [3]float32{10, -20, 5}, Radius: 32, Density: 0.25, ScaleMin: 0.8, ScaleMax: 1.2, Seed: 1234},
+			{Flora: "farstone_rock", Position: [3]float32{-100, 40, 0}, Radius: 8, Density: 0.05, ScaleMin: 1, ScaleMax: 1},
+		},
+	}
 
-				for i := range lod.Entries {
-					if lod.Entries[i].Category != lod2.Entries[i].Category {
-						t.Fatalf("category mismatch: %s != %s", lod.Entries[i].Category, lod2.Entries[i].Category)
-					}
-					if lod.Entries[i].Distance != lod2.Entries[i].Distance {
-						t.Fatalf("distance mismatch: %0.2f != %0.2f", lod.Entries[i].Distance, lod2.Entries[i].Distance)
-					}
+	buf := bytes.NewBuffer(nil)
+	err := rfd.Write(buf)
+	if err != nil {
+		t.Fatalf("failed to write: %s", err.Error())
+	}
 
-					if lod.Entries[i].ObjectName != lod2.Entries[i].ObjectName {
-						t.Fatalf("object name mismatch: %s != %s", lod.Entries[i].ObjectName, lod2.Entries[i].ObjectName)
-					}
-				}
+	rfd2 := &Rfd{}
+	err = rfd2.Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to read: %s", err.Error())
+	}
+	if rfd2.Version != 1 || len(rfd2.Entries) != 2 || *rfd2.Entries[0] != *rfd.Entries[0] {
+		t.Fatalf("round trip mismatch: %+v", rfd2.Entries)
+	}
 
-				err = helper.ByteCompareTest(file.Data(), buf.Bytes())
-				if err != nil {
-					t.Fatalf("%s byteCompare: %s", tt.name, err)
-				}
-			}
-		})
+	buf2 := bytes.NewBuffer(nil)
+	err = rfd2.Write(buf2)
+	if err != nil {
+		t.Fatalf("failed to write: %s", err.Error())
+	}
+	err = helper.ByteCompareTest(buf.Bytes(), buf2.Bytes())
+	if err != nil {
+		t.Fatalf("byteCompare: %s", err)
+	}
+
+	// a file that does not fit the layout is kept as is
+	data := append(buf.Bytes(), 0)
+	err = rfd2.Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to read: %s", err.Error())
+	}
+	if rfd2.Data == "" || len(rfd2.Entries) != 0 {
+		t.Fatalf("expected unparsed data, got %d entries", len(rfd2.Entries))
+	}
+	buf3 := bytes.NewBuffer(nil)
+	err = rfd2.Write(buf3)
+	if err != nil {
+		t.Fatalf("failed to write: %s", err.Error())
+	}
+	err = helper.ByteCompareTest(data, buf3.Bytes())
+	if err != nil {
+		t.Fatalf("byteCompare: %s", err)
 	}
 }
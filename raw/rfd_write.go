@@ -1,10 +1,47 @@
 package raw
 
 import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
 	"io"
+
+	"github.com/xackery/encdec"
 )
 
+// Write writes a RFD file
 func (e *Rfd) Write(w io.Writer) error {
+	if e.Data != "" {
+		data, err := base64.StdEncoding.DecodeString(e.Data)
+		if err != nil {
+			return fmt.Errorf("rfd decode: %w", err)
+		}
+		_, err = w.Write(data)
+		if err != nil {
+			return fmt.Errorf("rfd write: %w", err)
+		}
+		return nil
+	}
+
+	enc := encdec.NewEncoder(w, binary.LittleEndian)
+
+	enc.Uint32(e.Version)
+	enc.Uint32(uint32(len(e.Entries)))
+	for _, entry := range e.Entries {
+		enc.StringZero(entry.Flora)
+		enc.Float32(entry.Position[0])
+		enc.Float32(entry.Position[1])
+		enc.Float32(entry.Position[2])
+		enc.Float32(entry.Radius)
+		enc.Float32(entry.Density)
+		enc.Float32(entry.ScaleMin)
+		enc.Float32(entry.ScaleMax)
+		enc.Uint32(entry.Seed)
+	}
 
+	err := enc.Error()
+	if err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
 	return nil
 }
@@ -1,17 +1,36 @@
 package raw
 
 import (
+	"fmt"
 	"io"
+	"strconv"
+	"strings"
 )
 
+// Tog is a v4 zone toggle file, a group of objects .dat tiles place through TogRefs.
+// Typical usage is like so:
+/*
+*BEGIN_OBJECTGROUP
+	*BEGIN_OBJECT
+		*NAME     	obj_lamp01
+		*POSITION 	0 	0	 	12
+		*ROTATION 	0	 	0	 	90
+		*SCALE    	1
+		*FILE     	MOD     	obj_lamp01
+	*END_OBJECT
+*END_OBJECTGROUP
+*/
 type Tog struct {
-	MetaFileName string
-	Entries      []*TogEntry
+	MetaFileName      string
+	IsCRLF            bool // lines end in \r\n
+	IsTrailingNewline bool // last line ends in a newline
+	Entries           []*TogEntry
+	Lines             []string // source lines after the last object, written as is
 }
 
 // Identity returns the type of the struct
 func (tog *Tog) Identity() string {
-	return "Tog"
+	return "tog"
 }
 
 type TogEntry struct {
@@ -19,10 +38,138 @@ type TogEntry struct {
 	Rotation [3]float32
 	Scale    float32
 	Name     string
-	FileType string
+	FileType string // MOD, MDS or TER
+	FileName string
+	Before   []string // source lines between the previous object and this one, such as *BEGIN_OBJECTGROUP, written as is
+	Lines    []string // source lines of the object, properties are written as is while their values are unchanged
 }
 
 func (tog *Tog) Read(r io.ReadSeeker) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+
+	tog.Entries = []*TogEntry{}
+	tog.Lines = nil
+	if len(data) == 0 {
+		return nil
+	}
+
+	text := string(data)
+	tog.IsCRLF = strings.Contains(text, "\r\n")
+	tog.IsTrailingNewline = strings.HasSuffix(text, "\n")
+	text = strings.TrimSuffix(text, "\n")
+	lineEnd := "\n"
+	if tog.IsCRLF {
+		text = strings.TrimSuffix(text, "\r")
+		lineEnd = "\r\n"
+	}
+
+	isGroup := false
+	var entry *TogEntry
+	before := []string{}
+	for lineNumber, line := range strings.Split(text, lineEnd) {
+		records := strings.Fields(line)
+		key := ""
+		if len(records) > 0 {
+			key = records[0]
+		}
+
+		switch key {
+		case "*BEGIN_OBJECTGROUP":
+			isGroup = true
+		case "*END_OBJECTGROUP":
+			isGroup = false
+		case "*BEGIN_OBJECT":
+			if !isGroup {
+				return fmt.Errorf("line %d: object outside of *BEGIN_OBJECTGROUP", lineNumber+1)
+			}
+			if entry != nil {
+				return fmt.Errorf("line %d: *BEGIN_OBJECT inside *BEGIN_OBJECT", lineNumber+1)
+			}
+			entry = &TogEntry{Before: before, Lines: []string{line}}
+			before = []string{}
+			continue
+		case "*END_OBJECT":
+			if entry == nil {
+				return fmt.Errorf("line %d: *END_OBJECT without *BEGIN_OBJECT", lineNumber+1)
+			}
+			entry.Lines = append(entry.Lines, line)
+			tog.Entries = append(tog.Entries, entry)
+			entry = nil
+			continue
+		}
+
+		if entry == nil {
+			before = append(before, line)
+			continue
+		}
+		// unknown lines are kept in Lines and written back as is
+		_, err = entry.parseProperty(records)
+		if err != nil {
+			return fmt.Errorf("line %d %w", lineNumber+1, err)
+		}
+		entry.Lines = append(entry.Lines, line)
+	}
+	if entry != nil {
+		return fmt.Errorf("*BEGIN_OBJECT %s has no *END_OBJECT", entry.Name)
+	}
+	tog.Lines = before
+	return nil
+}
+
+// parseProperty sets the value of a *KEY value line, returning false if the key is unknown
+func (entry *TogEntry) parseProperty(records []string) (bool, error) {
+	if len(records) == 0 {
+		return false, nil
+	}
+	switch records[0] {
+	case "*NAME":
+		if len(records) < 2 {
+			return true, fmt.Errorf("name: missing value")
+		}
+		entry.Name = records[1]
+	case "*POSITION":
+		err := togParseFloats(entry.Position[:], records[1:])
+		if err != nil {
+			return true, fmt.Errorf("position: %w", err)
+		}
+	case "*ROTATION":
+		err := togParseFloats(entry.Rotation[:], records[1:])
+		if err != nil {
+			return true, fmt.Errorf("rotation: %w", err)
+		}
+	case "*SCALE":
+		scale := []float32{0}
+		err := togParseFloats(scale, records[1:])
+		if err != nil {
+			return true, fmt.Errorf("scale: %w", err)
+		}
+		entry.Scale = scale[0]
+	case "*FILE":
+		if len(records) < 3 {
+			return true, fmt.Errorf("file: expected type and name")
+		}
+		entry.FileType = records[1]
+		entry.FileName = records[2]
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+func togParseFloats(dst []float32, records []string) error {
+	if len(records) != len(dst) {
+		return fmt.Errorf("expected %d values, got %d", len(dst), len(records))
+	}
+	for i, record := range records {
+		val, err := strconv.ParseFloat(record, 32)
+		if err != nil {
+			return fmt.Errorf("%s is not a number", record)
+		}
+		dst[i] = float32(val)
+	}
 	return nil
 }
 
@@ -0,0 +1,82 @@
+package raw
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/xackery/quail/helper"
+)
+
+func TestTogReadWrite(t *testing.T) {
+	tog := &Tog{
+		Entries: []*TogEntry{
+			{Name: "obj_lamp01", Position: [3]float32{10, -20.5, 3}, Rotation: [3]float32{0, 0, 90}, Scale: 1.5, FileType: "MOD", FileName: "obj_lamp01"},
+			{Name: "obj_cart", Position: [3]float32{100, 200, 0}, Scale: 1, FileType: "MOD", FileName: "obj_cart02"},
+		},
+	}
+
+	buf := bytes.NewBuffer(nil)
+	err := tog.Write(buf)
+	if err != nil {
+		t.Fatalf("failed to write: %s", err.Error())
+	}
+
+	tog2 := &Tog{}
+	err = tog2.Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to read: %s\n%s", err.Error(), buf.String())
+	}
+	if len(tog2.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(tog2.Entries))
+	}
+	for i := range tog.Entries {
+		if !tog.Entries[i].isSame(tog2.Entries[i]) {
+			t.Fatalf("entry %d got %+v, want %+v", i, tog2.Entries[i], tog.Entries[i])
+		}
+	}
+
+	err = tog2.Read(bytes.NewReader([]byte("*BEGIN_OBJECTGROUP\n*BEGIN_OBJECT\n*POSITION 1 2\n")))
+	if err == nil {
+		t.Fatalf("expected position error")
+	}
+}
+
+func TestTogSourceLines(t *testing.T) {
+	data := []byte("// farstone lamps\r\n*BEGIN_OBJECTGROUP\r\n\t*BEGIN_OBJECT\r\n\t\t*NAME obj_lamp01\r\n\t\t*POSITION 1 2 3\r\n" +
+		"\t\t*LIGHT lamp_flicker\r\n\t\t*FILE MOD obj_lamp01\r\n\t*END_OBJECT\r\n*END_OBJECTGROUP\r\n")
+	tog := &Tog{}
+	err := tog.Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to read: %s", err.Error())
+	}
+	if len(tog.Entries) != 1 || tog.Entries[0].Position != [3]float32{1, 2, 3} {
+		t.Fatalf("got %+v", tog.Entries)
+	}
+
+	// unknown properties, comments and line endings are written as is
+	buf := bytes.NewBuffer(nil)
+	err = tog.Write(buf)
+	if err != nil {
+		t.Fatalf("failed to write: %s", err.Error())
+	}
+	err = helper.ByteCompareTest(data, buf.Bytes())
+	if err != nil {
+		t.Fatalf("byteCompare: %s", err)
+	}
+
+	tog.Entries[0].Position[0] = 5
+	tog.Entries[0].Scale = 2
+	buf.Reset()
+	err = tog.Write(buf)
+	if err != nil {
+		t.Fatalf("failed to write: %s", err.Error())
+	}
+	tog2 := &Tog{}
+	err = tog2.Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to read: %s", err.Error())
+	}
+	if tog2.Entries[0].Position != [3]float32{5, 2, 3} || tog2.Entries[0].Scale != 2 || len(tog2.Entries[0].Lines) != 7 {
+		t.Fatalf("got %+v\n%s", tog2.Entries[0], buf.String())
+	}
+}
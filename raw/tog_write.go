@@ -3,28 +3,126 @@ package raw
 import (
 	"fmt"
 	"io"
-	"text/template"
+	"strings"
 )
 
+// Write writes source lines as is where they still match, a tog without any is written as a new group
 func (tog *Tog) Write(w io.Writer) error {
-	tmpl, err := template.New("toggle").Parse(togTemplate)
-	if err != nil {
-		return fmt.Errorf("parse togTemplate: %w", err)
+	lineEnd := "\n"
+	if tog.IsCRLF {
+		lineEnd = "\r\n"
+	}
+
+	isSource := len(tog.Lines) > 0
+	for _, entry := range tog.Entries {
+		if len(entry.Before) > 0 || len(entry.Lines) > 0 {
+			isSource = true
+		}
+	}
+
+	lines := []string{}
+	if !isSource {
+		lines = append(lines, "*BEGIN_OBJECTGROUP")
+	}
+	for _, entry := range tog.Entries {
+		lines = append(lines, entry.Before...)
+		lines = append(lines, entry.text()...)
 	}
-	err = tmpl.Execute(w, tog)
+	lines = append(lines, tog.Lines...)
+	if !isSource {
+		lines = append(lines, "*END_OBJECTGROUP")
+	}
+
+	text := strings.Join(lines, lineEnd)
+	if !isSource || tog.IsTrailingNewline {
+		text += lineEnd
+	}
+	_, err := w.Write([]byte(text))
 	if err != nil {
-		return fmt.Errorf("execute: %w", err)
+		return fmt.Errorf("write: %w", err)
 	}
 	return nil
 }
 
-var togTemplate = `*BEGIN_OBJECTGROUP{{range .Entries}}
-	*BEGIN_OBJECT
-		*NAME     	{{.Name}}
-		*POSITION 	{{index .Position 0}} 	{{index .Position 1}}	 	{{index .Position 2}}
-		*ROTATION 	{{index .Rotation 0}}	 	{{index .Rotation 1}}	 	{{index .Rotation 2}}
-		*SCALE    	{{.Scale}}
-		*FILE     	{{.FileType}}     	{{.FileName}}
-	*END_OBJECT{{end}}
-*END_OBJECTGROUP
-`
+// text returns the lines of an object, keeping source lines whose values are unchanged
+// and adding a property the source lines lack before *END_OBJECT once it is set
+func (entry *TogEntry) text() []string {
+	properties := []string{"*NAME", "*POSITION", "*ROTATION", "*SCALE", "*FILE"}
+	if len(entry.Lines) == 0 {
+		lines := []string{"\t*BEGIN_OBJECT"}
+		for _, key := range properties {
+			lines = append(lines, entry.propertyLine(key))
+		}
+		return append(lines, "\t*END_OBJECT")
+	}
+
+	lines := []string{}
+	isWritten := map[string]bool{}
+	for _, line := range entry.Lines {
+		records := strings.Fields(line)
+		if len(records) > 0 && records[0] == "*END_OBJECT" {
+			for _, key := range properties {
+				if !isWritten[key] && !entry.isUnset(key) {
+					lines = append(lines, entry.propertyLine(key))
+				}
+			}
+		}
+
+		source := *entry
+		isKnown, err := source.parseProperty(records)
+		if !isKnown {
+			lines = append(lines, line)
+			continue
+		}
+		isWritten[records[0]] = true
+		if err == nil && source.isSame(entry) {
+			lines = append(lines, line)
+			continue
+		}
+		lines = append(lines, entry.propertyLine(records[0]))
+	}
+	return lines
+}
+
+// propertyLine returns a *KEY value line for the value of key
+func (entry *TogEntry) propertyLine(key string) string {
+	switch key {
+	case "*NAME":
+		return fmt.Sprintf("\t\t*NAME     \t%s", entry.Name)
+	case "*POSITION":
+		return fmt.Sprintf("\t\t*POSITION \t%v \t%v\t \t%v", entry.Position[0], entry.Position[1], entry.Position[2])
+	case "*ROTATION":
+		return fmt.Sprintf("\t\t*ROTATION \t%v\t \t%v\t \t%v", entry.Rotation[0], entry.Rotation[1], entry.Rotation[2])
+	case "*SCALE":
+		return fmt.Sprintf("\t\t*SCALE    \t%v", entry.Scale)
+	case "*FILE":
+		return fmt.Sprintf("\t\t*FILE     \t%s     \t%s", entry.FileType, entry.FileName)
+	}
+	return ""
+}
+
+// isUnset returns true if the value of key is what reading an object without it gives
+func (entry *TogEntry) isUnset(key string) bool {
+	switch key {
+	case "*NAME":
+		return entry.Name == ""
+	case "*POSITION":
+		return entry.Position == [3]float32{}
+	case "*ROTATION":
+		return entry.Rotation == [3]float32{}
+	case "*SCALE":
+		return entry.Scale == 0
+	case "*FILE":
+		return entry.FileType == "" && entry.FileName == ""
+	}
+	return true
+}
+
+func (entry *TogEntry) isSame(other *TogEntry) bool {
+	return entry.Name == other.Name &&
+		entry.Position == other.Position &&
+		entry.Rotation == other.Rotation &&
+		entry.Scale == other.Scale &&
+		entry.FileType == other.FileType &&
+		entry.FileName == other.FileName
+}
@@ -223,6 +223,9 @@ func (a *AsciiReadToken) readDefinitions() error {
 		&EqgZonDef{},
 		&EqgLit{},
 		&EqgLodDef{},
+		&EqgEcoDef{},
+		&EqgRfdDef{},
+		&EqgTogDef{},
 		&EqgLayDef{},
 		&ParticleCloudDef{},
 		&PointLight{},
@@ -606,6 +609,27 @@ func (a *AsciiReadToken) readDefinitions() error {
 				frag.Tag = args[1]
 				a.wce.LodDefs = append(a.wce.LodDefs, frag)
 				definitions[i] = &EqgLodDef{}
+			case *EqgEcoDef:
+				if len(args) == 1 {
+					return fmt.Errorf("definition %s has no arguments", defName)
+				}
+				frag.Tag = args[1]
+				a.wce.EcoDefs = append(a.wce.EcoDefs, frag)
+				definitions[i] = &EqgEcoDef{}
+			case *EqgRfdDef:
+				if len(args) == 1 {
+					return fmt.Errorf("definition %s has no arguments", defName)
+				}
+				frag.Tag = args[1]
+				a.wce.RfdDefs = append(a.wce.RfdDefs, frag)
+				definitions[i] = &EqgRfdDef{}
+			case *EqgTogDef:
+				if len(args) == 1 {
+					return fmt.Errorf("definition %s has no arguments", defName)
+				}
+				frag.Tag = args[1]
+				a.wce.TogDefs = append(a.wce.TogDefs, frag)
+				definitions[i] = &EqgTogDef{}
 			case *EqgLayDef:
 				if len(args) == 1 {
 					return fmt.Errorf("definition %s has no arguments", defName)
@@ -26,6 +26,9 @@ var (
 		&wce.EqgMdsDef{},
 		&wce.EqgModDef{},
 		&wce.EqgLodDef{},
+		&wce.EqgEcoDef{},
+		&wce.EqgRfdDef{},
+		&wce.EqgTogDef{},
 		&wce.EqgParticlePointDef{},
 		&wce.EqgParticleRenderDef{},
 		&wce.EqgTerDef{},
@@ -18,6 +18,8 @@ type Wce struct {
 	lastReadFolder         string // used during wce parsing to remember context
 	isObj                  bool   // true when a _obj suffix is found in path
 	isChr                  bool   // true when a _chr suffix is found in path
+	isWldConverted         bool   // true once wld characters were converted to eqg models
+	isEqgCopy              bool   // true on the copy ConvertWldToEQG returns, so it is not converted twice
 	maxMaterialHeads       map[string]int
 	maxMaterialTextures    map[string]int
 	indexedTags            map[string]int32 // used when parsing to keep track of indexes
@@ -63,6 +65,9 @@ type Wce struct {
 	PtsDefs                []*EqgParticlePointDef
 	PrtDefs                []*EqgParticleRenderDef
 	LodDefs                []*EqgLodDef
+	EcoDefs                []*EqgEcoDef
+	RfdDefs                []*EqgRfdDef
+	TogDefs                []*EqgTogDef
 	ZonDefs                []*EqgZonDef
 	Lits                   []*EqgLit
 	EffectOlds             []*EffectOld
@@ -399,6 +404,9 @@ func (wce *Wce) reset() {
 	wce.PtsDefs = []*EqgParticlePointDef{}
 	wce.PrtDefs = []*EqgParticleRenderDef{}
 	wce.LodDefs = []*EqgLodDef{}
+	wce.EcoDefs = []*EqgEcoDef{}
+	wce.RfdDefs = []*EqgRfdDef{}
+	wce.TogDefs = []*EqgTogDef{}
 	wce.ZonDefs = []*EqgZonDef{}
 	wce.Lits = []*EqgLit{}
 	wce.EffectOlds = []*EffectOld{}
@@ -0,0 +1,241 @@
+package wce
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Animation sets an audit checks models against
+const (
+	AnimSetPlayable = "playable"
+	AnimSetNPC      = "npc"
+)
+
+// animAuditWldCodes are the animation codes s3d character models are expected to have
+var animAuditWldCodes = map[string][]string{
+	AnimSetPlayable: {
+		"C01", "C02", "C03", "C04", "C05", "C06", "C07", "C08", "C09", "C10", "C11",
+		"D01", "D02", "D03", "D04", "D05",
+		"L01", "L02", "L03", "L04", "L05", "L06", "L07", "L08", "L09",
+		"O01",
+		"P01", "P02", "P03", "P04", "P05", "P06", "P07", "P08",
+		"S01", "S02", "S03", "S04", "S05", "S06", "S07", "S08", "S09", "S10", "S11", "S12", "S13", "S14",
+		"S15", "S16", "S17", "S18", "S19", "S20", "S21", "S22", "S23", "S24", "S25", "S26", "S27", "S28",
+		"T02", "T03", "T04", "T05", "T06", "T07", "T08", "T09",
+	},
+	AnimSetNPC: {"C01", "C02", "C03", "D01", "D02", "D05", "L01", "L02", "O01", "P01"},
+}
+
+// animAuditEqgCodes are the animation names eqg character models are expected to have, from the
+// commonly used list of notes/anim_list.md
+var animAuditEqgCodes = map[string][]string{
+	AnimSetPlayable: {
+		"crch", "crmp", "dest", "flch", "gcst", "idle", "jmpa", "jmpu", "jpmu",
+		"msht", "nrun", "slpr", "stnd", "stun", "swim", "turn", "walk",
+	},
+	AnimSetNPC: {"dest", "idle", "nrun", "stnd", "stun", "walk"},
+}
+
+// AnimAudit reports the animations of a character model against an animation set
+type AnimAudit struct {
+	Model      string
+	Bones      int
+	Present    []string
+	Missing    []string
+	Mismatched []*AnimAuditMismatch
+}
+
+// AnimAuditMismatch is an animation whose bones or frames do not line up with its model
+type AnimAuditMismatch struct {
+	Code   string
+	Reason string
+}
+
+// animAuditTrack is one bone of a found animation
+type animAuditTrack struct {
+	bone   string
+	frames int
+}
+
+// AnimAudit checks every character model against the animation set named set, either
+// AnimSetPlayable or AnimSetNPC
+func (wce *Wce) AnimAudit(set string) ([]*AnimAudit, error) {
+	wldCodes, ok := animAuditWldCodes[set]
+	if !ok {
+		return nil, fmt.Errorf("unknown animation set %s, wanted %s or %s", set, AnimSetPlayable, AnimSetNPC)
+	}
+	eqgCodes := animAuditEqgCodes[set]
+
+	audits := []*AnimAudit{}
+	for _, actor := range wce.ActorDefs {
+		var sprite *HierarchicalSpriteDef
+		for _, action := range actor.Actions {
+			for _, lod := range action.LevelOfDetails {
+				hs, ok := wce.ByTag(lod.SpriteTag).(*HierarchicalSpriteDef)
+				if ok && sprite == nil {
+					sprite = hs
+				}
+			}
+		}
+		if sprite == nil {
+			continue
+		}
+		model := strings.TrimSuffix(baseTag(actor.Tag), "_ACTORDEF")
+		audit, err := wce.animAuditWld(model, sprite, wldCodes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", actor.Tag, err)
+		}
+		audits = append(audits, audit)
+	}
+
+	for _, mds := range wce.MdsDefs {
+		audits = append(audits, wce.animAuditEqg(mds, eqgCodes, wldCodes))
+	}
+
+	sort.Slice(audits, func(i, j int) bool {
+		return audits[i].Model < audits[j].Model
+	})
+	return audits, nil
+}
+
+// animAuditWld finds animation tracks of a hierarchical sprite, named by animation code and dag track
+func (wce *Wce) animAuditWld(model string, sprite *HierarchicalSpriteDef, codes []string) (*AnimAudit, error) {
+	bones, tracks, err := wce.wldAnimBones(model, sprite)
+	if err != nil {
+		return nil, err
+	}
+	dagTracks := map[string]string{}
+	for _, bone := range bones {
+		dagTracks[baseTag(tracks[strings.ToUpper(bone.name)])] = bone.name
+	}
+
+	tracksByCode := map[string][]animAuditTrack{}
+	for _, instance := range wce.TrackInstances {
+		tag := baseTag(instance.Tag)
+		if len(tag) <= 3 || !regexAniPrefix.MatchString(tag) {
+			continue
+		}
+		bone, ok := dagTracks[tag[3:]]
+		if !ok {
+			continue
+		}
+		_, track, err := wce.trackDefByInstance(instance.Tag)
+		if err != nil {
+			return nil, err
+		}
+		tracksByCode[tag[:3]] = append(tracksByCode[tag[:3]], animAuditTrack{bone: bone, frames: wldTrackFrameCount(track)})
+	}
+	found := map[string][][]animAuditTrack{}
+	for code, tracks := range tracksByCode {
+		found[code] = [][]animAuditTrack{tracks}
+	}
+	return animAuditReport(model, len(bones), codes, found, nil), nil
+}
+
+// animAuditEqg finds the .ani files of a skinned model. Models converted from s3d keep the wld
+// animation codes, like hum_c01, and are checked against wldCodes instead
+func (wce *Wce) animAuditEqg(mds *EqgMdsDef, eqgCodes []string, wldCodes []string) *AnimAudit {
+	model := strings.ToLower(mds.Tag)
+	boneNames := map[string]bool{}
+	for _, bone := range mds.Bones {
+		boneNames[strings.ToLower(bone.Name)] = true
+	}
+
+	found := map[string][][]animAuditTrack{}
+	unknown := map[string][]string{}
+	for _, ani := range wce.AniDefs {
+		code, ok := eqgAniCode(ani.Tag, model)
+		if !ok {
+			continue
+		}
+		if len(code) == 3 && regexAniPrefix.MatchString(strings.ToUpper(code)) {
+			code = strings.ToUpper(code)
+		}
+		// variants like stnd_ba_1 and stnd_ba_2 are each checked under the same code
+		tracks := []animAuditTrack{}
+		for _, bone := range ani.Bones {
+			if !boneNames[strings.ToLower(bone.Name)] {
+				unknown[code] = append(unknown[code], bone.Name)
+				continue
+			}
+			tracks = append(tracks, animAuditTrack{bone: strings.ToLower(bone.Name), frames: len(bone.Frames)})
+		}
+		found[code] = append(found[code], tracks)
+	}
+
+	// a model is checked against the wld codes when it has more of them than eqg names
+	wldCount, eqgCount := 0, 0
+	for _, code := range wldCodes {
+		if _, ok := found[code]; ok {
+			wldCount++
+		}
+	}
+	for _, code := range eqgCodes {
+		if _, ok := found[code]; ok {
+			eqgCount++
+		}
+	}
+	codes := eqgCodes
+	if wldCount > eqgCount {
+		codes = wldCodes
+	}
+	return animAuditReport(mds.Tag, len(mds.Bones), codes, found, unknown)
+}
+
+// eqgAniCode returns the animation name of an .ani of model, named like stnd_ba_1_exo or exo_stnd
+func eqgAniCode(tag string, model string) (string, bool) {
+	parts := strings.Split(strings.ToLower(tag), "_")
+	if len(parts) < 2 {
+		return "", false
+	}
+	model = strings.ToLower(model)
+	switch {
+	case parts[len(parts)-1] == model:
+		return parts[0], true
+	case parts[0] == model:
+		return parts[1], true
+	}
+	return "", false
+}
+
+// animAuditReport sorts the codes of a model into present, missing and mismatched. Each code
+// has one or more variants, each a list of animated bones
+func animAuditReport(model string, boneCount int, codes []string, found map[string][][]animAuditTrack, unknown map[string][]string) *AnimAudit {
+	audit := &AnimAudit{Model: model, Bones: boneCount}
+	for _, code := range codes {
+		variants, ok := found[code]
+		if !ok {
+			audit.Missing = append(audit.Missing, code)
+			continue
+		}
+
+		reasons := []string{}
+		for _, tracks := range variants {
+			bones := map[string]bool{}
+			minFrames, maxFrames := 0, 0
+			for i, track := range tracks {
+				bones[track.bone] = true
+				if i == 0 || track.frames < minFrames {
+					minFrames = track.frames
+				}
+				maxFrames = max(maxFrames, track.frames)
+			}
+			if len(bones) != boneCount {
+				reasons = appendUnique(reasons, fmt.Sprintf("animates %d of %d bones", len(bones), boneCount))
+			}
+			if minFrames != maxFrames {
+				reasons = appendUnique(reasons, fmt.Sprintf("bones have %d to %d frames", minFrames, maxFrames))
+			}
+		}
+		if len(unknown[code]) > 0 {
+			reasons = append(reasons, fmt.Sprintf("has bones the model lacks: %s", strings.Join(unknown[code], ", ")))
+		}
+		if len(reasons) > 0 {
+			audit.Mismatched = append(audit.Mismatched, &AnimAuditMismatch{Code: code, Reason: strings.Join(reasons, ", ")})
+			continue
+		}
+		audit.Present = append(audit.Present, code)
+	}
+	return audit
+}
@@ -0,0 +1,80 @@
+package wce
+
+import (
+	"testing"
+)
+
+func TestAnimAudit(t *testing.T) {
+	chr := New("global_chr.wld")
+	track := func(tag string, frames int) {
+		def := &TrackDef{Tag: tag + "DEF"}
+		for i := 0; i < frames; i++ {
+			def.Frames = append(def.Frames, &Frame{RotScale: 16384})
+		}
+		chr.TrackDefs = append(chr.TrackDefs, def)
+		chr.TrackInstances = append(chr.TrackInstances, &TrackInstance{Tag: tag, Def: tag + "DEF"})
+	}
+	track("ELF_TRACK", 1)
+	track("ELFHE_TRACK", 1)
+	track("C01ELF_TRACK", 5)
+	track("C01ELFHE_TRACK", 5)
+	track("L01ELF_TRACK", 8)
+	track("L01ELFHE_TRACK", 6)
+	track("L02ELF_TRACK", 4)
+	chr.HierarchicalSpriteDefs = append(chr.HierarchicalSpriteDefs, &HierarchicalSpriteDef{
+		Tag: "ELF_HS_DEF",
+		Dags: []Dag{
+			{Tag: "ELF_DAG", Track: "ELF_TRACK", SubDags: []uint32{1}},
+			{Tag: "ELFHE_DAG", Track: "ELFHE_TRACK"},
+		},
+	})
+	chr.ActorDefs = append(chr.ActorDefs, &ActorDef{
+		Tag:     "ELF_ACTORDEF",
+		Actions: []ActorAction{{LevelOfDetails: []ActorLevelOfDetail{{SpriteTag: "ELF_HS_DEF"}}}},
+	})
+
+	_, err := chr.AnimAudit("everything")
+	if err == nil {
+		t.Fatalf("unknown set was accepted")
+	}
+	audits, err := chr.AnimAudit(AnimSetNPC)
+	if err != nil {
+		t.Fatalf("audit: %v", err)
+	}
+	if len(audits) != 1 || audits[0].Model != "ELF" || audits[0].Bones != 2 {
+		t.Fatalf("got %d audits, want ELF with 2 bones", len(audits))
+	}
+	audit := audits[0]
+	if len(audit.Present) != 1 || audit.Present[0] != "C01" {
+		t.Fatalf("present %v, want [C01]", audit.Present)
+	}
+	if len(audit.Mismatched) != 2 || audit.Mismatched[0].Code != "L01" || audit.Mismatched[1].Reason != "animates 1 of 2 bones" {
+		t.Fatalf("mismatched not reported")
+	}
+	if len(audit.Missing) != 7 {
+		t.Fatalf("missing %v, want 7 codes", audit.Missing)
+	}
+
+	eqg := New("exo.eqg")
+	eqg.MdsDefs = append(eqg.MdsDefs, &EqgMdsDef{Tag: "exo", Bones: []*MdsBone{{Name: "root"}, {Name: "head"}}})
+	frames := []*AniBoneFrame{{}, {}}
+	eqg.AniDefs = append(eqg.AniDefs,
+		&EqgAniDef{Tag: "stnd_ba_1_exo", Bones: []*AniBone{{Name: "root", Frames: frames}, {Name: "head", Frames: frames}}},
+		&EqgAniDef{Tag: "walk_ba_1_exo", Bones: []*AniBone{{Name: "root", Frames: frames}, {Name: "tail", Frames: frames}}},
+		&EqgAniDef{Tag: "idle_ba_1_hum", Bones: []*AniBone{{Name: "root", Frames: frames}}},
+	)
+	audits, err = eqg.AnimAudit(AnimSetNPC)
+	if err != nil {
+		t.Fatalf("audit eqg: %v", err)
+	}
+	audit = audits[0]
+	if len(audit.Present) != 1 || audit.Present[0] != "stnd" {
+		t.Fatalf("eqg present %v, want [stnd]", audit.Present)
+	}
+	if len(audit.Mismatched) != 1 || audit.Mismatched[0].Reason != "animates 1 of 2 bones, has bones the model lacks: tail" {
+		t.Fatalf("eqg walk mismatch not reported")
+	}
+	if len(audit.Missing) != 4 {
+		t.Fatalf("eqg missing %v, want 4 codes", audit.Missing)
+	}
+}
@@ -0,0 +1,339 @@
+package wce
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// AnimCopyOption configures AnimCopy
+type AnimCopyOption struct {
+	From       string            // model animations are copied from, like ELF
+	To         string            // model animations are copied to, like XEL
+	Animations []string          // animation codes to copy, like C01, empty copies every animation
+	BoneMap    map[string]string // from bone to bone, bones not listed keep their name
+}
+
+// animCopyBone is a bone of a model and how far its rest pose sits from its parent
+type animCopyBone struct {
+	name   string
+	length float32
+}
+
+// AnimCopy copies animations of the opt.From model in src onto the opt.To model in wce, which may be src.
+// Bones are renamed through opt.BoneMap and animation translations are rescaled by the rest length of the
+// target bone over the source bone. Bones the target does not have are skipped, and animations the target
+// already has are replaced. The codes of the copied animations are returned
+func (wce *Wce) AnimCopy(src *Wce, opt *AnimCopyOption) ([]string, error) {
+	if opt.From == "" || opt.To == "" {
+		return nil, fmt.Errorf("from and to models are required")
+	}
+	for _, mds := range wce.MdsDefs {
+		if strings.EqualFold(mds.Tag, opt.To) {
+			return wce.animCopyEqg(src, mds, opt)
+		}
+	}
+	sprite, ok := wce.ByTag(strings.ToUpper(opt.To) + "_HS_DEF").(*HierarchicalSpriteDef)
+	if ok {
+		return wce.animCopyWld(src, sprite, opt)
+	}
+	return nil, fmt.Errorf("model %s not found", opt.To)
+}
+
+// animCopyCodes returns a set of the upper case animation codes of opt, or nil for every animation
+func animCopyCodes(opt *AnimCopyOption) map[string]bool {
+	if len(opt.Animations) == 0 {
+		return nil
+	}
+	codes := map[string]bool{}
+	for _, code := range opt.Animations {
+		codes[strings.ToUpper(strings.TrimSpace(code))] = true
+	}
+	return codes
+}
+
+// animCopyBoneName returns the target name of a source bone
+func animCopyBoneName(opt *AnimCopyOption, name string) string {
+	for from, to := range opt.BoneMap {
+		if strings.EqualFold(from, name) {
+			return to
+		}
+	}
+	return name
+}
+
+// animCopyScales returns the translation scale of each source bone copied to a target bone. Bones
+// with no rest length, like the root, use the scale of the whole skeleton
+func animCopyScales(opt *AnimCopyOption, from []animCopyBone, to []animCopyBone) map[string]float32 {
+	targets := map[string]float32{}
+	for _, bone := range to {
+		targets[strings.ToUpper(bone.name)] = bone.length
+	}
+
+	scales := map[string]float32{}
+	fromTotal, toTotal := float32(0), float32(0)
+	for _, bone := range from {
+		length, ok := targets[strings.ToUpper(animCopyBoneName(opt, bone.name))]
+		if !ok {
+			continue
+		}
+		fromTotal += bone.length
+		toTotal += length
+		if bone.length > 0.0001 {
+			scales[strings.ToUpper(bone.name)] = length / bone.length
+		}
+	}
+	skeleton := float32(1)
+	if fromTotal > 0.0001 {
+		skeleton = toTotal / fromTotal
+	}
+	for _, bone := range from {
+		if _, ok := scales[strings.ToUpper(bone.name)]; !ok {
+			scales[strings.ToUpper(bone.name)] = skeleton
+		}
+	}
+	return scales
+}
+
+// animCopyEqg copies EQGANIDEFs of the skinned model opt.From onto dst
+func (wce *Wce) animCopyEqg(src *Wce, dst *EqgMdsDef, opt *AnimCopyOption) ([]string, error) {
+	var from *EqgMdsDef
+	for _, mds := range src.MdsDefs {
+		if strings.EqualFold(mds.Tag, opt.From) {
+			from = mds
+			break
+		}
+	}
+	if from == nil {
+		return nil, fmt.Errorf("skinned model %s not found", opt.From)
+	}
+
+	eqgBones := func(mds *EqgMdsDef) []animCopyBone {
+		bones := []animCopyBone{}
+		for _, bone := range mds.Bones {
+			bones = append(bones, animCopyBone{name: bone.Name, length: vecLength(bone.Pivot)})
+		}
+		return bones
+	}
+	scales := animCopyScales(opt, eqgBones(from), eqgBones(dst))
+	targets := map[string]string{}
+	for _, bone := range dst.Bones {
+		targets[strings.ToUpper(bone.Name)] = bone.Name
+	}
+
+	codes := animCopyCodes(opt)
+	prefix := strings.ToLower(opt.From) + "_"
+	folder := strings.TrimSuffix(strings.ToLower(wce.FileName), ".eqg")
+	copied := []string{}
+	for _, ani := range src.AniDefs {
+		if !strings.HasPrefix(strings.ToLower(ani.Tag), prefix) {
+			continue
+		}
+		code := strings.ToUpper(ani.Tag[len(prefix):])
+		if codes != nil && !codes[code] {
+			continue
+		}
+
+		anim := &EqgAniDef{
+			folders: []string{folder + "/" + folder + "_ani"},
+			Tag:     strings.ToLower(dst.Tag) + "_" + strings.ToLower(code),
+			Version: ani.Version,
+			Strict:  ani.Strict,
+		}
+		for _, bone := range ani.Bones {
+			name, ok := targets[strings.ToUpper(animCopyBoneName(opt, bone.Name))]
+			if !ok {
+				continue
+			}
+			scale := scales[strings.ToUpper(bone.Name)]
+			aniBone := &AniBone{Name: name}
+			for _, frame := range bone.Frames {
+				aniFrame := *frame
+				aniFrame.Translation = vecScale(frame.Translation, scale)
+				aniBone.Frames = append(aniBone.Frames, &aniFrame)
+			}
+			anim.Bones = append(anim.Bones, aniBone)
+		}
+		if len(anim.Bones) == 0 {
+			continue
+		}
+
+		isReplaced := false
+		for i, existing := range wce.AniDefs {
+			if strings.EqualFold(existing.Tag, anim.Tag) {
+				anim.folders = existing.folders
+				wce.AniDefs[i] = anim
+				isReplaced = true
+				break
+			}
+		}
+		if !isReplaced {
+			wce.AniDefs = append(wce.AniDefs, anim)
+		}
+		copied = append(copied, code)
+	}
+	sort.Strings(copied)
+	return copied, nil
+}
+
+// wldAnimBones returns the bones of a hierarchical sprite, named by their dag track without the model prefix,
+// like HE for ELFHE_TRACK, with the root named by an empty string
+func (wce *Wce) wldAnimBones(model string, sprite *HierarchicalSpriteDef) ([]animCopyBone, map[string]string, error) {
+	bones := []animCopyBone{}
+	tracks := map[string]string{}
+	for _, dag := range sprite.Dags {
+		if dag.Track == "" {
+			continue
+		}
+		_, track, err := wce.trackDefByInstance(dag.Track)
+		if err != nil {
+			return nil, nil, fmt.Errorf("dag %s: %w", dag.Tag, err)
+		}
+		translation, _ := wldTrackFrame(track, 0)
+		name := strings.TrimPrefix(strings.TrimSuffix(baseTag(dag.Track), "_TRACK"), model)
+		bones = append(bones, animCopyBone{name: name, length: vecLength(translation)})
+		tracks[strings.ToUpper(name)] = dag.Track
+	}
+	return bones, tracks, nil
+}
+
+// animCopyWld copies animation TRACKINSTANCEs of the hierarchical sprite opt.From onto dst. Animation
+// tracks are the dag track prefixed by the animation code, like C01ELFHE_TRACK for ELFHE_TRACK
+func (wce *Wce) animCopyWld(src *Wce, dst *HierarchicalSpriteDef, opt *AnimCopyOption) ([]string, error) {
+	fromModel := strings.ToUpper(opt.From)
+	toModel := strings.ToUpper(opt.To)
+	from, ok := src.ByTag(fromModel + "_HS_DEF").(*HierarchicalSpriteDef)
+	if !ok {
+		return nil, fmt.Errorf("hierarchical sprite %s_HS_DEF not found", fromModel)
+	}
+
+	fromBones, fromTracks, err := src.wldAnimBones(fromModel, from)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", from.Tag, err)
+	}
+	toBones, toTracks, err := wce.wldAnimBones(toModel, dst)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", dst.Tag, err)
+	}
+	scales := animCopyScales(opt, fromBones, toBones)
+
+	codes := animCopyCodes(opt)
+	copied := map[string]bool{}
+	for _, bone := range fromBones {
+		restTag, ok := toTracks[strings.ToUpper(animCopyBoneName(opt, bone.name))]
+		if !ok {
+			continue
+		}
+		rest, _, err := wce.trackDefByInstance(restTag)
+		if err != nil {
+			return nil, err
+		}
+		sourceTag := fromTracks[strings.ToUpper(bone.name)]
+		for _, instance := range src.TrackInstances {
+			tag := baseTag(instance.Tag)
+			if len(tag) <= 3 || tag[3:] != baseTag(sourceTag) || !regexAniPrefix.MatchString(tag) {
+				continue
+			}
+			code := tag[:3]
+			if codes != nil && !codes[code] {
+				continue
+			}
+			_, track, err := src.trackDefByInstance(instance.Tag)
+			if err != nil {
+				return nil, err
+			}
+
+			scaled := wldScaledTrack(track, scales[strings.ToUpper(bone.name)])
+			scaled.folders = rest.folders
+			scaled.Tag = code + baseTag(restTag) + "DEF"
+			wce.animCopyWldTrack(scaled, &TrackInstance{
+				folders:     rest.folders,
+				Tag:         code + baseTag(restTag),
+				Def:         scaled.Tag,
+				Interpolate: instance.Interpolate,
+				Reverse:     instance.Reverse,
+				Sleep:       instance.Sleep,
+			})
+			copied[code] = true
+		}
+	}
+
+	codeList := make([]string, 0, len(copied))
+	for code := range copied {
+		codeList = append(codeList, code)
+	}
+	sort.Strings(codeList)
+	return codeList, nil
+}
+
+// animCopyWldTrack adds a track definition and instance, replacing ones with the same tags
+func (wce *Wce) animCopyWldTrack(track *TrackDef, instance *TrackInstance) {
+	isReplaced := false
+	for i, existing := range wce.TrackDefs {
+		if existing.Tag == track.Tag {
+			track.folders = existing.folders
+			wce.TrackDefs[i] = track
+			isReplaced = true
+			break
+		}
+	}
+	if !isReplaced {
+		wce.TrackDefs = append(wce.TrackDefs, track)
+	}
+
+	for i, existing := range wce.TrackInstances {
+		if existing.Tag == instance.Tag {
+			instance.folders = existing.folders
+			wce.TrackInstances[i] = instance
+			return
+		}
+	}
+	wce.TrackInstances = append(wce.TrackInstances, instance)
+}
+
+// wldScaledTrack returns a copy of track with its translations scaled
+func wldScaledTrack(track *TrackDef, scale float32) *TrackDef {
+	scaled := &TrackDef{animation: track.animation}
+	for _, frame := range track.Frames {
+		f := *frame
+		f.XYZ, f.XYZScale = wldScaledShift(frame.XYZ, frame.XYZScale, scale)
+		scaled.Frames = append(scaled.Frames, &f)
+	}
+	for _, frame := range track.LegacyFrames {
+		f := *frame
+		f.XYZ, f.XYZScale = wldScaledShift(frame.XYZ, frame.XYZScale, scale)
+		scaled.LegacyFrames = append(scaled.LegacyFrames, &f)
+	}
+	return scaled
+}
+
+// wldScaledShift scales a fixed point track translation
+func wldScaledShift(xyz [3]int16, denominator int16, scale float32) ([3]int16, int16) {
+	if denominator == 0 {
+		return xyz, denominator
+	}
+	translation := [3]float32{float32(xyz[0]), float32(xyz[1]), float32(xyz[2])}
+	return wldShiftEncode(vecScale(translation, scale/float32(denominator)), denominator)
+}
+
+// wldShiftEncode returns a translation as int16 numerators of denominator, halving the denominator
+// until the numerators fit
+func wldShiftEncode(translation [3]float32, denominator int16) ([3]int16, int16) {
+	for {
+		fits := true
+		xyz := [3]int16{}
+		for i := 0; i < 3; i++ {
+			v := math.Round(float64(translation[i]) * float64(denominator))
+			if denominator > 1 && (v > math.MaxInt16 || v < math.MinInt16) {
+				fits = false
+				break
+			}
+			xyz[i] = int16(math.Max(math.MinInt16, math.Min(v, math.MaxInt16)))
+		}
+		if fits {
+			return xyz, denominator
+		}
+		denominator /= 2
+	}
+}
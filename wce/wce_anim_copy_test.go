@@ -0,0 +1,97 @@
+package wce
+
+import (
+	"testing"
+)
+
+func TestAnimCopy(t *testing.T) {
+	chr := New("global_chr.wld")
+	track := func(tag string, frames ...*Frame) {
+		chr.TrackDefs = append(chr.TrackDefs, &TrackDef{Tag: tag + "DEF", Frames: frames})
+		instance := &TrackInstance{Tag: tag, Def: tag + "DEF"}
+		instance.Sleep.Valid = true
+		instance.Sleep.Uint32 = 80
+		chr.TrackInstances = append(chr.TrackInstances, instance)
+	}
+	raised := func(z int16) *Frame {
+		return &Frame{XYZScale: 256, XYZ: [3]int16{0, 0, z * 256}, RotScale: 16384}
+	}
+	model := func(name string, bone string, height int16) {
+		track(name+"_TRACK", raised(0))
+		track(name+bone+"_TRACK", raised(height))
+		chr.HierarchicalSpriteDefs = append(chr.HierarchicalSpriteDefs, &HierarchicalSpriteDef{
+			Tag: name + "_HS_DEF",
+			Dags: []Dag{
+				{Tag: name + "_DAG", Track: name + "_TRACK", SubDags: []uint32{1}},
+				{Tag: name + bone + "_DAG", Track: name + bone + "_TRACK"},
+			},
+		})
+	}
+	model("ELF", "HE", 2)
+	model("XEL", "HEAD", 4)
+	track("C01ELF_TRACK", raised(1), raised(1))
+	track("C01ELFHE_TRACK", raised(2), raised(3))
+	track("L01ELFHE_TRACK", raised(2))
+
+	opt := &AnimCopyOption{From: "ELF", To: "XEL", Animations: []string{"C01"}, BoneMap: map[string]string{"HE": "HEAD"}}
+	copied, err := chr.AnimCopy(chr, opt)
+	if err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	if len(copied) != 1 || copied[0] != "C01" {
+		t.Fatalf("copied %v, want [C01]", copied)
+	}
+	if chr.ByTag("L01XELHEAD_TRACK") != nil {
+		t.Fatalf("copied an animation that was not asked for")
+	}
+	_, head, err := chr.trackDefByInstance("C01XELHEAD_TRACK")
+	if err != nil {
+		t.Fatalf("head: %v", err)
+	}
+	// the target head sits twice as far from its parent, so it moves twice as far
+	translation, _ := wldTrackFrame(head, 1)
+	if translation != [3]float32{0, 0, 6} {
+		t.Fatalf("head frame 1 at %v, want [0 0 6]", translation)
+	}
+	// the root has no length, so it scales by the whole skeleton
+	_, root, err := chr.trackDefByInstance("C01XEL_TRACK")
+	if err != nil {
+		t.Fatalf("root: %v", err)
+	}
+	translation, _ = wldTrackFrame(root, 0)
+	if translation != [3]float32{0, 0, 2} {
+		t.Fatalf("root frame 0 at %v, want [0 0 2]", translation)
+	}
+
+	trackCount := len(chr.TrackInstances)
+	_, err = chr.AnimCopy(chr, opt)
+	if err != nil {
+		t.Fatalf("copy again: %v", err)
+	}
+	if len(chr.TrackInstances) != trackCount {
+		t.Fatalf("copying again added tracks instead of replacing them")
+	}
+
+	eqg := New("dra.eqg")
+	eqg.MdsDefs = append(eqg.MdsDefs,
+		&EqgMdsDef{Tag: "dra", Bones: []*MdsBone{{Name: "root"}, {Name: "neck", Pivot: [3]float32{0, 0, 1}}}},
+		&EqgMdsDef{Tag: "drb", Bones: []*MdsBone{{Name: "root"}, {Name: "neck", Pivot: [3]float32{0, 0, 3}}}},
+	)
+	eqg.AniDefs = append(eqg.AniDefs, &EqgAniDef{Tag: "dra_c01", Version: 2, Bones: []*AniBone{
+		{Name: "neck", Frames: []*AniBoneFrame{{Milliseconds: 0, Translation: [3]float32{0, 0, 1}, Rotation: [4]float32{0, 0, 0, 1}}}},
+		{Name: "tail", Frames: []*AniBoneFrame{{Milliseconds: 0}}},
+	}})
+	copied, err = eqg.AnimCopy(eqg, &AnimCopyOption{From: "dra", To: "drb"})
+	if err != nil {
+		t.Fatalf("copy eqg: %v", err)
+	}
+	if len(copied) != 1 || len(eqg.AniDefs) != 2 || eqg.AniDefs[1].Tag != "drb_c01" {
+		t.Fatalf("eqg animation not copied")
+	}
+	if len(eqg.AniDefs[1].Bones) != 1 || eqg.AniDefs[1].Bones[0].Frames[0].Translation != [3]float32{0, 0, 3} {
+		t.Fatalf("eqg neck not rescaled, or missing tail bone not skipped")
+	}
+	if eqg.AniDefs[0].Bones[0].Frames[0].Translation != [3]float32{0, 0, 1} {
+		t.Fatalf("source animation was changed")
+	}
+}
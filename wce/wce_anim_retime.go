@@ -0,0 +1,327 @@
+package wce
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// AnimTimingOption configures how an animation is retimed. Times are in milliseconds from the
+// first frame, where frame n of a s3d track plays at n times its sleep
+type AnimTimingOption struct {
+	TrimStart    uint32  // drop frames before this time
+	TrimEnd      uint32  // drop frames after this time, 0 keeps to the end
+	IsReverse    bool    // play ReverseStart to ReverseEnd backwards, after trimming
+	ReverseStart uint32  // start of the reversed range
+	ReverseEnd   uint32  // end of the reversed range, 0 reverses to the end
+	Speed        float32 // playback speed, 2 plays twice as fast, 0 keeps the speed
+	Interval     uint32  // milliseconds between resampled frames, 0 keeps the frame times of eqg animations
+}
+
+// animKey is a keyframe of one bone
+type animKey struct {
+	ms          float64
+	translation [3]float32
+	rotation    [4]float32 // x, y, z, w
+	scale       [3]float32
+}
+
+// quatSlerp returns the rotation t of the way from a to b along the shortest arc
+func quatSlerp(a [4]float32, b [4]float32, t float32) [4]float32 {
+	dot := a[0]*b[0] + a[1]*b[1] + a[2]*b[2] + a[3]*b[3]
+	if dot < 0 {
+		b = [4]float32{-b[0], -b[1], -b[2], -b[3]}
+		dot = -dot
+	}
+	wa, wb := 1-t, t
+	// nearly equal rotations are lerped, as sin of their angle is too small to divide by
+	if dot < 0.9995 {
+		angle := math.Acos(float64(dot))
+		sin := math.Sin(angle)
+		wa = float32(math.Sin(float64(1-t)*angle) / sin)
+		wb = float32(math.Sin(float64(t)*angle) / sin)
+	}
+	return quatNormalize([4]float32{
+		a[0]*wa + b[0]*wb,
+		a[1]*wa + b[1]*wb,
+		a[2]*wa + b[2]*wb,
+		a[3]*wa + b[3]*wb,
+	})
+}
+
+// vecLerp returns the point t of the way from a to b
+func vecLerp(a [3]float32, b [3]float32, t float32) [3]float32 {
+	return vecAdd(a, vecScale(vecSub(b, a), t))
+}
+
+// animKeySample returns the pose of keys at ms, holding the first and last keys past either end
+func animKeySample(keys []animKey, ms float64) animKey {
+	if ms <= keys[0].ms {
+		key := keys[0]
+		key.ms = ms
+		return key
+	}
+	for i := 1; i < len(keys); i++ {
+		if ms > keys[i].ms {
+			continue
+		}
+		a, b := keys[i-1], keys[i]
+		t := float32(0)
+		if b.ms > a.ms {
+			t = float32((ms - a.ms) / (b.ms - a.ms))
+		}
+		return animKey{
+			ms:          ms,
+			translation: vecLerp(a.translation, b.translation, t),
+			rotation:    quatSlerp(a.rotation, b.rotation, t),
+			scale:       vecLerp(a.scale, b.scale, t),
+		}
+	}
+	key := keys[len(keys)-1]
+	key.ms = ms
+	return key
+}
+
+// animKeysRetime applies opt to the keys of one bone
+func animKeysRetime(keys []animKey, opt *AnimTimingOption) []animKey {
+	if len(keys) == 0 {
+		return keys
+	}
+
+	if opt.TrimStart > 0 || opt.TrimEnd > 0 {
+		start := float64(opt.TrimStart)
+		end := keys[len(keys)-1].ms
+		if opt.TrimEnd > 0 {
+			end = math.Min(end, float64(opt.TrimEnd))
+		}
+		end = math.Max(start, end)
+		trimmed := []animKey{animKeySample(keys, start)}
+		for _, key := range keys {
+			if key.ms > start && key.ms < end {
+				trimmed = append(trimmed, key)
+			}
+		}
+		if end > start {
+			trimmed = append(trimmed, animKeySample(keys, end))
+		}
+		for i := range trimmed {
+			trimmed[i].ms -= start
+		}
+		keys = trimmed
+	}
+
+	if opt.IsReverse {
+		start := float64(opt.ReverseStart)
+		end := keys[len(keys)-1].ms
+		if opt.ReverseEnd > 0 {
+			end = math.Min(end, float64(opt.ReverseEnd))
+		}
+		if end > start {
+			// the range ends become keys so poses outside it are kept
+			reversed := []animKey{animKeySample(keys, start), animKeySample(keys, end)}
+			for _, key := range keys {
+				if key.ms != start && key.ms != end {
+					reversed = append(reversed, key)
+				}
+			}
+			for i := range reversed {
+				if reversed[i].ms >= start && reversed[i].ms <= end {
+					reversed[i].ms = start + end - reversed[i].ms
+				}
+			}
+			sort.SliceStable(reversed, func(i, j int) bool {
+				return reversed[i].ms < reversed[j].ms
+			})
+			keys = reversed
+		}
+	}
+
+	if opt.Speed > 0 && opt.Speed != 1 {
+		for i := range keys {
+			keys[i].ms /= float64(opt.Speed)
+		}
+	}
+
+	if opt.Interval > 0 {
+		duration := keys[len(keys)-1].ms
+		count := int(math.Round(duration/float64(opt.Interval))) + 1
+		resampled := make([]animKey, 0, count)
+		for i := 0; i < count; i++ {
+			resampled = append(resampled, animKeySample(keys, math.Min(float64(i)*float64(opt.Interval), duration)))
+		}
+		keys = resampled
+	}
+	return keys
+}
+
+// Retime trims, reverses, speeds up and resamples every bone of an eqg animation
+func (e *EqgAniDef) Retime(opt *AnimTimingOption) error {
+	for _, bone := range e.Bones {
+		keys := make([]animKey, 0, len(bone.Frames))
+		for _, frame := range bone.Frames {
+			keys = append(keys, animKey{
+				ms:          float64(frame.Milliseconds),
+				translation: frame.Translation,
+				rotation:    quatNormalize(frame.Rotation),
+				scale:       frame.Scale,
+			})
+		}
+		sort.SliceStable(keys, func(i, j int) bool {
+			return keys[i].ms < keys[j].ms
+		})
+
+		keys = animKeysRetime(keys, opt)
+		bone.Frames = bone.Frames[:0]
+		for _, key := range keys {
+			bone.Frames = append(bone.Frames, &AniBoneFrame{
+				Milliseconds: uint32(math.Round(key.ms)),
+				Translation:  key.translation,
+				Rotation:     key.rotation,
+				Scale:        key.scale,
+			})
+		}
+	}
+	return nil
+}
+
+// Retime trims, reverses, speeds up and resamples a track played at sleep milliseconds per frame.
+// Tracks play frames at a fixed rate, so frames are always resampled, by default at the sped up sleep.
+// The new sleep is returned
+func (e *TrackDef) Retime(sleep uint32, opt *AnimTimingOption) (uint32, error) {
+	if sleep == 0 {
+		return 0, fmt.Errorf("%s sleep is 0", e.Tag)
+	}
+	count := wldTrackFrameCount(e)
+	if count == 0 {
+		return sleep, nil
+	}
+
+	keys := make([]animKey, 0, count)
+	denominator := int16(0)
+	for i := 0; i < count; i++ {
+		translation, rotation := wldTrackFrame(e, i)
+		keys = append(keys, animKey{ms: float64(i) * float64(sleep), translation: translation, rotation: rotation})
+		if len(e.Frames) > 0 {
+			denominator = max(denominator, e.Frames[i].XYZScale)
+		} else {
+			denominator = max(denominator, e.LegacyFrames[i].XYZScale)
+		}
+	}
+	if denominator <= 0 {
+		denominator = 256
+	}
+
+	resample := *opt
+	if resample.Interval == 0 {
+		speed := float64(opt.Speed)
+		if speed <= 0 {
+			speed = 1
+		}
+		resample.Interval = uint32(math.Max(1, math.Round(float64(sleep)/speed)))
+	}
+	keys = animKeysRetime(keys, &resample)
+
+	isLegacy := len(e.Frames) == 0
+	e.Frames = nil
+	e.LegacyFrames = nil
+	for _, key := range keys {
+		xyz, xyzScale := wldShiftEncode(key.translation, denominator)
+		if isLegacy {
+			e.LegacyFrames = append(e.LegacyFrames, &LegacyFrame{XYZScale: xyzScale, XYZ: xyz, Rotation: key.rotation})
+			continue
+		}
+		e.Frames = append(e.Frames, &Frame{
+			XYZScale: xyzScale,
+			XYZ:      xyz,
+			RotScale: int16(math.Round(float64(key.rotation[3]) * 16384)),
+			Rotation: [3]int16{
+				int16(math.Round(float64(key.rotation[0]) * 16384)),
+				int16(math.Round(float64(key.rotation[1]) * 16384)),
+				int16(math.Round(float64(key.rotation[2]) * 16384)),
+			},
+		})
+	}
+	return resample.Interval, nil
+}
+
+// RetimeAnimations retimes the animations of model named by codes, or every animation when codes
+// is empty. s3d animation tracks get the returned sleep of their track, eqg animations are changed
+// in place. The names of the retimed animations are returned
+func (wce *Wce) RetimeAnimations(model string, codes []string, opt *AnimTimingOption) ([]string, error) {
+	isWanted := func(code string) bool {
+		if len(codes) == 0 {
+			return true
+		}
+		for _, want := range codes {
+			if strings.EqualFold(want, code) {
+				return true
+			}
+		}
+		return false
+	}
+
+	retimed := map[string]bool{}
+	for _, mds := range wce.MdsDefs {
+		if !strings.EqualFold(mds.Tag, model) {
+			continue
+		}
+		for _, ani := range wce.AniDefs {
+			code, ok := eqgAniCode(ani.Tag, model)
+			if !ok || !isWanted(code) {
+				continue
+			}
+			err := ani.Retime(opt)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", ani.Tag, err)
+			}
+			retimed[ani.Tag] = true
+		}
+	}
+
+	sprite, ok := wce.ByTag(strings.ToUpper(model) + "_HS_DEF").(*HierarchicalSpriteDef)
+	if ok {
+		_, tracks, err := wce.wldAnimBones(strings.ToUpper(model), sprite)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", sprite.Tag, err)
+		}
+		dagTracks := map[string]bool{}
+		for _, tag := range tracks {
+			dagTracks[baseTag(tag)] = true
+		}
+		// a track shared by instances is retimed once
+		sleeps := map[*TrackDef]uint32{}
+		for _, instance := range wce.TrackInstances {
+			tag := baseTag(instance.Tag)
+			if len(tag) <= 3 || !regexAniPrefix.MatchString(tag) || !dagTracks[tag[3:]] || !isWanted(tag[:3]) {
+				continue
+			}
+			_, track, err := wce.trackDefByInstance(instance.Tag)
+			if err != nil {
+				return nil, err
+			}
+			sleep, ok := sleeps[track]
+			if !ok {
+				sleep = 100
+				if instance.Sleep.Valid && instance.Sleep.Uint32 > 0 {
+					sleep = instance.Sleep.Uint32
+				}
+				sleep, err = track.Retime(sleep, opt)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", instance.Tag, err)
+				}
+				sleeps[track] = sleep
+			}
+			instance.Sleep.Valid = true
+			instance.Sleep.Uint32 = sleep
+			retimed[tag[:3]] = true
+		}
+	}
+
+	names := make([]string, 0, len(retimed))
+	for name := range retimed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,107 @@
+package wce
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAnimRetime(t *testing.T) {
+	// 2 units up and 45 degrees about z each frame
+	newTrack := func() *TrackDef {
+		track := &TrackDef{Tag: "C01ELF_TRACKDEF"}
+		for i := 0; i < 3; i++ {
+			angle := float64(i) * math.Pi / 8
+			track.Frames = append(track.Frames, &Frame{
+				XYZScale: 256,
+				XYZ:      [3]int16{0, 0, int16(i * 512)},
+				RotScale: int16(math.Round(math.Cos(angle) * 16384)),
+				Rotation: [3]int16{0, 0, int16(math.Round(math.Sin(angle) * 16384))},
+			})
+		}
+		return track
+	}
+	heights := func(track *TrackDef) []float32 {
+		values := []float32{}
+		for i := 0; i < wldTrackFrameCount(track); i++ {
+			translation, _ := wldTrackFrame(track, i)
+			values = append(values, translation[2])
+		}
+		return values
+	}
+	equal := func(a []float32, b []float32) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if math.Abs(float64(a[i]-b[i])) > 0.01 {
+				return false
+			}
+		}
+		return true
+	}
+
+	track := newTrack()
+	sleep, err := track.Retime(100, &AnimTimingOption{Speed: 2})
+	if err != nil {
+		t.Fatalf("speed: %v", err)
+	}
+	if sleep != 50 || !equal(heights(track), []float32{0, 2, 4}) {
+		t.Fatalf("sped up to sleep %d with heights %v, want 50 with [0 2 4]", sleep, heights(track))
+	}
+
+	track = newTrack()
+	sleep, err = track.Retime(100, &AnimTimingOption{Interval: 50})
+	if err != nil {
+		t.Fatalf("resample: %v", err)
+	}
+	if sleep != 50 || !equal(heights(track), []float32{0, 1, 2, 3, 4}) {
+		t.Fatalf("resampled to sleep %d with heights %v, want 50 with [0 1 2 3 4]", sleep, heights(track))
+	}
+	_, rotation := wldTrackFrame(track, 1)
+	// halfway between no turn and 45 degrees is 22.5 degrees, a quaternion z of sin(11.25)
+	if math.Abs(float64(rotation[2])-math.Sin(math.Pi/16)) > 0.001 {
+		t.Fatalf("resampled rotation %v not slerped", rotation)
+	}
+
+	track = newTrack()
+	_, err = track.Retime(100, &AnimTimingOption{TrimStart: 100})
+	if err != nil {
+		t.Fatalf("trim: %v", err)
+	}
+	if !equal(heights(track), []float32{2, 4}) {
+		t.Fatalf("trimmed heights %v, want [2 4]", heights(track))
+	}
+
+	track = newTrack()
+	_, err = track.Retime(100, &AnimTimingOption{IsReverse: true, ReverseStart: 100})
+	if err != nil {
+		t.Fatalf("reverse: %v", err)
+	}
+	if !equal(heights(track), []float32{0, 4, 2}) {
+		t.Fatalf("reversed heights %v, want [0 4 2]", heights(track))
+	}
+
+	ani := &EqgAniDef{Tag: "stnd_ba_1_exo", Bones: []*AniBone{{Name: "root", Frames: []*AniBoneFrame{
+		{Milliseconds: 0, Rotation: [4]float32{0, 0, 0, 1}, Scale: [3]float32{1, 1, 1}},
+		{Milliseconds: 1000, Translation: [3]float32{4, 0, 0}, Rotation: [4]float32{0, 0, 0, 1}, Scale: [3]float32{3, 3, 3}},
+	}}}}
+	err = ani.Retime(&AnimTimingOption{Speed: 2, Interval: 125})
+	if err != nil {
+		t.Fatalf("eqg retime: %v", err)
+	}
+	frames := ani.Bones[0].Frames
+	if len(frames) != 5 || frames[4].Milliseconds != 500 || frames[2].Translation[0] != 2 || frames[2].Scale[0] != 2 {
+		t.Fatalf("eqg animation not sped up and resampled")
+	}
+
+	eqg := New("exo.eqg")
+	eqg.MdsDefs = append(eqg.MdsDefs, &EqgMdsDef{Tag: "exo"})
+	eqg.AniDefs = append(eqg.AniDefs, ani, &EqgAniDef{Tag: "walk_ba_1_exo"})
+	names, err := eqg.RetimeAnimations("exo", []string{"stnd"}, &AnimTimingOption{Speed: 0.5})
+	if err != nil {
+		t.Fatalf("retime animations: %v", err)
+	}
+	if len(names) != 1 || names[0] != "stnd_ba_1_exo" || ani.Bones[0].Frames[4].Milliseconds != 1000 {
+		t.Fatalf("retimed %v, want [stnd_ba_1_exo] slowed back to 1000ms", names)
+	}
+}
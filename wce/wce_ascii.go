@@ -317,6 +317,27 @@ func (wce *Wce) writeAsciiData(path string) error {
 		}
 	}
 
+	for _, ecoDef := range wce.EcoDefs {
+		err = ecoDef.Write(token)
+		if err != nil {
+			return fmt.Errorf("ecodef %s: %w", ecoDef.Tag, err)
+		}
+	}
+
+	for _, rfdDef := range wce.RfdDefs {
+		err = rfdDef.Write(token)
+		if err != nil {
+			return fmt.Errorf("rfddef %s: %w", rfdDef.Tag, err)
+		}
+	}
+
+	for _, togDef := range wce.TogDefs {
+		err = togDef.Write(token)
+		if err != nil {
+			return fmt.Errorf("togdef %s: %w", togDef.Tag, err)
+		}
+	}
+
 	for _, layDef := range wce.LayDefs {
 		err = layDef.Write(token)
 		if err != nil {
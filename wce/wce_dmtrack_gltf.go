@@ -0,0 +1,418 @@
+package wce
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/xackery/quail/gltf"
+)
+
+// wldToGltf converts a z up wld position to y up gltf
+func wldToGltf(v [3]float32) [3]float32 {
+	return [3]float32{v[0], v[2], -v[1]}
+}
+
+// gltfToWld converts a y up gltf position to z up wld
+func gltfToWld(v [3]float32) [3]float32 {
+	return [3]float32{v[0], -v[2], v[1]}
+}
+
+// dmSpriteByTag returns the DMSPRITEDEF2 named tag
+func (wce *Wce) dmSpriteByTag(tag string) (*DMSpriteDef2, error) {
+	sprite, ok := wce.ByTag(tag).(*DMSpriteDef2)
+	if !ok {
+		return nil, fmt.Errorf("dmspritedef2 %s not found", tag)
+	}
+	return sprite, nil
+}
+
+// DMTrackGltf exports the DMTRACKDEF2 vertex animation of the sprite named spriteTag as a gltf mesh,
+// with one morph target per frame and a weight animation stepping through them at the track sleep
+func (wce *Wce) DMTrackGltf(spriteTag string) (*gltf.Document, error) {
+	sprite, err := wce.dmSpriteByTag(spriteTag)
+	if err != nil {
+		return nil, err
+	}
+	track, ok := wce.ByTag(sprite.DmTrackTag).(*DMTrackDef2)
+	if !ok {
+		return nil, fmt.Errorf("%s has no dmtrackdef2", sprite.Tag)
+	}
+	if len(track.Frames) == 0 {
+		return nil, fmt.Errorf("%s has no frames", track.Tag)
+	}
+	for i, frame := range track.Frames {
+		if len(frame) != len(sprite.Vertices) {
+			return nil, fmt.Errorf("%s frame %d has %d vertices, %s has %d", track.Tag, i, len(frame), sprite.Tag, len(sprite.Vertices))
+		}
+	}
+
+	doc := gltf.New()
+
+	positions := make([]float32, 0, len(sprite.Vertices)*3)
+	for _, v := range sprite.Vertices {
+		p := wldToGltf(vecAdd(v, sprite.CenterOffset))
+		positions = append(positions, p[:]...)
+	}
+	primitive := &gltf.Primitive{
+		Attributes: map[string]int{"POSITION": doc.AddFloats(positions, gltf.TypeVec3, gltf.TargetArrayBuffer)},
+	}
+	if len(sprite.VertexNormals) == len(sprite.Vertices) {
+		normals := make([]float32, 0, len(sprite.VertexNormals)*3)
+		for _, n := range sprite.VertexNormals {
+			n = wldToGltf(n)
+			normals = append(normals, n[:]...)
+		}
+		primitive.Attributes["NORMAL"] = doc.AddFloats(normals, gltf.TypeVec3, gltf.TargetArrayBuffer)
+	}
+	indices := make([]uint32, 0, len(sprite.Faces)*3)
+	for _, face := range sprite.Faces {
+		indices = append(indices, uint32(face.Triangle[0]), uint32(face.Triangle[1]), uint32(face.Triangle[2]))
+	}
+	if len(indices) > 0 {
+		primitive.Indices = gltf.Int(doc.AddIndices(indices))
+	}
+
+	mesh := &gltf.Mesh{
+		Name:       sprite.Tag,
+		Primitives: []*gltf.Primitive{primitive},
+		Weights:    make([]float32, len(track.Frames)),
+		Extras:     &gltf.MeshExtras{},
+	}
+	mesh.Weights[0] = 1
+	// frames and sprite vertices are both relative to the center offset, so a target is their difference
+	for i, frame := range track.Frames {
+		displacements := make([]float32, 0, len(frame)*3)
+		for j, v := range frame {
+			d := wldToGltf(vecSub(v, sprite.Vertices[j]))
+			displacements = append(displacements, d[:]...)
+		}
+		primitive.Targets = append(primitive.Targets, map[string]int{"POSITION": doc.AddFloats(displacements, gltf.TypeVec3, gltf.TargetArrayBuffer)})
+		mesh.Extras.TargetNames = append(mesh.Extras.TargetNames, fmt.Sprintf("frame%d", i))
+	}
+	doc.Meshes = append(doc.Meshes, mesh)
+	doc.Nodes = append(doc.Nodes, &gltf.Node{Name: sprite.Tag, Mesh: gltf.Int(0)})
+	doc.Scenes = append(doc.Scenes, &gltf.Scene{Nodes: []int{0}})
+	doc.Scene = gltf.Int(0)
+
+	// each key fully weights its own frame
+	times := make([]float32, len(track.Frames))
+	weights := make([]float32, len(track.Frames)*len(track.Frames))
+	for i := range track.Frames {
+		times[i] = float32(i) * float32(track.Sleep) / 1000
+		weights[i*len(track.Frames)+i] = 1
+	}
+	doc.Animations = append(doc.Animations, &gltf.Animation{
+		Name: track.Tag,
+		Samplers: []*gltf.Sampler{{
+			Input:         doc.AddFloats(times, gltf.TypeScalar, 0),
+			Interpolation: gltf.InterpolationLinear,
+			Output:        doc.AddFloats(weights, gltf.TypeScalar, 0),
+		}},
+		Channels: []*gltf.Channel{{Sampler: 0, Target: gltf.ChannelTarget{Node: gltf.Int(0), Path: "weights"}}},
+	})
+	return doc, nil
+}
+
+// DMTrackFromGltf replaces the DMTRACKDEF2 of the sprite named spriteTag with the morph target
+// weight animation in doc. Weights are sampled every sleep, the shortest gap between animation keys,
+// and frames are placed by the nodes above the mesh. A sprite without a track gets one
+func (wce *Wce) DMTrackFromGltf(spriteTag string, doc *gltf.Document) (*DMTrackDef2, error) {
+	sprite, err := wce.dmSpriteByTag(spriteTag)
+	if err != nil {
+		return nil, err
+	}
+
+	meshIndex := -1
+	for i, mesh := range doc.Meshes {
+		if len(mesh.Primitives) > 0 && len(mesh.Primitives[0].Targets) > 0 {
+			meshIndex = i
+			break
+		}
+	}
+	if meshIndex < 0 {
+		return nil, fmt.Errorf("no mesh with morph targets found")
+	}
+	mesh := doc.Meshes[meshIndex]
+	primitive := mesh.Primitives[0]
+
+	position, ok := primitive.Attributes["POSITION"]
+	if !ok {
+		return nil, fmt.Errorf("mesh %s has no positions", mesh.Name)
+	}
+	base, err := doc.Floats(position)
+	if err != nil {
+		return nil, fmt.Errorf("mesh %s positions: %w", mesh.Name, err)
+	}
+	if len(base)/3 != len(sprite.Vertices) {
+		return nil, fmt.Errorf("mesh %s has %d vertices, %s has %d", mesh.Name, len(base)/3, sprite.Tag, len(sprite.Vertices))
+	}
+	targets := make([][]float32, len(primitive.Targets))
+	for i, target := range primitive.Targets {
+		index, ok := target["POSITION"]
+		if !ok {
+			targets[i] = make([]float32, len(base))
+			continue
+		}
+		targets[i], err = doc.Floats(index)
+		if err != nil {
+			return nil, fmt.Errorf("mesh %s target %d: %w", mesh.Name, i, err)
+		}
+		if len(targets[i]) != len(base) {
+			return nil, fmt.Errorf("mesh %s target %d has %d vertices, want %d", mesh.Name, i, len(targets[i])/3, len(base)/3)
+		}
+	}
+
+	transform, err := gltfNodeTransform(doc, meshIndex)
+	if err != nil {
+		return nil, fmt.Errorf("mesh %s: %w", mesh.Name, err)
+	}
+	weights, err := gltfWeightKeys(doc, meshIndex, len(targets))
+	if err != nil {
+		return nil, fmt.Errorf("mesh %s: %w", mesh.Name, err)
+	}
+	sleep, keys := weights.resample()
+
+	frames := make([][][3]float32, 0, len(keys))
+	extent := float32(0)
+	for _, weights := range keys {
+		frame := make([][3]float32, len(sprite.Vertices))
+		for j := range frame {
+			p := [3]float32{base[j*3], base[j*3+1], base[j*3+2]}
+			for k, target := range targets {
+				p = vecAdd(p, vecScale([3]float32{target[j*3], target[j*3+1], target[j*3+2]}, weights[k]))
+			}
+			frame[j] = vecSub(gltfToWld(transform(p)), sprite.CenterOffset)
+			for i := 0; i < 3; i++ {
+				extent = float32(math.Max(float64(extent), math.Abs(float64(frame[j][i]))))
+			}
+		}
+		frames = append(frames, frame)
+	}
+	fpScale, err := fixedPointScale(extent)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", sprite.Tag, err)
+	}
+
+	track, ok := wce.ByTag(sprite.DmTrackTag).(*DMTrackDef2)
+	if !ok {
+		track = &DMTrackDef2{
+			folders: sprite.folders,
+			Tag:     strings.TrimSuffix(baseTag(sprite.Tag), "_DMSPRITEDEF") + "_DMTRACKDEF",
+		}
+		wce.DMTrackDef2s = append(wce.DMTrackDef2s, track)
+		sprite.DmTrackTag = track.Tag
+	}
+	track.Sleep = sleep
+	track.FPScale = fpScale
+	track.Frames = frames
+	return track, nil
+}
+
+// gltfTimeEpsilon is how close in seconds a sample has to be to a key to land on it
+const gltfTimeEpsilon = 0.0001
+
+// gltfWeightTrack is the morph target weight animation of a mesh
+type gltfWeightTrack struct {
+	interpolation string
+	times         []float32
+	values        [][]float32
+	inTangents    [][]float32 // cubic spline only
+	outTangents   [][]float32 // cubic spline only
+}
+
+// sample returns the weights at t seconds, holding the first and last keys past either end
+func (track *gltfWeightTrack) sample(t float32) []float32 {
+	last := len(track.times) - 1
+	if t <= track.times[0] {
+		return track.values[0]
+	}
+	if t+gltfTimeEpsilon >= track.times[last] {
+		return track.values[last]
+	}
+	k := sort.Search(len(track.times), func(i int) bool { return track.times[i] > t+gltfTimeEpsilon }) - 1
+	if track.interpolation == gltf.InterpolationStep {
+		return track.values[k]
+	}
+
+	span := track.times[k+1] - track.times[k]
+	s := (t - track.times[k]) / span
+	weights := make([]float32, len(track.values[k]))
+	for i := range weights {
+		a, b := track.values[k][i], track.values[k+1][i]
+		if track.interpolation == gltf.InterpolationCubicSpline {
+			s2, s3 := s*s, s*s*s
+			weights[i] = (2*s3-3*s2+1)*a + (s3-2*s2+s)*span*track.outTangents[k][i] +
+				(-2*s3+3*s2)*b + (s3-s2)*span*track.inTangents[k+1][i]
+			continue
+		}
+		weights[i] = a + (b-a)*s
+	}
+	return weights
+}
+
+// resample returns the sleep in milliseconds between frames, the shortest gap between keys, and
+// the weights of each frame from the first key to the last
+func (track *gltfWeightTrack) resample() (uint16, [][]float32) {
+	duration := float64(track.times[len(track.times)-1]-track.times[0]) * 1000
+	if duration <= 0 {
+		return 100, [][]float32{track.values[0]}
+	}
+	gap := duration
+	for i := 1; i < len(track.times); i++ {
+		ms := float64(track.times[i]-track.times[i-1]) * 1000
+		if ms > 0 && ms < gap {
+			gap = ms
+		}
+	}
+	sleep := math.Max(1, math.Round(gap))
+	// a track keeps at most a uint16 of frames
+	if duration/sleep >= math.MaxUint16 {
+		sleep = math.Ceil(duration / (math.MaxUint16 - 1))
+	}
+	sleep = math.Min(sleep, math.MaxUint16)
+
+	keys := make([][]float32, int(math.Round(duration/sleep))+1)
+	for i := range keys {
+		keys[i] = track.sample(track.times[0] + float32(float64(i)*sleep/1000))
+	}
+	return uint16(sleep), keys
+}
+
+// gltfNodeTransform returns a function placing a point of mesh by the node drawing it and every
+// node above that. Nodes moved by an animation are rejected, as only weights are imported
+func gltfNodeTransform(doc *gltf.Document, meshIndex int) (func([3]float32) [3]float32, error) {
+	parents := map[int]int{}
+	for i, node := range doc.Nodes {
+		for _, child := range node.Children {
+			parents[child] = i
+		}
+	}
+	moved := map[int]string{}
+	for _, animation := range doc.Animations {
+		for _, channel := range animation.Channels {
+			if channel.Target.Node != nil && channel.Target.Path != "weights" {
+				moved[*channel.Target.Node] = channel.Target.Path
+			}
+		}
+	}
+
+	index := -1
+	for i, node := range doc.Nodes {
+		if node.Mesh != nil && *node.Mesh == meshIndex {
+			index = i
+			break
+		}
+	}
+	chain := []*gltf.Node{}
+	for index >= 0 {
+		if len(chain) > len(doc.Nodes) {
+			return nil, fmt.Errorf("node %d is its own ancestor", index)
+		}
+		node := doc.Nodes[index]
+		if path, ok := moved[index]; ok {
+			return nil, fmt.Errorf("node %d has a %s animation, only weights are imported", index, path)
+		}
+		if len(node.Matrix) != 0 && len(node.Matrix) != 16 {
+			return nil, fmt.Errorf("node %d matrix has %d values, want 16", index, len(node.Matrix))
+		}
+		if len(node.Matrix) != 0 && (len(node.Translation) != 0 || len(node.Rotation) != 0 || len(node.Scale) != 0) {
+			return nil, fmt.Errorf("node %d has both a matrix and a translation, rotation or scale", index)
+		}
+		if len(node.Translation) != 0 && len(node.Translation) != 3 ||
+			len(node.Rotation) != 0 && len(node.Rotation) != 4 ||
+			len(node.Scale) != 0 && len(node.Scale) != 3 {
+			return nil, fmt.Errorf("node %d translation, rotation or scale has the wrong number of values", index)
+		}
+		chain = append(chain, node)
+
+		parent, ok := parents[index]
+		if !ok {
+			break
+		}
+		index = parent
+	}
+
+	return func(p [3]float32) [3]float32 {
+		for _, node := range chain {
+			if len(node.Matrix) == 16 {
+				m := node.Matrix
+				p = [3]float32{
+					m[0]*p[0] + m[4]*p[1] + m[8]*p[2] + m[12],
+					m[1]*p[0] + m[5]*p[1] + m[9]*p[2] + m[13],
+					m[2]*p[0] + m[6]*p[1] + m[10]*p[2] + m[14],
+				}
+				continue
+			}
+			if len(node.Scale) == 3 {
+				p = [3]float32{p[0] * node.Scale[0], p[1] * node.Scale[1], p[2] * node.Scale[2]}
+			}
+			if len(node.Rotation) == 4 {
+				p = quatRotate(quatNormalize([4]float32{node.Rotation[0], node.Rotation[1], node.Rotation[2], node.Rotation[3]}), p)
+			}
+			if len(node.Translation) == 3 {
+				p = vecAdd(p, [3]float32{node.Translation[0], node.Translation[1], node.Translation[2]})
+			}
+		}
+		return p
+	}, nil
+}
+
+// gltfWeightKeys returns the morph target animation of mesh. Without an animation, the mesh
+// weights are the only key
+func gltfWeightKeys(doc *gltf.Document, meshIndex int, targetCount int) (*gltfWeightTrack, error) {
+	for _, animation := range doc.Animations {
+		for _, channel := range animation.Channels {
+			if channel.Target.Path != "weights" || channel.Target.Node == nil {
+				continue
+			}
+			node := *channel.Target.Node
+			if node < 0 || node >= len(doc.Nodes) || doc.Nodes[node].Mesh == nil || *doc.Nodes[node].Mesh != meshIndex {
+				continue
+			}
+			if channel.Sampler < 0 || channel.Sampler >= len(animation.Samplers) {
+				return nil, fmt.Errorf("animation %s sampler %d out of range", animation.Name, channel.Sampler)
+			}
+			sampler := animation.Samplers[channel.Sampler]
+			times, err := doc.Floats(sampler.Input)
+			if err != nil {
+				return nil, fmt.Errorf("animation %s input: %w", animation.Name, err)
+			}
+			values, err := doc.Floats(sampler.Output)
+			if err != nil {
+				return nil, fmt.Errorf("animation %s output: %w", animation.Name, err)
+			}
+			// cubic spline keys are an in tangent, value and out tangent
+			stride := targetCount
+			offset := 0
+			if sampler.Interpolation == gltf.InterpolationCubicSpline {
+				stride = targetCount * 3
+				offset = targetCount
+			}
+			if len(values) != len(times)*stride {
+				return nil, fmt.Errorf("animation %s has %d weights for %d keys of %d targets", animation.Name, len(values), len(times), targetCount)
+			}
+			if len(times) == 0 {
+				return nil, fmt.Errorf("animation %s has no keys", animation.Name)
+			}
+			track := &gltfWeightTrack{interpolation: sampler.Interpolation, times: times}
+			for i := range times {
+				if i > 0 && times[i] < times[i-1] {
+					return nil, fmt.Errorf("animation %s key %d is before key %d", animation.Name, i, i-1)
+				}
+				key := values[i*stride : (i+1)*stride]
+				track.values = append(track.values, key[offset:offset+targetCount])
+				if sampler.Interpolation == gltf.InterpolationCubicSpline {
+					track.inTangents = append(track.inTangents, key[:targetCount])
+					track.outTangents = append(track.outTangents, key[targetCount*2:])
+				}
+			}
+			return track, nil
+		}
+	}
+
+	weights := make([]float32, targetCount)
+	copy(weights, doc.Meshes[meshIndex].Weights)
+	return &gltfWeightTrack{times: []float32{0}, values: [][]float32{weights}}, nil
+}
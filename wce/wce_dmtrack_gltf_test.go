@@ -0,0 +1,155 @@
+package wce
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/xackery/quail/gltf"
+	"github.com/xackery/quail/raw"
+)
+
+func TestDMTrackGltf(t *testing.T) {
+	flag := New("flag.wld")
+	flag.DMSpriteDef2s = append(flag.DMSpriteDef2s, &DMSpriteDef2{
+		Tag:           "FLAG_DMSPRITEDEF",
+		DmTrackTag:    "FLAG_DMTRACKDEF",
+		CenterOffset:  [3]float32{10, 20, 30},
+		Vertices:      [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 0, 1}},
+		VertexNormals: [][3]float32{{0, 1, 0}, {0, 1, 0}, {0, 1, 0}},
+		Faces:         []*Face{{Triangle: [3]uint16{0, 1, 2}}},
+	})
+	flag.DMTrackDef2s = append(flag.DMTrackDef2s, &DMTrackDef2{
+		Tag:     "FLAG_DMTRACKDEF",
+		Sleep:   150,
+		FPScale: 8,
+		Frames: [][][3]float32{
+			{{0, 0, 0}, {1, 0, 0}, {0, 0, 1}},
+			{{0, 0, 0}, {1, 0.5, 0}, {0, 0.25, 1}},
+			{{0, 0, 0}, {1, -0.5, 0}, {0, -0.25, 1}},
+		},
+	})
+
+	doc, err := flag.DMTrackGltf("FLAG_DMSPRITEDEF")
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if len(doc.Meshes[0].Primitives[0].Targets) != 3 || len(doc.Animations) != 1 {
+		t.Fatalf("got %d targets and %d animations, want 3 and 1", len(doc.Meshes[0].Primitives[0].Targets), len(doc.Animations))
+	}
+	positions, err := doc.Floats(doc.Meshes[0].Primitives[0].Attributes["POSITION"])
+	if err != nil {
+		t.Fatalf("positions: %v", err)
+	}
+	// wld 11 20 30 is gltf y up 11 30 -20
+	if positions[3] != 11 || positions[4] != 30 || positions[5] != -20 {
+		t.Fatalf("vertex 1 at %v, want [11 30 -20]", positions[3:6])
+	}
+
+	buf := &bytes.Buffer{}
+	err = doc.WriteGLB(buf)
+	if err != nil {
+		t.Fatalf("write glb: %v", err)
+	}
+	doc, err = gltf.Read(buf)
+	if err != nil {
+		t.Fatalf("read glb: %v", err)
+	}
+
+	want := flag.DMTrackDef2s[0].Frames
+	flag.DMTrackDef2s[0] = &DMTrackDef2{Tag: "FLAG_DMTRACKDEF"}
+	track, err := flag.DMTrackFromGltf("FLAG_DMSPRITEDEF", doc)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if track != flag.DMTrackDef2s[0] || track.Sleep != 150 || len(track.Frames) != 3 {
+		t.Fatalf("got track %s sleep %d with %d frames, want sleep 150 with 3", track.Tag, track.Sleep, len(track.Frames))
+	}
+	// the largest offset is 1 unit, and 2^15 steps of it would overflow an int16
+	if track.FPScale != 14 {
+		t.Fatalf("fpscale %d, want 14", track.FPScale)
+	}
+	for i, frame := range track.Frames {
+		for j, v := range frame {
+			for k := 0; k < 3; k++ {
+				if math.Abs(float64(v[k]-want[i][j][k])) > 0.0001 {
+					t.Fatalf("frame %d vertex %d at %v, want %v", i, j, v, want[i][j])
+				}
+			}
+		}
+	}
+
+	rawWld := &raw.Wld{}
+	_, err = track.ToRaw(flag, rawWld)
+	if err != nil {
+		t.Fatalf("to raw: %v", err)
+	}
+
+	// a sprite without a track gets one
+	flag.DMSpriteDef2s[0].DmTrackTag = ""
+	track, err = flag.DMTrackFromGltf("FLAG_DMSPRITEDEF", doc)
+	if err != nil {
+		t.Fatalf("import new: %v", err)
+	}
+	if track.Tag != "FLAG_DMTRACKDEF" || flag.DMSpriteDef2s[0].DmTrackTag != track.Tag || len(flag.DMTrackDef2s) != 2 {
+		t.Fatalf("new track %s not linked to its sprite", track.Tag)
+	}
+}
+
+func TestDMTrackFromGltfResample(t *testing.T) {
+	flag := New("flag.wld")
+	flag.DMSpriteDef2s = append(flag.DMSpriteDef2s, &DMSpriteDef2{
+		Tag:        "FLAG_DMSPRITEDEF",
+		DmTrackTag: "FLAG_DMTRACKDEF",
+		Vertices:   [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 0, 1}},
+		Faces:      []*Face{{Triangle: [3]uint16{0, 1, 2}}},
+	})
+	want := [][][3]float32{
+		{{0, 0, 0}, {1, 0, 0}, {0, 0, 1}},
+		{{0, 0, 0}, {1, 0.5, 0}, {0, 0.25, 1}},
+		{{0, 0, 0}, {1, -0.5, 0}, {0, -0.25, 1}},
+	}
+	flag.DMTrackDef2s = append(flag.DMTrackDef2s, &DMTrackDef2{Tag: "FLAG_DMTRACKDEF", Sleep: 100, Frames: want})
+
+	doc, err := flag.DMTrackGltf("FLAG_DMSPRITEDEF")
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	// keys at 0, 100 and 300 ms step to each frame, so 200 ms holds frame 1
+	sampler := doc.Animations[0].Samplers[0]
+	sampler.Input = doc.AddFloats([]float32{0, 0.1, 0.3}, gltf.TypeScalar, 0)
+	sampler.Interpolation = gltf.InterpolationStep
+	// gltf y up 2 is wld z up 2
+	doc.Nodes[0].Translation = []float32{0, 2, 0}
+
+	track, err := flag.DMTrackFromGltf("FLAG_DMSPRITEDEF", doc)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if track.Sleep != 100 || len(track.Frames) != 4 {
+		t.Fatalf("got sleep %d with %d frames, want sleep 100 with 4", track.Sleep, len(track.Frames))
+	}
+	for i, source := range []int{0, 1, 1, 2} {
+		for j, v := range track.Frames[i] {
+			w := vecAdd(want[source][j], [3]float32{0, 0, 2})
+			for k := 0; k < 3; k++ {
+				if math.Abs(float64(v[k]-w[k])) > 0.0001 {
+					t.Fatalf("frame %d vertex %d at %v, want %v", i, j, v, w)
+				}
+			}
+		}
+	}
+
+	doc.Nodes[0].Matrix = make([]float32, 16)
+	_, err = flag.DMTrackFromGltf("FLAG_DMSPRITEDEF", doc)
+	if err == nil {
+		t.Fatalf("node with a matrix and a translation imported")
+	}
+	doc.Nodes[0].Matrix = nil
+
+	doc.Animations[0].Channels = append(doc.Animations[0].Channels, &gltf.Channel{Sampler: 0, Target: gltf.ChannelTarget{Node: gltf.Int(0), Path: "translation"}})
+	_, err = flag.DMTrackFromGltf("FLAG_DMSPRITEDEF", doc)
+	if err == nil {
+		t.Fatalf("node moved by an animation imported")
+	}
+}
@@ -0,0 +1,71 @@
+package wce
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/xackery/quail/helper"
+	"github.com/xackery/quail/raw"
+)
+
+func TestEqgEcoDefReadWrite(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"equals", []byte("*BEGIN_LAYER\r\n*NAME=farstone_grass\r\n*END_LAYER\r\n*BEGIN_FLORA\r\n*MODEL=obj_fern01\r\n\r\n*END_FLORA\r\n")},
+		{"whitespace", []byte("*VERSION 1\r\n*BEGIN_LAYER\r\n\t*NAME\t\tfarstone_grass\r\n*END_LAYER \r\n*BEGIN_FLORA\r\n\t*MODEL\tobj_fern01\r\n\r\n*END_FLORA\r\n")},
+		{"quoted", []byte("*BEGIN_LAYER\r\n*NAME \"farstone fern\"\r\n*PATH \"c:\\\\q\\\\\"\r\n*END_LAYER\r\n")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testEqgEcoDefReadWrite(t, tt.data)
+		})
+	}
+}
+
+func testEqgEcoDefReadWrite(t *testing.T, data []byte) {
+	src := &raw.Eco{MetaFileName: "farstone_base"}
+	err := src.Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("raw read: %s", err.Error())
+	}
+
+	wce := New("arcstone.eqg")
+	def := &EqgEcoDef{}
+	err = def.FromRaw(wce, src)
+	if err != nil {
+		t.Fatalf("from raw: %s", err.Error())
+	}
+	wce.EcoDefs = append(wce.EcoDefs, def)
+
+	dir := filepath.Join(t.TempDir(), "arcstone.quail")
+	err = wce.WriteAscii(dir)
+	if err != nil {
+		t.Fatalf("write ascii: %s", err.Error())
+	}
+	wce2 := New("arcstone.eqg")
+	err = wce2.ReadAscii(filepath.Join(dir, "_root.wce"))
+	if err != nil {
+		t.Fatalf("read ascii: %s", err.Error())
+	}
+	if len(wce2.EcoDefs) != 1 {
+		t.Fatalf("got %d eco defs, want 1", len(wce2.EcoDefs))
+	}
+
+	dst := &raw.Eco{}
+	err = wce2.EcoDefs[0].ToRaw(wce2, dst)
+	if err != nil {
+		t.Fatalf("to raw: %s", err.Error())
+	}
+	buf := &bytes.Buffer{}
+	err = dst.Write(buf)
+	if err != nil {
+		t.Fatalf("raw write: %s", err.Error())
+	}
+	err = helper.ByteCompareTest(data, buf.Bytes())
+	if err != nil {
+		t.Fatalf("byteCompare: %s", err)
+	}
+}
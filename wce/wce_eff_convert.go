@@ -0,0 +1,244 @@
+package wce
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xackery/quail/helper"
+)
+
+// EmitterByID returns the emitter an EmitterID refers to. Emitters are numbered from 1 by their
+// order in the .edd, and an EmitterID of 0 is an unused slot
+func (wce *Wce) EmitterByID(id int32) *EmitterDef {
+	if id <= 0 || int(id) > len(wce.EmitterDefs) {
+		return nil
+	}
+	return wce.EmitterDefs[id-1]
+}
+
+// classicParticle holds the particle settings shared by spells.eff sub effects and wld particle clouds
+type classicParticle struct {
+	name         string
+	sprite       string
+	particleType int32
+	colorBGRA    [4]uint8
+	gravity      float32
+	spawnNormal  [3]float32
+	spawnRadius  float32
+	lifespan     uint32 // milliseconds
+	velocity     float32
+	spawnRate    uint32 // particles a second
+	spawnScale   float32
+	animSpeed    float32
+}
+
+// classicEmitterDef returns an emitter that plays a classic particle. The sprite becomes the emitter
+// texture, the particle type is kept as the old particle type, and particles are spawned once a
+// second at the spawn rate
+func classicEmitterDef(p *classicParticle) *EmitterDef {
+	alpha := float32(p.colorBGRA[3]) / 255
+	// classic effects leave alpha unset as 0
+	if alpha == 0 {
+		alpha = 1
+	}
+	speed := vecScale(p.spawnNormal, p.velocity)
+	return &EmitterDef{
+		Name:                p.name,
+		Texture:             p.sprite,
+		AdditiveBlending:    1,
+		StickToActor:        1,
+		ParticleLifeSpan:    float32(p.lifespan) / 1000,
+		ParticlesAtInterval: int32(p.spawnRate),
+		IntervalsPerSecond:  1,
+		MaxAlpha:            alpha,
+		Alpha:               alpha,
+		ShapeRadius:         p.spawnRadius,
+		TintStart:           [3]uint32{uint32(p.colorBGRA[2]), uint32(p.colorBGRA[1]), uint32(p.colorBGRA[0])},
+		TintEnd:             [3]uint32{uint32(p.colorBGRA[2]), uint32(p.colorBGRA[1]), uint32(p.colorBGRA[0])},
+		SpeedMin:            speed,
+		SpeedMax:            speed,
+		ScalarGravity:       p.gravity,
+		AnimationRate:       p.animSpeed,
+		OldParticleType:     p.particleType,
+		SpawnScale:          p.spawnScale,
+	}
+}
+
+// spriteTextureFile returns the texture file of the first frame of a sprite, following a blit
+// sprite to its simple sprite. Sprites are looked up by tag, and by name as spells.eff names them
+// without the _SPB or _SPRITE suffix
+func (wce *Wce) spriteTextureFile(name string) (string, bool) {
+	if wce == nil || name == "" {
+		return "", false
+	}
+	for _, tag := range []string{name, name + "_SPB", name + "_SPRITE"} {
+		var sprite *SimpleSpriteDef
+		switch def := wce.ByTag(tag).(type) {
+		case *BlitSpriteDef:
+			sprite, _ = wce.ByTag(def.SpriteTag).(*SimpleSpriteDef)
+		case *SimpleSpriteDef:
+			sprite = def
+		}
+		if sprite == nil {
+			continue
+		}
+		frames := simpleSpriteFrameFiles(sprite)
+		if len(frames) > 0 {
+			return frames[0], true
+		}
+	}
+	return "", false
+}
+
+// effOldDagNames are the bones a spells.eff sub effect attaches to by dag index
+var effOldDagNames = map[int32]string{
+	1: "head",
+	2: "right hand",
+	3: "left hand",
+	4: "right foot",
+	5: "left foot",
+}
+
+// ConvertEffectOlds converts the spells.eff effects into spellsnew.eff effects of dst, with an
+// emitter made for each sub effect and extra effect that has a sprite, so a sprite used twice gets
+// two emitters. The emitters are added after the emitters dst already has. Source emitters fill the
+// first emitter slots and target emitters the second, sub effects before extra effects. Each sprite
+// is textured by its texture file in sprites, usually the wld of spells.s3d, and sprites may be nil.
+// Notes on fields spellsnew.eff cannot hold and sprites without a texture file are returned
+func (wce *Wce) ConvertEffectOlds(dst *Wce, sprites *Wce) ([]string, error) {
+	if len(wce.EffectOlds) == 0 {
+		return nil, fmt.Errorf("no old effect definitions found")
+	}
+
+	notes := []string{}
+	dst.EffectNews = dst.EffectNews[:0]
+	for index, old := range wce.EffectOlds {
+		name := fmt.Sprintf("spell_effect_%03d", index)
+		e := &EffectNew{folders: []string{"spellsnew"}, TagIndex: index}
+		note := func(format string, a ...interface{}) {
+			notes = append(notes, fmt.Sprintf("effect %d: ", index)+fmt.Sprintf(format, a...))
+		}
+
+		if old.Header != [2]uint32{} {
+			note("header %d %d is not kept", old.Header[0], old.Header[1])
+		}
+		blocks := []struct {
+			prefix string
+			block  *EffectOldBlock
+			slots  *[4]EffectNewEmitterRef
+		}{
+			{"source", &old.Source, &e.FirstEmitters},
+			{"source_to_target", &old.SourceToTarget, nil},
+			{"target", &old.Target, &e.SecondEmitters},
+		}
+		for _, b := range blocks {
+			if b.block.SoundRef != 0 {
+				note("%s sound ref %d is not kept", b.prefix, b.block.SoundRef)
+			}
+			if b.block.EffectMode != 0 {
+				note("%s effect mode %d is not kept", b.prefix, b.block.EffectMode)
+			}
+			unknownDW, unknownF32 := 0, 0
+			for _, v := range b.block.UnknownDW {
+				if v != 0 {
+					unknownDW++
+				}
+			}
+			for _, v := range b.block.UnknownF32 {
+				if v != 0 {
+					unknownF32++
+				}
+			}
+			if unknownDW > 0 || unknownF32 > 0 {
+				note("%s unknown fields are not kept, %d dword%s and %d float%s are set", b.prefix,
+					unknownDW, helper.Pluralize(unknownDW), unknownF32, helper.Pluralize(unknownF32))
+			}
+
+			particles := []*classicParticle{}
+			for i, sub := range b.block.Sub {
+				if sub.PrimarySprite == "" {
+					continue
+				}
+				if sub.SpawnAngle != 0 {
+					note("%s sub %d spawn angle %g is not kept", b.prefix, i, sub.SpawnAngle)
+				}
+				dagName, ok := effOldDagNames[sub.DagIndex]
+				if ok {
+					note("%s sub %d attaches to the %s, emitters follow the whole actor", b.prefix, i, dagName)
+				} else if sub.DagIndex != 0 {
+					note("%s sub %d dag index %d is not kept, emitters follow the whole actor", b.prefix, i, sub.DagIndex)
+				}
+				particles = append(particles, &classicParticle{
+					name:         fmt.Sprintf("%s_%s_sub%d", name, b.prefix, i),
+					sprite:       sub.PrimarySprite,
+					particleType: sub.EffectType,
+					colorBGRA:    sub.ColorBGRA,
+					gravity:      sub.Gravity,
+					spawnNormal:  sub.SpawnNormal,
+					spawnRadius:  sub.SpawnRadius,
+					lifespan:     sub.Lifespan,
+					velocity:     sub.SpawnVelocity,
+					spawnRate:    sub.SpawnRate,
+					spawnScale:   sub.SpawnScale,
+				})
+			}
+			for i, extra := range b.block.ExtraEffect {
+				if extra.Sprite == "" {
+					continue
+				}
+				if extra.AngleRangeA != 0 || extra.AngleRangeB != 0 {
+					note("%s extra %d angle range %d to %d is not kept", b.prefix, i, extra.AngleRangeA, extra.AngleRangeB)
+				}
+				particles = append(particles, &classicParticle{
+					name:         fmt.Sprintf("%s_%s_extra%d", name, b.prefix, i),
+					sprite:       extra.Sprite,
+					particleType: int32(extra.EffectType),
+					colorBGRA:    [4]uint8{extra.ColorBGR[0], extra.ColorBGR[1], extra.ColorBGR[2], 0},
+					spawnRadius:  extra.Radius,
+					spawnScale:   extra.Scale,
+					animSpeed:    extra.AnimSpeedMultiplier,
+				})
+			}
+			if len(particles) == 0 {
+				continue
+			}
+			if b.slots == nil {
+				note("%s has %d sprite%s, spellsnew.eff has no emitters for it", b.prefix, len(particles), helper.Pluralize(len(particles)))
+				continue
+			}
+			if len(particles) > len(b.slots) {
+				dropped := []string{}
+				for _, p := range particles[len(b.slots):] {
+					dropped = append(dropped, p.sprite)
+				}
+				note("%s has %d sprites, only %d emitters fit, dropped %s", b.prefix, len(particles), len(b.slots), strings.Join(dropped, ", "))
+				particles = particles[:len(b.slots)]
+			}
+
+			for i, p := range particles {
+				emitter := classicEmitterDef(p)
+				texture, ok := sprites.spriteTextureFile(p.sprite)
+				if ok {
+					emitter.Texture = texture
+				} else {
+					note("%s sprite %s has no texture file, the emitter texture is the sprite name", b.prefix, p.sprite)
+				}
+				// edd textures are at most 31 bytes
+				if len(emitter.Texture) > 31 {
+					note("%s sprite %s is too long for an emitter texture", b.prefix, emitter.Texture)
+					emitter.Texture = emitter.Texture[:31]
+				}
+				emitter.folders = []string{"spellsnew"}
+				emitter.TagIndex = len(dst.EmitterDefs)
+				dst.EmitterDefs = append(dst.EmitterDefs, emitter)
+				b.slots[i].EmitterID = int32(len(dst.EmitterDefs))
+				e.Name = name
+			}
+		}
+		dst.EffectNews = append(dst.EffectNews, e)
+	}
+	if dst.WorldDef == nil {
+		dst.WorldDef = &WorldDef{folders: []string{"spellsnew"}}
+	}
+	return notes, nil
+}
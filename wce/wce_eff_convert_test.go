@@ -0,0 +1,71 @@
+package wce
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertEffectOlds(t *testing.T) {
+	src := New("spells")
+	src.EffectOlds = append(src.EffectOlds, &EffectOld{}, &EffectOld{TagIndex: 1})
+	old := src.EffectOlds[1]
+	old.Source.SoundRef = 7
+	old.Source.Sub[0] = EffectOldSub{
+		PrimarySprite: "SPELLBLIT01",
+		DagIndex:      2,
+		ColorBGRA:     [4]uint8{10, 20, 30, 0},
+		SpawnNormal:   [3]float32{0, 0, 1},
+		SpawnVelocity: 2,
+		Lifespan:      1500,
+		SpawnRate:     20,
+	}
+	for i := 0; i < 5; i++ {
+		old.Target.ExtraEffect[i].Sprite = "SPELLBLIT02"
+	}
+	old.SourceToTarget.Sub[1].PrimarySprite = "BOLT"
+	old.Target.Sub[2] = EffectOldSub{PrimarySprite: "SPELLBLIT03", DagIndex: 9}
+	old.Target.UnknownDW[4] = 3
+	old.Target.UnknownF32[0] = 0.5
+	old.Target.UnknownF32[11] = 2
+
+	dst := New("spellsnew")
+	dst.EmitterDefs = append(dst.EmitterDefs, &EmitterDef{Name: "existing"})
+	sprites := New("spells")
+	sprites.SimpleSpriteDefs = append(sprites.SimpleSpriteDefs, &SimpleSpriteDef{
+		Tag:                "SPELLBLIT01_SPRITE",
+		SimpleSpriteFrames: []SimpleSpriteFrame{{TextureTag: "SPELLBLIT01", TextureFiles: []string{"SPELLBLIT01.BMP"}}},
+	})
+	sprites.BlitSpriteDefs = append(sprites.BlitSpriteDefs, &BlitSpriteDef{Tag: "SPELLBLIT01_SPB", SpriteTag: "SPELLBLIT01_SPRITE"})
+	notes, err := src.ConvertEffectOlds(dst, sprites)
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	if len(dst.EffectNews) != 2 || dst.EffectNews[0].Name != "" || dst.EffectNews[1].Name != "spell_effect_001" {
+		t.Fatalf("got %d effects, want an empty effect 0 and spell_effect_001", len(dst.EffectNews))
+	}
+	if len(dst.EmitterDefs) != 6 {
+		t.Fatalf("got %d emitters, want 1 existing, 1 source and 4 target", len(dst.EmitterDefs))
+	}
+
+	e := dst.EffectNews[1]
+	emitter := dst.EmitterByID(e.FirstEmitters[0].EmitterID)
+	if emitter == nil || emitter.Texture != "spellblit01.bmp" || e.FirstEmitters[0].EmitterID != 2 {
+		t.Fatalf("source sprite not made the first emitter")
+	}
+	if emitter.TintStart != [3]uint32{30, 20, 10} || emitter.SpeedMax[2] != 2 || emitter.ParticleLifeSpan != 1.5 || emitter.ParticlesAtInterval != 20 {
+		t.Fatalf("source emitter settings not mapped: %+v", emitter)
+	}
+	if e.SecondEmitters[3].EmitterID != 6 || dst.EmitterByID(7) != nil || dst.EmitterByID(0) != nil {
+		t.Fatalf("target emitters not numbered from 1")
+	}
+
+	want := []string{"sound ref 7", "right hand", "source_to_target has 1 sprite", "dropped SPELLBLIT02",
+		"target sub 2 dag index 9 is not kept",
+		"target sprite SPELLBLIT02 has no texture file", "target unknown fields are not kept, 1 dword and 2 floats"}
+	text := strings.Join(notes, "\n")
+	for _, w := range want {
+		if !strings.Contains(text, w) {
+			t.Fatalf("notes %q do not mention %s", text, w)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package wce
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/xackery/quail/raw"
+)
+
+// effCSVField is one column of a spell effect row, with value pointing at the field it edits
+type effCSVField struct {
+	name  string
+	value interface{}
+}
+
+// effOldCSVFields returns the columns of a spells.eff effect
+func effOldCSVFields(e *EffectOld) []effCSVField {
+	fields := []effCSVField{
+		{"header0", &e.Header[0]},
+		{"header1", &e.Header[1]},
+	}
+	blocks := []struct {
+		prefix string
+		block  *EffectOldBlock
+	}{
+		{"source", &e.Source},
+		{"source_to_target", &e.SourceToTarget},
+		{"target", &e.Target},
+	}
+	for _, b := range blocks {
+		p := b.prefix
+		fields = append(fields,
+			effCSVField{p + "_label", &b.block.Label},
+			effCSVField{p + "_effect_mode", &b.block.EffectMode},
+			effCSVField{p + "_sound_ref", &b.block.SoundRef},
+		)
+		for i := range b.block.Sub {
+			sub := &b.block.Sub[i]
+			sp := fmt.Sprintf("%s_sub%d", p, i)
+			fields = append(fields,
+				effCSVField{sp + "_sprite", &sub.PrimarySprite},
+				effCSVField{sp + "_dag_index", &sub.DagIndex},
+				effCSVField{sp + "_effect_type", &sub.EffectType},
+				effCSVField{sp + "_color_b", &sub.ColorBGRA[0]},
+				effCSVField{sp + "_color_g", &sub.ColorBGRA[1]},
+				effCSVField{sp + "_color_r", &sub.ColorBGRA[2]},
+				effCSVField{sp + "_color_a", &sub.ColorBGRA[3]},
+				effCSVField{sp + "_gravity", &sub.Gravity},
+				effCSVField{sp + "_spawn_normal_x", &sub.SpawnNormal[0]},
+				effCSVField{sp + "_spawn_normal_y", &sub.SpawnNormal[1]},
+				effCSVField{sp + "_spawn_normal_z", &sub.SpawnNormal[2]},
+				effCSVField{sp + "_spawn_radius", &sub.SpawnRadius},
+				effCSVField{sp + "_spawn_angle", &sub.SpawnAngle},
+				effCSVField{sp + "_lifespan", &sub.Lifespan},
+				effCSVField{sp + "_spawn_velocity", &sub.SpawnVelocity},
+				effCSVField{sp + "_spawn_rate", &sub.SpawnRate},
+				effCSVField{sp + "_spawn_scale", &sub.SpawnScale},
+			)
+		}
+		for i := range b.block.ExtraEffect {
+			extra := &b.block.ExtraEffect[i]
+			ep := fmt.Sprintf("%s_extra%d", p, i)
+			fields = append(fields,
+				effCSVField{ep + "_sprite", &extra.Sprite},
+				effCSVField{ep + "_color_b", &extra.ColorBGR[0]},
+				effCSVField{ep + "_color_g", &extra.ColorBGR[1]},
+				effCSVField{ep + "_color_r", &extra.ColorBGR[2]},
+				effCSVField{ep + "_anim_speed", &extra.AnimSpeedMultiplier},
+				effCSVField{ep + "_angle_range_a", &extra.AngleRangeA},
+				effCSVField{ep + "_angle_range_b", &extra.AngleRangeB},
+				effCSVField{ep + "_radius", &extra.Radius},
+				effCSVField{ep + "_effect_type", &extra.EffectType},
+				effCSVField{ep + "_scale", &extra.Scale},
+			)
+		}
+		for i := range b.block.UnknownDW {
+			fields = append(fields, effCSVField{fmt.Sprintf("%s_unknown_dw%d", p, i), &b.block.UnknownDW[i]})
+		}
+		for i := range b.block.UnknownF32 {
+			fields = append(fields, effCSVField{fmt.Sprintf("%s_unknown_f%d", p, i), &b.block.UnknownF32[i]})
+		}
+	}
+	return fields
+}
+
+// effNewCSVFields returns the columns of a spellsnew.eff effect
+func effNewCSVFields(e *EffectNew) []effCSVField {
+	fields := []effCSVField{{"name", &e.Name}}
+	emitters := []struct {
+		prefix string
+		refs   *[4]EffectNewEmitterRef
+	}{
+		{"first", &e.FirstEmitters},
+		{"second", &e.SecondEmitters},
+	}
+	for _, emitter := range emitters {
+		for i := range emitter.refs {
+			ref := &emitter.refs[i]
+			p := fmt.Sprintf("%s%d", emitter.prefix, i)
+			fields = append(fields,
+				effCSVField{p + "_emitter_id", &ref.EmitterID},
+				effCSVField{p + "_unknown_a", &ref.UnknownA},
+				effCSVField{p + "_unknown_b", &ref.UnknownB},
+				effCSVField{p + "_unknown_c", &ref.UnknownC},
+			)
+		}
+	}
+	for i := range e.Unknown {
+		fields = append(fields, effCSVField{fmt.Sprintf("unknown%d", i), &e.Unknown[i]})
+	}
+	return fields
+}
+
+// effCSVFormat returns the cell text of a field
+func effCSVFormat(value interface{}) string {
+	switch v := value.(type) {
+	case *string:
+		return *v
+	case *int16:
+		return strconv.FormatInt(int64(*v), 10)
+	case *int32:
+		return strconv.FormatInt(int64(*v), 10)
+	case *uint8:
+		return strconv.FormatUint(uint64(*v), 10)
+	case *uint32:
+		return strconv.FormatUint(uint64(*v), 10)
+	case *float32:
+		return strconv.FormatFloat(float64(*v), 'g', -1, 32)
+	}
+	return ""
+}
+
+// effCSVParse sets a field from cell text, where an empty cell is zero
+func effCSVParse(value interface{}, text string) error {
+	if v, ok := value.(*string); ok {
+		*v = text
+		return nil
+	}
+	if text == "" {
+		text = "0"
+	}
+	switch v := value.(type) {
+	case *int16:
+		n, err := strconv.ParseInt(text, 10, 16)
+		if err != nil {
+			return err
+		}
+		*v = int16(n)
+	case *int32:
+		n, err := strconv.ParseInt(text, 10, 32)
+		if err != nil {
+			return err
+		}
+		*v = int32(n)
+	case *uint8:
+		n, err := strconv.ParseUint(text, 10, 8)
+		if err != nil {
+			return err
+		}
+		*v = uint8(n)
+	case *uint32:
+		n, err := strconv.ParseUint(text, 10, 32)
+		if err != nil {
+			return err
+		}
+		*v = uint32(n)
+	case *float32:
+		n, err := strconv.ParseFloat(text, 32)
+		if err != nil {
+			return err
+		}
+		*v = float32(n)
+	default:
+		return fmt.Errorf("unsupported field type %T", value)
+	}
+	return nil
+}
+
+// WriteEffCSV writes the spell effects as csv with one row per effect index, for editing in a spreadsheet
+func (wce *Wce) WriteEffCSV(w io.Writer) error {
+	if len(wce.EffectOlds) > 0 && len(wce.EffectNews) > 0 {
+		return fmt.Errorf("both old and new effect definitions are present")
+	}
+
+	rows := [][]effCSVField{}
+	for _, e := range wce.EffectOlds {
+		rows = append(rows, effOldCSVFields(e))
+	}
+	for _, e := range wce.EffectNews {
+		rows = append(rows, effNewCSVFields(e))
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("no old or new effect definitions found")
+	}
+
+	cw := csv.NewWriter(w)
+	header := []string{"index"}
+	for _, field := range rows[0] {
+		header = append(header, field.name)
+	}
+	err := cw.Write(header)
+	if err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+	for i, fields := range rows {
+		record := []string{strconv.Itoa(i)}
+		for _, field := range fields {
+			record = append(record, effCSVFormat(field.value))
+		}
+		err = cw.Write(record)
+		if err != nil {
+			return fmt.Errorf("write effect %d: %w", i, err)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadEffCSV replaces the spell effects with the rows of a csv written by WriteEffCSV. Whether the
+// effects are old or new is decided by the columns. Columns left out and empty cells are zero, and
+// effect indexes without a row are left empty
+func (wce *Wce) ReadEffCSV(r io.Reader) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return fmt.Errorf("read csv: %w", err)
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("csv is empty")
+	}
+	header := records[0]
+
+	hasColumns := func(fields []effCSVField) bool {
+		names := map[string]bool{"index": true}
+		for _, field := range fields {
+			names[field.name] = true
+		}
+		for _, name := range header {
+			if !names[name] {
+				return false
+			}
+		}
+		return true
+	}
+	isNew := hasColumns(effNewCSVFields(&EffectNew{}))
+	if !isNew && !hasColumns(effOldCSVFields(&EffectOld{})) {
+		return fmt.Errorf("columns match neither spells.eff nor spellsnew.eff")
+	}
+	indexColumn := -1
+	for i, name := range header {
+		if name == "index" {
+			indexColumn = i
+		}
+	}
+	if indexColumn < 0 {
+		return fmt.Errorf("index column not found")
+	}
+
+	folder := "spells"
+	if isNew {
+		folder = "spellsnew"
+	}
+	olds := map[int]*EffectOld{}
+	news := map[int]*EffectNew{}
+	for i, record := range records[1:] {
+		line := i + 2
+		if len(record) != len(header) {
+			return fmt.Errorf("line %d: has %d columns, wanted %d", line, len(record), len(header))
+		}
+		index, err := strconv.Atoi(record[indexColumn])
+		if err != nil || index < 0 {
+			return fmt.Errorf("line %d: invalid index %q", line, record[indexColumn])
+		}
+		if olds[index] != nil || news[index] != nil {
+			return fmt.Errorf("line %d: index %d is repeated", line, index)
+		}
+
+		var fields []effCSVField
+		if isNew {
+			e := &EffectNew{folders: []string{folder}, TagIndex: index}
+			news[index] = e
+			fields = effNewCSVFields(e)
+		} else {
+			if index >= raw.EffOldRecordCount {
+				return fmt.Errorf("line %d: index %d is past the %d effects of spells.eff", line, index, raw.EffOldRecordCount)
+			}
+			e := &EffectOld{folders: []string{folder}, TagIndex: index}
+			olds[index] = e
+			fields = effOldCSVFields(e)
+		}
+		values := map[string]interface{}{}
+		for _, field := range fields {
+			values[field.name] = field.value
+		}
+		for j, name := range header {
+			if j == indexColumn {
+				continue
+			}
+			err = effCSVParse(values[name], record[j])
+			if err != nil {
+				return fmt.Errorf("line %d: %s: %w", line, name, err)
+			}
+		}
+	}
+
+	wce.reset()
+	if wce.WorldDef == nil {
+		wce.WorldDef = &WorldDef{folders: []string{folder}}
+	}
+	if isNew {
+		indexes := make([]int, 0, len(news))
+		for index := range news {
+			indexes = append(indexes, index)
+		}
+		sort.Ints(indexes)
+		count := 0
+		if len(indexes) > 0 {
+			count = indexes[len(indexes)-1] + 1
+		}
+		for i := 0; i < count; i++ {
+			e, ok := news[i]
+			if !ok {
+				e = &EffectNew{folders: []string{folder}, TagIndex: i}
+			}
+			wce.EffectNews = append(wce.EffectNews, e)
+		}
+		return nil
+	}
+	// spells.eff always holds every effect index
+	for i := 0; i < raw.EffOldRecordCount; i++ {
+		e, ok := olds[i]
+		if !ok {
+			e = &EffectOld{folders: []string{folder}, TagIndex: i}
+		}
+		wce.EffectOlds = append(wce.EffectOlds, e)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package wce
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestEffCSV(t *testing.T) {
+	src := New("spellsnew")
+	for i := 0; i < 2; i++ {
+		e := &EffectNew{TagIndex: i, Name: "effect, quoted"}
+		e.FirstEmitters[0].EmitterID = int32(100 + i)
+		e.SecondEmitters[3].UnknownC = -7
+		e.Unknown[18] = 42
+		src.EffectNews = append(src.EffectNews, e)
+	}
+	buf := &bytes.Buffer{}
+	err := src.WriteEffCSV(buf)
+	if err != nil {
+		t.Fatalf("write new: %v", err)
+	}
+
+	dst := New("spellsnew")
+	err = dst.ReadEffCSV(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("read new: %v", err)
+	}
+	if len(dst.EffectNews) != 2 || len(dst.EffectOlds) != 0 {
+		t.Fatalf("got %d new and %d old effects, want 2 new", len(dst.EffectNews), len(dst.EffectOlds))
+	}
+	e := dst.EffectNews[1]
+	if e.Name != "effect, quoted" || e.FirstEmitters[0].EmitterID != 101 || e.SecondEmitters[3].UnknownC != -7 || e.Unknown[18] != 42 {
+		t.Fatalf("new effect did not round trip: %+v", e)
+	}
+
+	// a sparse, partial sheet leaves the rest of spells.eff empty
+	sheet := "index,source_sound_ref,target_sub2_spawn_normal_z,target_extra11_sprite\n5,12,0.25,SPELLBLIT\n"
+	old := New("spells")
+	err = old.ReadEffCSV(strings.NewReader(sheet))
+	if err != nil {
+		t.Fatalf("read old: %v", err)
+	}
+	if len(old.EffectOlds) != 256 {
+		t.Fatalf("got %d old effects, want 256", len(old.EffectOlds))
+	}
+	o := old.EffectOlds[5]
+	if o.Source.SoundRef != 12 || o.Target.Sub[2].SpawnNormal[2] != 0.25 || o.Target.ExtraEffect[11].Sprite != "SPELLBLIT" {
+		t.Fatalf("old effect fields not set")
+	}
+	buf.Reset()
+	err = old.WriteEffCSV(buf)
+	if err != nil {
+		t.Fatalf("write old: %v", err)
+	}
+	if !strings.Contains(buf.String(), "\n5,0,0,,0,12,") {
+		t.Fatalf("old effect 5 row not written")
+	}
+
+	err = old.ReadEffCSV(strings.NewReader("index,flavor\n0,1\n"))
+	if err == nil {
+		t.Fatalf("unknown column was accepted")
+	}
+	err = old.ReadEffCSV(strings.NewReader("index,name\n0,a\n0,b\n"))
+	if err == nil {
+		t.Fatalf("repeated index was accepted")
+	}
+}
+
+func TestEffOldCSVRoundTrip(t *testing.T) {
+	src := New("spells")
+	for i := 0; i < 256; i++ {
+		src.EffectOlds = append(src.EffectOlds, &EffectOld{folders: []string{"spells"}, TagIndex: i})
+	}
+	// every column of effect 7 gets its own value
+	for i, field := range effOldCSVFields(src.EffectOlds[7]) {
+		text := fmt.Sprintf("%d", i%100+1)
+		switch field.value.(type) {
+		case *string:
+			text = fmt.Sprintf("SPRITE%d", i)
+		case *float32:
+			text = fmt.Sprintf("%d.5", i)
+		}
+		err := effCSVParse(field.value, text)
+		if err != nil {
+			t.Fatalf("set %s: %v", field.name, err)
+		}
+	}
+	if src.EffectOlds[7].Target.UnknownDW[50] == 0 || src.EffectOlds[7].Target.UnknownF32[11] == 0 {
+		t.Fatalf("unknown fields have no columns")
+	}
+
+	buf := &bytes.Buffer{}
+	err := src.WriteEffCSV(buf)
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	dst := New("spells")
+	err = dst.ReadEffCSV(buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if diff := deep.Equal(src.EffectOlds, dst.EffectOlds); diff != nil {
+		t.Fatalf("effects did not round trip: %v", diff)
+	}
+}
@@ -0,0 +1,97 @@
+package wce
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// EffValidation reports broken references between spellsnew.eff effects, emitters and textures
+type EffValidation struct {
+	Effects         int
+	Emitters        int
+	UnknownEmitters []*EffEmitterRef
+	UnusedEmitters  []*EffEmitter
+	MissingTextures []*EffEmitter
+}
+
+// EffEmitterRef is an emitter slot of an effect, named like first0 or second3
+type EffEmitterRef struct {
+	Effect    int
+	Slot      string
+	EmitterID int32
+}
+
+// EffEmitter is an emitter by its EmitterID
+type EffEmitter struct {
+	EmitterID int32
+	Name      string
+	Texture   string
+}
+
+// IsValid returns true when no effect refers to an unknown emitter and no emitter lacks its texture
+func (e *EffValidation) IsValid() bool {
+	return len(e.UnknownEmitters) == 0 && len(e.MissingTextures) == 0
+}
+
+// effTextureKey returns the name a texture is matched by, ignoring case and extension
+func effTextureKey(name string) string {
+	name = strings.ToLower(name)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// EffValidate checks the emitter slots of the spellsnew.eff effects against the emitters, numbered
+// as in EmitterByID, and the emitter textures against textures. Textures match by name without
+// case or extension, and a nil textures skips the texture check
+func (wce *Wce) EffValidate(textures []string) (*EffValidation, error) {
+	if len(wce.EffectNews) == 0 {
+		return nil, fmt.Errorf("no new effect definitions found")
+	}
+
+	result := &EffValidation{Effects: len(wce.EffectNews), Emitters: len(wce.EmitterDefs)}
+	used := map[int32]bool{}
+	for index, e := range wce.EffectNews {
+		slots := []struct {
+			prefix string
+			refs   [4]EffectNewEmitterRef
+		}{
+			{"first", e.FirstEmitters},
+			{"second", e.SecondEmitters},
+		}
+		for _, slot := range slots {
+			for i, ref := range slot.refs {
+				if ref.EmitterID == 0 {
+					continue
+				}
+				if wce.EmitterByID(ref.EmitterID) == nil {
+					result.UnknownEmitters = append(result.UnknownEmitters, &EffEmitterRef{
+						Effect:    index,
+						Slot:      fmt.Sprintf("%s%d", slot.prefix, i),
+						EmitterID: ref.EmitterID,
+					})
+					continue
+				}
+				used[ref.EmitterID] = true
+			}
+		}
+	}
+
+	var textureKeys map[string]bool
+	if textures != nil {
+		textureKeys = map[string]bool{}
+		for _, texture := range textures {
+			textureKeys[effTextureKey(texture)] = true
+		}
+	}
+	for i, emitter := range wce.EmitterDefs {
+		id := int32(i + 1)
+		ref := &EffEmitter{EmitterID: id, Name: emitter.Name, Texture: emitter.Texture}
+		if !used[id] {
+			result.UnusedEmitters = append(result.UnusedEmitters, ref)
+		}
+		if textureKeys != nil && !textureKeys[effTextureKey(emitter.Texture)] {
+			result.MissingTextures = append(result.MissingTextures, ref)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,39 @@
+package wce
+
+import (
+	"testing"
+)
+
+func TestEffValidate(t *testing.T) {
+	w := New("spellsnew")
+	e := &EffectNew{Name: "fire"}
+	e.FirstEmitters[0].EmitterID = 1
+	e.SecondEmitters[2].EmitterID = 9
+	w.EffectNews = append(w.EffectNews, e)
+	w.EmitterDefs = append(w.EmitterDefs,
+		&EmitterDef{Name: "flame", Texture: "Flame01.dds"},
+		&EmitterDef{Name: "smoke", Texture: "SPELLBLIT02"},
+	)
+
+	result, err := w.EffValidate(nil)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if len(result.UnknownEmitters) != 1 || result.UnknownEmitters[0].Slot != "second2" || result.UnknownEmitters[0].EmitterID != 9 {
+		t.Fatalf("unknown emitter 9 of second2 not reported")
+	}
+	if len(result.UnusedEmitters) != 1 || result.UnusedEmitters[0].Name != "smoke" {
+		t.Fatalf("unused emitter smoke not reported")
+	}
+	if len(result.MissingTextures) != 0 {
+		t.Fatalf("textures checked without textures")
+	}
+
+	result, err = w.EffValidate([]string{"flame01.DDS", "spellblit01"})
+	if err != nil {
+		t.Fatalf("validate textures: %v", err)
+	}
+	if len(result.MissingTextures) != 1 || result.MissingTextures[0].Texture != "SPELLBLIT02" || result.IsValid() {
+		t.Fatalf("missing texture SPELLBLIT02 not reported")
+	}
+}
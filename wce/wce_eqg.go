@@ -2722,6 +2722,619 @@ func (e *EqgLodDef) FromRaw(wce *Wce, src *raw.Lod) error {
 	return nil
 }
 
+// EqgEcoDef represents an eqg .eco ecology file
+type EqgEcoDef struct {
+	folders           []string
+	Tag               string
+	IsCRLF            int
+	IsTrailingNewline int
+	Entries           []*EcoEntry
+}
+
+type EcoEntry struct {
+	Type       string
+	Begin      string
+	End        string
+	Properties []*EcoProperty
+}
+
+type EcoProperty struct {
+	Key   string
+	Value string
+	Line  string
+}
+
+// quoteEscape writes quotes as \q and backslashes as \\, since the ascii reader splits on quotes
+func quoteEscape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\q`).Replace(value)
+}
+
+// quoteUnescape reverses quoteEscape
+func quoteUnescape(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+	out := strings.Builder{}
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 == len(value) {
+			out.WriteByte(value[i])
+			continue
+		}
+		i++
+		switch value[i] {
+		case 'q':
+			out.WriteByte('"')
+		default:
+			out.WriteByte(value[i])
+		}
+	}
+	return out.String()
+}
+
+func (e *EqgEcoDef) Definition() string {
+	return "EQGECODEF"
+}
+
+func (e *EqgEcoDef) Write(token *AsciiWriteToken) error {
+	for _, folder := range e.folders {
+		err := token.SetWriter(folder)
+		if err != nil {
+			return err
+		}
+		w, err := token.Writer()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "%s \"%s\"\n", e.Definition(), e.Tag)
+		fmt.Fprintf(w, "\tCRLF %d\n", e.IsCRLF)
+		fmt.Fprintf(w, "\tTRAILINGNEWLINE %d\n", e.IsTrailingNewline)
+		fmt.Fprintf(w, "\tNUMENTRIES %d\n", len(e.Entries))
+		for i, entry := range e.Entries {
+			fmt.Fprintf(w, "\t\tENTRY // %d\n", i)
+			fmt.Fprintf(w, "\t\t\tTYPE \"%s\"\n", entry.Type)
+			fmt.Fprintf(w, "\t\t\tSOURCE \"%s\" \"%s\"\n", quoteEscape(entry.Begin), quoteEscape(entry.End))
+			fmt.Fprintf(w, "\t\t\tNUMPROPERTIES %d\n", len(entry.Properties))
+			for _, property := range entry.Properties {
+				fmt.Fprintf(w, "\t\t\t\tPROPERTY \"%s\" \"%s\" \"%s\"\n", quoteEscape(property.Key), quoteEscape(property.Value), quoteEscape(property.Line))
+			}
+		}
+		fmt.Fprintf(w, "\n")
+
+		token.TagSetIsWritten(e.Tag)
+	}
+	return nil
+}
+
+func (e *EqgEcoDef) Read(token *AsciiReadToken) error {
+	records, err := token.ReadProperty("CRLF", 1)
+	if err != nil {
+		return err
+	}
+	err = parse(&e.IsCRLF, records[1])
+	if err != nil {
+		return fmt.Errorf("crlf: %w", err)
+	}
+
+	records, err = token.ReadProperty("TRAILINGNEWLINE", 1)
+	if err != nil {
+		return err
+	}
+	err = parse(&e.IsTrailingNewline, records[1])
+	if err != nil {
+		return fmt.Errorf("trailing newline: %w", err)
+	}
+
+	records, err = token.ReadProperty("NUMENTRIES", 1)
+	if err != nil {
+		return err
+	}
+	numEntries := 0
+	err = parse(&numEntries, records[1])
+	if err != nil {
+		return fmt.Errorf("num entries: %w", err)
+	}
+
+	for i := 0; i < numEntries; i++ {
+		entry := &EcoEntry{}
+		_, err = token.ReadProperty("ENTRY", 0)
+		if err != nil {
+			return fmt.Errorf("entry %d: %w", i, err)
+		}
+
+		records, err = token.ReadProperty("TYPE", 1)
+		if err != nil {
+			return fmt.Errorf("entry %d type: %w", i, err)
+		}
+		entry.Type = records[1]
+
+		records, err = token.ReadProperty("SOURCE", 2)
+		if err != nil {
+			return fmt.Errorf("entry %d source: %w", i, err)
+		}
+		entry.Begin = quoteUnescape(records[1])
+		entry.End = quoteUnescape(records[2])
+
+		records, err = token.ReadProperty("NUMPROPERTIES", 1)
+		if err != nil {
+			return fmt.Errorf("entry %d: %w", i, err)
+		}
+		numProperties := 0
+		err = parse(&numProperties, records[1])
+		if err != nil {
+			return fmt.Errorf("entry %d num properties: %w", i, err)
+		}
+
+		for j := 0; j < numProperties; j++ {
+			records, err = token.ReadProperty("PROPERTY", 3)
+			if err != nil {
+				return fmt.Errorf("entry %d property %d: %w", i, j, err)
+			}
+			entry.Properties = append(entry.Properties, &EcoProperty{Key: quoteUnescape(records[1]), Value: quoteUnescape(records[2]), Line: quoteUnescape(records[3])})
+		}
+		e.Entries = append(e.Entries, entry)
+	}
+
+	return nil
+}
+
+func (e *EqgEcoDef) ToRaw(wce *Wce, dst *raw.Eco) error {
+	dst.MetaFileName = e.Tag
+	dst.IsCRLF = e.IsCRLF == 1
+	dst.IsTrailingNewline = e.IsTrailingNewline == 1
+	for _, entry := range e.Entries {
+		ecoEntry := &raw.EcoEntry{Type: entry.Type, Begin: entry.Begin, End: entry.End}
+		for _, property := range entry.Properties {
+			ecoEntry.Properties = append(ecoEntry.Properties, &raw.EcoProperty{Key: property.Key, Value: property.Value, Line: property.Line})
+		}
+		dst.Entries = append(dst.Entries, ecoEntry)
+	}
+
+	return nil
+}
+
+func (e *EqgEcoDef) FromRaw(wce *Wce, src *raw.Eco) error {
+	folder := strings.TrimSuffix(strings.ToLower(wce.FileName), ".eqg")
+	e.folders = append(e.folders, folder)
+	e.Tag = src.MetaFileName
+	if src.IsCRLF {
+		e.IsCRLF = 1
+	}
+	if src.IsTrailingNewline {
+		e.IsTrailingNewline = 1
+	}
+	for _, entry := range src.Entries {
+		ecoEntry := &EcoEntry{Type: entry.Type, Begin: entry.Begin, End: entry.End}
+		for _, property := range entry.Properties {
+			ecoEntry.Properties = append(ecoEntry.Properties, &EcoProperty{Key: property.Key, Value: property.Value, Line: property.Line})
+		}
+		e.Entries = append(e.Entries, ecoEntry)
+	}
+
+	return nil
+}
+
+// EqgRfdDef represents an eqg .rfd radial flora file
+type EqgRfdDef struct {
+	folders []string
+	Tag     string
+	Version uint32
+	Data    string // base64 of a file that did not parse, written back as is
+	Entries []*RfdEntry
+}
+
+type RfdEntry struct {
+	Flora    string
+	Position [3]float32
+	Radius   float32
+	Density  float32
+	ScaleMin float32
+	ScaleMax float32
+	Seed     uint32
+}
+
+func (e *EqgRfdDef) Definition() string {
+	return "EQGRFDDEF"
+}
+
+func (e *EqgRfdDef) Write(token *AsciiWriteToken) error {
+	for _, folder := range e.folders {
+		err := token.SetWriter(folder)
+		if err != nil {
+			return err
+		}
+		w, err := token.Writer()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "%s \"%s\"\n", e.Definition(), e.Tag)
+		fmt.Fprintf(w, "\tVERSION %d\n", e.Version)
+		fmt.Fprintf(w, "\tDATA \"%s\"\n", e.Data)
+		fmt.Fprintf(w, "\tNUMENTRIES %d\n", len(e.Entries))
+		for i, entry := range e.Entries {
+			fmt.Fprintf(w, "\t\tENTRY // %d\n", i)
+			fmt.Fprintf(w, "\t\t\tFLORA \"%s\"\n", entry.Flora)
+			fmt.Fprintf(w, "\t\t\tPOSITION %0.8e %0.8e %0.8e\n", entry.Position[0], entry.Position[1], entry.Position[2])
+			fmt.Fprintf(w, "\t\t\tRADIUS %0.8e\n", entry.Radius)
+			fmt.Fprintf(w, "\t\t\tDENSITY %0.8e\n", entry.Density)
+			fmt.Fprintf(w, "\t\t\tSCALE %0.8e %0.8e\n", entry.ScaleMin, entry.ScaleMax)
+			fmt.Fprintf(w, "\t\t\tSEED %d\n", entry.Seed)
+		}
+		fmt.Fprintf(w, "\n")
+
+		token.TagSetIsWritten(e.Tag)
+	}
+	return nil
+}
+
+func (e *EqgRfdDef) Read(token *AsciiReadToken) error {
+	records, err := token.ReadProperty("VERSION", 1)
+	if err != nil {
+		return err
+	}
+	err = parse(&e.Version, records[1])
+	if err != nil {
+		return fmt.Errorf("version: %w", err)
+	}
+
+	records, err = token.ReadProperty("DATA", 1)
+	if err != nil {
+		return err
+	}
+	e.Data = records[1]
+
+	records, err = token.ReadProperty("NUMENTRIES", 1)
+	if err != nil {
+		return err
+	}
+	numEntries := 0
+	err = parse(&numEntries, records[1])
+	if err != nil {
+		return fmt.Errorf("num entries: %w", err)
+	}
+
+	for i := 0; i < numEntries; i++ {
+		entry := &RfdEntry{}
+		_, err = token.ReadProperty("ENTRY", 0)
+		if err != nil {
+			return fmt.Errorf("entry %d: %w", i, err)
+		}
+
+		records, err = token.ReadProperty("FLORA", 1)
+		if err != nil {
+			return fmt.Errorf("entry %d flora: %w", i, err)
+		}
+		entry.Flora = records[1]
+
+		records, err = token.ReadProperty("POSITION", 3)
+		if err != nil {
+			return fmt.Errorf("entry %d position: %w", i, err)
+		}
+		err = parse(&entry.Position, records[1:]...)
+		if err != nil {
+			return fmt.Errorf("entry %d position: %w", i, err)
+		}
+
+		records, err = token.ReadProperty("RADIUS", 1)
+		if err != nil {
+			return fmt.Errorf("entry %d radius: %w", i, err)
+		}
+		err = parse(&entry.Radius, records[1])
+		if err != nil {
+			return fmt.Errorf("entry %d radius: %w", i, err)
+		}
+
+		records, err = token.ReadProperty("DENSITY", 1)
+		if err != nil {
+			return fmt.Errorf("entry %d density: %w", i, err)
+		}
+		err = parse(&entry.Density, records[1])
+		if err != nil {
+			return fmt.Errorf("entry %d density: %w", i, err)
+		}
+
+		records, err = token.ReadProperty("SCALE", 2)
+		if err != nil {
+			return fmt.Errorf("entry %d scale: %w", i, err)
+		}
+		err = parse(&entry.ScaleMin, records[1])
+		if err != nil {
+			return fmt.Errorf("entry %d scale min: %w", i, err)
+		}
+		err = parse(&entry.ScaleMax, records[2])
+		if err != nil {
+			return fmt.Errorf("entry %d scale max: %w", i, err)
+		}
+
+		records, err = token.ReadProperty("SEED", 1)
+		if err != nil {
+			return fmt.Errorf("entry %d seed: %w", i, err)
+		}
+		err = parse(&entry.Seed, records[1])
+		if err != nil {
+			return fmt.Errorf("entry %d seed: %w", i, err)
+		}
+
+		e.Entries = append(e.Entries, entry)
+	}
+
+	return nil
+}
+
+func (e *EqgRfdDef) ToRaw(wce *Wce, dst *raw.Rfd) error {
+	dst.MetaFileName = e.Tag
+	dst.Version = e.Version
+	dst.Data = e.Data
+	for _, entry := range e.Entries {
+		dst.Entries = append(dst.Entries, &raw.RfdEntry{
+			Flora:    entry.Flora,
+			Position: entry.Position,
+			Radius:   entry.Radius,
+			Density:  entry.Density,
+			ScaleMin: entry.ScaleMin,
+			ScaleMax: entry.ScaleMax,
+			Seed:     entry.Seed,
+		})
+	}
+
+	return nil
+}
+
+func (e *EqgRfdDef) FromRaw(wce *Wce, src *raw.Rfd) error {
+	folder := strings.TrimSuffix(strings.ToLower(wce.FileName), ".eqg")
+	e.folders = append(e.folders, folder)
+	e.Tag = src.MetaFileName
+	e.Version = src.Version
+	e.Data = src.Data
+	for _, entry := range src.Entries {
+		e.Entries = append(e.Entries, &RfdEntry{
+			Flora:    entry.Flora,
+			Position: entry.Position,
+			Radius:   entry.Radius,
+			Density:  entry.Density,
+			ScaleMin: entry.ScaleMin,
+			ScaleMax: entry.ScaleMax,
+			Seed:     entry.Seed,
+		})
+	}
+
+	return nil
+}
+
+// EqgTogDef represents an eqg .tog toggled object group
+type EqgTogDef struct {
+	folders           []string
+	Tag               string
+	IsCRLF            int
+	IsTrailingNewline int
+	Entries           []*TogEntry
+	Lines             []string
+}
+
+type TogEntry struct {
+	Name     string
+	Position [3]float32
+	Rotation [3]float32
+	Scale    float32
+	FileType string
+	FileName string
+	Before   []string
+	Lines    []string
+}
+
+func (e *EqgTogDef) Definition() string {
+	return "EQGTOGDEF"
+}
+
+func (e *EqgTogDef) Write(token *AsciiWriteToken) error {
+	for _, folder := range e.folders {
+		err := token.SetWriter(folder)
+		if err != nil {
+			return err
+		}
+		w, err := token.Writer()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "%s \"%s\"\n", e.Definition(), e.Tag)
+		fmt.Fprintf(w, "\tCRLF %d\n", e.IsCRLF)
+		fmt.Fprintf(w, "\tTRAILINGNEWLINE %d\n", e.IsTrailingNewline)
+		fmt.Fprintf(w, "\tNUMOBJECTS %d\n", len(e.Entries))
+		for i, entry := range e.Entries {
+			fmt.Fprintf(w, "\t\tOBJECT // %d\n", i)
+			fmt.Fprintf(w, "\t\t\tNAME \"%s\"\n", entry.Name)
+			fmt.Fprintf(w, "\t\t\tPOSITION %0.8e %0.8e %0.8e\n", entry.Position[0], entry.Position[1], entry.Position[2])
+			fmt.Fprintf(w, "\t\t\tROTATION %0.8e %0.8e %0.8e\n", entry.Rotation[0], entry.Rotation[1], entry.Rotation[2])
+			fmt.Fprintf(w, "\t\t\tSCALE %0.8e\n", entry.Scale)
+			fmt.Fprintf(w, "\t\t\tFILE \"%s\" \"%s\"\n", entry.FileType, entry.FileName)
+			fmt.Fprintf(w, "\t\t\tNUMBEFORE %d\n", len(entry.Before))
+			for _, line := range entry.Before {
+				fmt.Fprintf(w, "\t\t\t\tBEFORE \"%s\"\n", quoteEscape(line))
+			}
+			fmt.Fprintf(w, "\t\t\tNUMLINES %d\n", len(entry.Lines))
+			for _, line := range entry.Lines {
+				fmt.Fprintf(w, "\t\t\t\tLINE \"%s\"\n", quoteEscape(line))
+			}
+		}
+		fmt.Fprintf(w, "\tNUMAFTER %d\n", len(e.Lines))
+		for _, line := range e.Lines {
+			fmt.Fprintf(w, "\t\tAFTER \"%s\"\n", quoteEscape(line))
+		}
+		fmt.Fprintf(w, "\n")
+
+		token.TagSetIsWritten(e.Tag)
+	}
+	return nil
+}
+
+func (e *EqgTogDef) Read(token *AsciiReadToken) error {
+	records, err := token.ReadProperty("CRLF", 1)
+	if err != nil {
+		return err
+	}
+	err = parse(&e.IsCRLF, records[1])
+	if err != nil {
+		return fmt.Errorf("crlf: %w", err)
+	}
+
+	records, err = token.ReadProperty("TRAILINGNEWLINE", 1)
+	if err != nil {
+		return err
+	}
+	err = parse(&e.IsTrailingNewline, records[1])
+	if err != nil {
+		return fmt.Errorf("trailing newline: %w", err)
+	}
+
+	records, err = token.ReadProperty("NUMOBJECTS", 1)
+	if err != nil {
+		return err
+	}
+	numEntries := 0
+	err = parse(&numEntries, records[1])
+	if err != nil {
+		return fmt.Errorf("num objects: %w", err)
+	}
+
+	for i := 0; i < numEntries; i++ {
+		entry := &TogEntry{}
+		_, err = token.ReadProperty("OBJECT", 0)
+		if err != nil {
+			return fmt.Errorf("object %d: %w", i, err)
+		}
+
+		records, err = token.ReadProperty("NAME", 1)
+		if err != nil {
+			return fmt.Errorf("object %d name: %w", i, err)
+		}
+		entry.Name = records[1]
+
+		records, err = token.ReadProperty("POSITION", 3)
+		if err != nil {
+			return fmt.Errorf("object %d position: %w", i, err)
+		}
+		err = parse(&entry.Position, records[1:]...)
+		if err != nil {
+			return fmt.Errorf("object %d position: %w", i, err)
+		}
+
+		records, err = token.ReadProperty("ROTATION", 3)
+		if err != nil {
+			return fmt.Errorf("object %d rotation: %w", i, err)
+		}
+		err = parse(&entry.Rotation, records[1:]...)
+		if err != nil {
+			return fmt.Errorf("object %d rotation: %w", i, err)
+		}
+
+		records, err = token.ReadProperty("SCALE", 1)
+		if err != nil {
+			return fmt.Errorf("object %d scale: %w", i, err)
+		}
+		err = parse(&entry.Scale, records[1])
+		if err != nil {
+			return fmt.Errorf("object %d scale: %w", i, err)
+		}
+
+		records, err = token.ReadProperty("FILE", 2)
+		if err != nil {
+			return fmt.Errorf("object %d file: %w", i, err)
+		}
+		entry.FileType = records[1]
+		entry.FileName = records[2]
+
+		entry.Before, err = readTogLines(token, "NUMBEFORE", "BEFORE")
+		if err != nil {
+			return fmt.Errorf("object %d: %w", i, err)
+		}
+		entry.Lines, err = readTogLines(token, "NUMLINES", "LINE")
+		if err != nil {
+			return fmt.Errorf("object %d: %w", i, err)
+		}
+
+		e.Entries = append(e.Entries, entry)
+	}
+
+	e.Lines, err = readTogLines(token, "NUMAFTER", "AFTER")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// readTogLines reads a count property followed by that many quoted source lines
+func readTogLines(token *AsciiReadToken, countName string, lineName string) ([]string, error) {
+	records, err := token.ReadProperty(countName, 1)
+	if err != nil {
+		return nil, err
+	}
+	numLines := 0
+	err = parse(&numLines, records[1])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", strings.ToLower(countName), err)
+	}
+	lines := []string{}
+	for i := 0; i < numLines; i++ {
+		records, err = token.ReadProperty(lineName, 1)
+		if err != nil {
+			return nil, fmt.Errorf("%s %d: %w", strings.ToLower(lineName), i, err)
+		}
+		lines = append(lines, quoteUnescape(records[1]))
+	}
+	return lines, nil
+}
+
+func (e *EqgTogDef) ToRaw(wce *Wce, dst *raw.Tog) error {
+	dst.MetaFileName = e.Tag
+	dst.IsCRLF = e.IsCRLF == 1
+	dst.IsTrailingNewline = e.IsTrailingNewline == 1
+	for _, entry := range e.Entries {
+		dst.Entries = append(dst.Entries, &raw.TogEntry{
+			Name:     entry.Name,
+			Position: entry.Position,
+			Rotation: entry.Rotation,
+			Scale:    entry.Scale,
+			FileType: entry.FileType,
+			FileName: entry.FileName,
+			Before:   entry.Before,
+			Lines:    entry.Lines,
+		})
+	}
+	dst.Lines = e.Lines
+
+	return nil
+}
+
+func (e *EqgTogDef) FromRaw(wce *Wce, src *raw.Tog) error {
+	folder := strings.TrimSuffix(strings.ToLower(wce.FileName), ".eqg")
+	e.folders = append(e.folders, folder)
+	e.Tag = src.MetaFileName
+	if src.IsCRLF {
+		e.IsCRLF = 1
+	}
+	if src.IsTrailingNewline {
+		e.IsTrailingNewline = 1
+	}
+	for _, entry := range src.Entries {
+		e.Entries = append(e.Entries, &TogEntry{
+			Name:     entry.Name,
+			Position: entry.Position,
+			Rotation: entry.Rotation,
+			Scale:    entry.Scale,
+			FileType: entry.FileType,
+			FileName: entry.FileName,
+			Before:   entry.Before,
+			Lines:    entry.Lines,
+		})
+	}
+	e.Lines = src.Lines
+
+	return nil
+}
+
 // EqgZonDef is an entry
 type EqgZonDef struct {
 	folders   []string
@@ -168,6 +168,48 @@ func (wce *Wce) readEqgEntry(entry *pfs.FileEntry) error {
 			return fmt.Errorf("lod: %w", err)
 		}
 		wce.LodDefs = append(wce.LodDefs, def)
+	case ".eco":
+		rawSrc := &raw.Eco{
+			MetaFileName: strings.TrimSuffix(entry.Name(), ".eco"),
+		}
+		err = rawSrc.Read(bytes.NewReader(entry.Data()))
+		if err != nil {
+			return err
+		}
+		def := &EqgEcoDef{}
+		err := def.FromRaw(wce, rawSrc)
+		if err != nil {
+			return fmt.Errorf("eco: %w", err)
+		}
+		wce.EcoDefs = append(wce.EcoDefs, def)
+	case ".rfd":
+		rawSrc := &raw.Rfd{
+			MetaFileName: strings.TrimSuffix(entry.Name(), ".rfd"),
+		}
+		err = rawSrc.Read(bytes.NewReader(entry.Data()))
+		if err != nil {
+			return err
+		}
+		def := &EqgRfdDef{}
+		err := def.FromRaw(wce, rawSrc)
+		if err != nil {
+			return fmt.Errorf("rfd: %w", err)
+		}
+		wce.RfdDefs = append(wce.RfdDefs, def)
+	case ".tog":
+		rawSrc := &raw.Tog{
+			MetaFileName: strings.TrimSuffix(entry.Name(), ".tog"),
+		}
+		err = rawSrc.Read(bytes.NewReader(entry.Data()))
+		if err != nil {
+			return err
+		}
+		def := &EqgTogDef{}
+		err := def.FromRaw(wce, rawSrc)
+		if err != nil {
+			return fmt.Errorf("tog: %w", err)
+		}
+		wce.TogDefs = append(wce.TogDefs, def)
 	case ".lay":
 		rawSrc := &raw.Lay{
 			MetaFileName: strings.TrimSuffix(entry.Name(), ".lay"),
@@ -240,12 +282,15 @@ func (wce *Wce) WriteEqgRaw(archive *pfs.Pfs) error {
 	if archive == nil {
 		return fmt.Errorf("archive is nil")
 	}
-	var err error
-
-	err = wce.convertWldToEQG()
+	eqg, _, err := wce.ConvertWldToEQG()
 	if err != nil {
 		return fmt.Errorf("convert wld to eqg: %w", err)
 	}
+	return eqg.writeEqgRaw(archive)
+}
+
+func (wce *Wce) writeEqgRaw(archive *pfs.Pfs) error {
+	var err error
 
 	for _, mds := range wce.MdsDefs {
 		buf := &bytes.Buffer{}
@@ -468,6 +513,69 @@ func (wce *Wce) WriteEqgRaw(archive *pfs.Pfs) error {
 		}
 	}
 
+	for _, eco := range wce.EcoDefs {
+		buf := &bytes.Buffer{}
+		dst := &raw.Eco{
+			MetaFileName: eco.Tag,
+		}
+
+		err = eco.ToRaw(wce, dst)
+		if err != nil {
+			return fmt.Errorf("eco to raw: %w", err)
+		}
+
+		err := dst.Write(buf)
+		if err != nil {
+			return fmt.Errorf("eco write: %w", err)
+		}
+		err = archive.Add(eco.Tag+".eco", buf.Bytes())
+		if err != nil {
+			return fmt.Errorf("add eco: %w", err)
+		}
+	}
+
+	for _, rfd := range wce.RfdDefs {
+		buf := &bytes.Buffer{}
+		dst := &raw.Rfd{
+			MetaFileName: rfd.Tag,
+		}
+
+		err = rfd.ToRaw(wce, dst)
+		if err != nil {
+			return fmt.Errorf("rfd to raw: %w", err)
+		}
+
+		err := dst.Write(buf)
+		if err != nil {
+			return fmt.Errorf("rfd write: %w", err)
+		}
+		err = archive.Add(rfd.Tag+".rfd", buf.Bytes())
+		if err != nil {
+			return fmt.Errorf("add rfd: %w", err)
+		}
+	}
+
+	for _, tog := range wce.TogDefs {
+		buf := &bytes.Buffer{}
+		dst := &raw.Tog{
+			MetaFileName: tog.Tag,
+		}
+
+		err = tog.ToRaw(wce, dst)
+		if err != nil {
+			return fmt.Errorf("tog to raw: %w", err)
+		}
+
+		err := dst.Write(buf)
+		if err != nil {
+			return fmt.Errorf("tog write: %w", err)
+		}
+		err = archive.Add(tog.Tag+".tog", buf.Bytes())
+		if err != nil {
+			return fmt.Errorf("add tog: %w", err)
+		}
+	}
+
 	for _, lit := range wce.Lits {
 		buf := &bytes.Buffer{}
 		dst := &raw.Lit{
@@ -497,6 +605,8 @@ type SideFileType int
 const (
 	SideFileNone SideFileType = iota
 	SideFileZon
+	SideFileOnDemand
+	SideFileEdd
 )
 
 // WriteSideFile is used to write out side files beyond an archive
@@ -529,6 +639,37 @@ func (wce *Wce) WriteSingleFile(sidefileType SideFileType, path string) (bool, e
 			}
 			return true, nil
 		}
+	case SideFileEdd:
+		if len(wce.EmitterDefs) == 0 {
+			return false, nil
+		}
+		dst := &raw.Edd{}
+		dst.SetFileName(filepath.Base(path))
+		err := wce.WriteEddRaw(dst)
+		if err != nil {
+			return false, fmt.Errorf("edd to raw: %w", err)
+		}
+		w, err := os.Create(path)
+		if err != nil {
+			return false, fmt.Errorf("create edd file: %w", err)
+		}
+		defer w.Close()
+		err = dst.Write(w)
+		if err != nil {
+			return false, fmt.Errorf("edd write: %w", err)
+		}
+		return true, nil
+	case SideFileOnDemand:
+		// eqgs read as eqgs already have their OnDemandResources.txt entries
+		if !wce.isWldConverted || len(wce.MdsDefs) == 0 {
+			return false, nil
+		}
+		eqgName := strings.TrimSuffix(filepath.Base(path), "_ondemand.txt") + ".eqg"
+		err := os.WriteFile(path, []byte(strings.Join(wce.OnDemandResources(eqgName), "\n")+"\n"), 0644)
+		if err != nil {
+			return false, fmt.Errorf("write ondemand file: %w", err)
+		}
+		return true, nil
 	default:
 		return false, fmt.Errorf("unsupported side file type %d", sidefileType)
 	}
@@ -556,29 +697,39 @@ func writeEqgMaterials(srcMaterials []*EQMaterialDef) ([]*raw.ModMaterial, error
 	return dstMaterials, nil
 }
 
-func (wce *Wce) convertWldToEQG() error {
-	//var err error
+// ConvertWldToEQG returns wce with eqg models, animations and particles added for its wld
+// characters, and what their materials lost as notes. The conversion is done on a copy, so wce is
+// left as read, and a wce it already converted is returned as is
+func (wce *Wce) ConvertWldToEQG() (*Wce, []string, error) {
+	if wce.isEqgCopy {
+		return wce, nil, nil
+	}
 
-	// Write spell blit particles? (SPB)
-	for _, blitSprite := range wce.BlitSpriteDefs {
-		prt := &EqgParticleRenderDef{
-			Tag: blitSprite.Tag,
-		}
-		wce.PrtDefs = append(wce.PrtDefs, prt)
+	dst := *wce
+	dst.isEqgCopy = true
+	dst.AniDefs = append([]*EqgAniDef{}, wce.AniDefs...)
+	dst.EmitterDefs = append([]*EmitterDef{}, wce.EmitterDefs...)
+	dst.LayDefs = append([]*EqgLayDef{}, wce.LayDefs...)
+	dst.MdsDefs = append([]*EqgMdsDef{}, wce.MdsDefs...)
+	dst.ModDefs = append([]*EqgModDef{}, wce.ModDefs...)
+	dst.PrtDefs = append([]*EqgParticleRenderDef{}, wce.PrtDefs...)
+	dst.PtsDefs = append([]*EqgParticlePointDef{}, wce.PtsDefs...)
+
+	notes, err := dst.convertWldCharacters()
+	if err != nil {
+		return nil, nil, fmt.Errorf("characters: %w", err)
 	}
 
-	// Write other particle cloud blits
-	//for _, blitSprite := range wce.BlitSpriteDefs {
-	//}
+	// particle clouds ride on the bones of converted characters, or on the origin of actors they draw alone
+	err = dst.convertWldParticles()
+	if err != nil {
+		return nil, nil, fmt.Errorf("particles: %w", err)
+	}
 
 	// Write spell effect actordefs
 	//for _, actorDef := range wce.ActorDefs {
 	//}
 
-	// Write particle clouds
-	//for _, cloudDef := range wce.ParticleCloudDefs {
-	//	}
-
 	// Write other blits (for 2D Sprites and stuff)
 	//for _, blitSprite := range wce.BlitSpriteDefs {
 	//}
@@ -624,5 +775,5 @@ func (wce *Wce) convertWldToEQG() error {
 	//for _, zone := range wce.Zones {
 	//}
 
-	return nil
+	return &dst, notes, nil
 }
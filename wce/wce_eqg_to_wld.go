@@ -0,0 +1,531 @@
+package wce
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/xackery/quail/mesh"
+)
+
+// eqgRegionMaxFaces is the most terrain faces a converted BSP region holds
+const eqgRegionMaxFaces = 1024
+
+// eqgPlacement is a terrain placed in the world
+type eqgPlacement struct {
+	ter *EqgTerDef
+	t   zoneTransform
+}
+
+// eqgInstanceTransform returns the placement of an EQGZONDEF instance
+func eqgInstanceTransform(inst EqgZonInstance) zoneTransform {
+	t := zoneTransform{
+		translation: inst.Translation,
+		rotation:    inst.Rotation,
+		scale:       inst.Scale,
+	}
+	if t.scale == 0 {
+		t.scale = 1
+	}
+	return t
+}
+
+// eqgModelName returns the lower case model tag an instance places
+func eqgModelName(modelTag string) string {
+	name := strings.ToLower(modelTag)
+	return strings.TrimSuffix(strings.TrimSuffix(name, ".mod"), ".ter")
+}
+
+// eqgTerPlacements returns every terrain with the instances placing it, terrain no instance
+// places is at the origin
+func (wce *Wce) eqgTerPlacements() []eqgPlacement {
+	ters := map[string]*EqgTerDef{}
+	for _, ter := range wce.TerDefs {
+		ters[strings.ToLower(ter.Tag)] = ter
+	}
+	placements := []eqgPlacement{}
+	placed := map[*EqgTerDef]bool{}
+	for _, zon := range wce.ZonDefs {
+		for _, inst := range zon.Instances {
+			ter, ok := ters[eqgModelName(inst.ModelTag)]
+			if !ok {
+				continue
+			}
+			placements = append(placements, eqgPlacement{ter: ter, t: eqgInstanceTransform(inst)})
+			placed[ter] = true
+		}
+	}
+	for _, ter := range wce.TerDefs {
+		if placed[ter] {
+			continue
+		}
+		placements = append(placements, eqgPlacement{ter: ter, t: zoneTransformIdentity})
+	}
+	return placements
+}
+
+// eqgToWldVertex places an eqg vertex and moves it to wld space, which has x and y swapped
+func eqgToWldVertex(v *ModVertex, t zoneTransform) *ModVertex {
+	return &ModVertex{
+		Position: EqgToServerAxis(t.apply(v.Position)),
+		Normal:   EqgToServerAxis(t.rotate(v.Normal)),
+		Tint:     v.Tint,
+		Uv:       v.Uv,
+		Uv2:      v.Uv2,
+	}
+}
+
+// eqgToWldRotation converts an eqg rotation in radians to the wld actor rotation of the same
+// placement, in 1/512 of a circle. Swapping x and y also changes the axis order, so the whole
+// rotation is rebuilt rather than each angle swapped
+func eqgToWldRotation(rotation [3]float32) [3]float32 {
+	t := zoneTransform{rotation: rotation, scale: 1}
+	column := func(v [3]float32) [3]float32 {
+		return EqgToServerAxis(t.rotate(EqgToServerAxis(v)))
+	}
+	cx := column([3]float32{1, 0, 0})
+	cy := column([3]float32{0, 1, 0})
+	cz := column([3]float32{0, 0, 1})
+
+	// the rotation matrix is Rz * Ry * Rx
+	sinY := math.Max(-1, math.Min(1, float64(-cx[2])))
+	y := math.Asin(sinY)
+	x, z := 0.0, 0.0
+	if math.Abs(math.Cos(y)) > 1e-6 {
+		x = math.Atan2(float64(cy[2]), float64(cz[2]))
+		z = math.Atan2(float64(cx[1]), float64(cx[0]))
+	} else {
+		x = math.Atan2(float64(-cz[1]), float64(cy[1]))
+	}
+	toWld := func(radians float64) float32 {
+		return float32(radians / (2 * math.Pi) * 512)
+	}
+	return [3]float32{toWld(x), toWld(y), toWld(z)}
+}
+
+// eqgMaterialsToWld adds a MATERIALDEF and SIMPLESPRITEDEF for each eqg material dst does not have
+// yet, and returns what the materials lost
+func eqgMaterialsToWld(dst *Wce, materials []*EQMaterialDef) []string {
+	notes := []string{}
+	for _, material := range materials {
+		if dst.ByTag(eqgMaterialTag(material.Tag)) != nil {
+			continue
+		}
+		def, sprite, materialLoss := EqgMaterialToWld(material, "world")
+		notes = append(notes, materialNotes(material.Tag, materialLoss)...)
+		if sprite != nil {
+			dst.SimpleSpriteDefs = append(dst.SimpleSpriteDefs, sprite)
+		}
+		dst.MaterialDefs = append(dst.MaterialDefs, def)
+	}
+	return notes
+}
+
+// fixedPointScale returns the largest FPScale that fits extent in the int16 vertices of a
+// DMSPRITEDEF2 or DMTRACKDEF2, which are stored scaled by 1/2^FPScale
+func fixedPointScale(extent float32) (uint16, error) {
+	if extent > math.MaxInt16 {
+		return 0, fmt.Errorf("spans %0.2f units from its center, max is %d", extent, math.MaxInt16)
+	}
+	scale := uint16(0)
+	for scale < 15 && extent*float32(int(1)<<(scale+1)) <= math.MaxInt16 {
+		scale++
+	}
+	return scale, nil
+}
+
+// newEqgDMSprite builds a DMSPRITEDEF2 from wld space vertices and faces, with face groups
+// set to the index of the face material in palette. Faces of a material palette lacks use
+// index 0, see eqgUnknownMaterialNotes
+func newEqgDMSprite(tag string, paletteTag string, palette map[string]int, vertices []*ModVertex, faces []*ModFace) (*DMSpriteDef2, error) {
+	if len(vertices) > math.MaxUint16 {
+		return nil, fmt.Errorf("%s has %d vertices, max is %d", tag, len(vertices), math.MaxUint16)
+	}
+	sprite := &DMSpriteDef2{
+		folders:             []string{"world"},
+		Tag:                 tag,
+		MaterialPaletteTag:  paletteTag,
+		UseCenterOffset:     1,
+		UseBoundingRadius:   1,
+		UseBoundingBox:      1,
+		SpriteDefPolyhedron: 1,
+	}
+
+	m := &mesh.Mesh{}
+	for _, v := range vertices {
+		m.Positions = append(m.Positions, v.Position)
+	}
+	min, max := m.Bounds()
+	for i := 0; i < 3; i++ {
+		sprite.CenterOffset[i] = (min[i] + max[i]) / 2
+	}
+
+	extent := float32(0)
+	for _, v := range vertices {
+		position := vecSub(v.Position, sprite.CenterOffset)
+		for i := 0; i < 3; i++ {
+			extent = float32(math.Max(float64(extent), math.Abs(float64(position[i]))))
+		}
+		sprite.Vertices = append(sprite.Vertices, position)
+		sprite.VertexNormals = append(sprite.VertexNormals, v.Normal)
+		sprite.UVs = append(sprite.UVs, v.Uv)
+		sprite.VertexColors = append(sprite.VertexColors, v.Tint)
+	}
+	fpScale, err := fixedPointScale(extent)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", tag, err)
+	}
+	sprite.FPScale = fpScale
+
+	groups := make([]int, 0, len(faces))
+	for _, face := range faces {
+		// swapping x and y mirrors the mesh, so the winding is reversed to keep faces pointing out
+		sprite.Faces = append(sprite.Faces, &Face{
+			Passable: face.Passable,
+			Triangle: [3]uint16{uint16(face.Index[0]), uint16(face.Index[2]), uint16(face.Index[1])},
+		})
+		groups = append(groups, palette[strings.ToLower(face.MaterialName)])
+	}
+	if len(groups) > 0 {
+		sprite.FaceMaterialGroups = runsUint16(mesh.Runs(groups))
+	}
+	err = sprite.SortGroups()
+	if err != nil {
+		return nil, fmt.Errorf("sort groups: %w", err)
+	}
+	sprite.RecomputeBounds()
+	return sprite, nil
+}
+
+// eqgUnknownMaterialNotes returns a note for each material faces use that palette lacks, such
+// as the empty name of a mod face without a material, since those faces are drawn with index 0
+func eqgUnknownMaterialNotes(name string, palette map[string]int, faces []*ModFace) []string {
+	counts := map[string]int{}
+	order := []string{}
+	for _, face := range faces {
+		if _, ok := palette[strings.ToLower(face.MaterialName)]; ok {
+			continue
+		}
+		if counts[face.MaterialName] == 0 {
+			order = append(order, face.MaterialName)
+		}
+		counts[face.MaterialName]++
+	}
+	notes := []string{}
+	for _, material := range order {
+		notes = append(notes, fmt.Sprintf("%s: %d faces use unknown material %q, drawn with material 0", name, counts[material], material))
+	}
+	return notes
+}
+
+// eqgPalette adds a MATERIALPALETTE of materials to dst, returning material indexes keyed by lower case name
+func eqgPalette(dst *Wce, tag string, materials []*EQMaterialDef) map[string]int {
+	palette := &MaterialPalette{folders: []string{"world"}, Tag: tag}
+	indexes := map[string]int{}
+	for _, material := range materials {
+		name := strings.ToLower(material.Tag)
+		if _, ok := indexes[name]; ok {
+			continue
+		}
+		indexes[name] = len(palette.Materials)
+		palette.Materials = append(palette.Materials, eqgMaterialTag(material.Tag))
+	}
+	dst.MaterialPalettes = append(dst.MaterialPalettes, palette)
+	return indexes
+}
+
+// eqgModelToWld adds an ACTORDEF and DMSPRITEDEF2 of an eqg model to dst, unless dst has it
+// already, and returns what its materials lost
+func eqgModelToWld(dst *Wce, mod *EqgModDef) ([]string, error) {
+	name := strings.ToUpper(mod.Tag)
+	if dst.ByTag(name+"_ACTORDEF") != nil {
+		return nil, nil
+	}
+	notes := eqgMaterialsToWld(dst, mod.Materials)
+	palette := eqgPalette(dst, name+"_MP", mod.Materials)
+	notes = append(notes, eqgUnknownMaterialNotes(name, palette, mod.Faces)...)
+
+	vertices := make([]*ModVertex, 0, len(mod.Vertices))
+	for _, v := range mod.Vertices {
+		vertices = append(vertices, eqgToWldVertex(v, zoneTransformIdentity))
+	}
+	sprite, err := newEqgDMSprite(name+"_DMSPRITEDEF", name+"_MP", palette, vertices, mod.Faces)
+	if err != nil {
+		return nil, err
+	}
+	dst.DMSpriteDef2s = append(dst.DMSpriteDef2s, sprite)
+
+	dst.ActorDefs = append(dst.ActorDefs, &ActorDef{
+		folders:  []string{name},
+		Tag:      name + "_ACTORDEF",
+		Callback: "SPRITECALLBACK",
+		Actions: []ActorAction{{
+			LevelOfDetails: []ActorLevelOfDetail{{SpriteTag: sprite.Tag, MinDistance: 1e30}},
+		}},
+	})
+	return notes, nil
+}
+
+// convertEqgTerrain splits eqg terrain into a BSP WORLDTREE of REGIONs, each drawing a DMSPRITEDEF2
+// of the faces whose center falls inside it, and returns what its materials lost
+func (wce *Wce) convertEqgTerrain() ([]string, error) {
+	vertices := []*ModVertex{}
+	faces := []*ModFace{}
+	materials := []*EQMaterialDef{}
+	for _, placement := range wce.eqgTerPlacements() {
+		offset := uint32(len(vertices))
+		for _, v := range placement.ter.Vertices {
+			vertices = append(vertices, eqgToWldVertex(v, placement.t))
+		}
+		for _, face := range placement.ter.Faces {
+			placed := *face
+			placed.Index = [3]uint32{face.Index[0] + offset, face.Index[1] + offset, face.Index[2] + offset}
+			faces = append(faces, &placed)
+		}
+		materials = append(materials, placement.ter.Materials...)
+	}
+	if len(faces) == 0 {
+		return nil, nil
+	}
+
+	name := strings.ToUpper(strings.TrimSuffix(filepath.Base(wce.FileName), filepath.Ext(wce.FileName)))
+	notes := eqgMaterialsToWld(wce, materials)
+	palette := eqgPalette(wce, name+"_MP", materials)
+	notes = append(notes, eqgUnknownMaterialNotes(name, palette, faces)...)
+
+	centers := make([][3]float32, len(faces))
+	for i, face := range faces {
+		for _, index := range face.Index {
+			centers[i] = vecAdd(centers[i], vertices[index].Position)
+		}
+		for j := 0; j < 3; j++ {
+			centers[i][j] /= 3
+		}
+	}
+
+	tree := &WorldTree{folders: []string{"ZONE"}, Tag: "WORLDTREE"}
+	faceIndexes := make([]int, len(faces))
+	for i := range faceIndexes {
+		faceIndexes[i] = i
+	}
+
+	var build func(faceIndexes []int) (uint32, error)
+	build = func(faceIndexes []int) (uint32, error) {
+		node := &WorldNode{}
+		tree.WorldNodes = append(tree.WorldNodes, node)
+		nodeNumber := uint32(len(tree.WorldNodes))
+
+		axis, split, front, back := eqgBspSplit(centers, faceIndexes)
+		if len(faceIndexes) <= eqgRegionMaxFaces || len(front) == 0 || len(back) == 0 {
+			region, err := wce.eqgRegion(name+"_MP", palette, vertices, faces, faceIndexes)
+			if err != nil {
+				return 0, err
+			}
+			node.WorldRegionTag = region.Tag
+			return nodeNumber, nil
+		}
+
+		// points in front of a node have normal . point + distance above 0
+		node.Normals[axis] = 1
+		node.Normals[3] = -split
+		var err error
+		node.FrontTree, err = build(front)
+		if err != nil {
+			return 0, err
+		}
+		node.BackTree, err = build(back)
+		if err != nil {
+			return 0, err
+		}
+		return nodeNumber, nil
+	}
+	_, err := build(faceIndexes)
+	if err != nil {
+		return nil, err
+	}
+	wce.WorldTrees = append(wce.WorldTrees, tree)
+
+	visList := &VisList{}
+	for count := len(wce.Regions); count > 0; {
+		if count <= 62 {
+			visList.Ranges = append(visList.Ranges, byte(0xC0+count))
+			break
+		}
+		run := min(count, math.MaxUint16)
+		visList.Ranges = append(visList.Ranges, 0xFF, byte(run), byte(run>>8))
+		count -= run
+	}
+	for _, region := range wce.Regions {
+		// every region sees every other region
+		region.VisListBytes = 1
+		region.VisTree = &VisTree{
+			VisNodes: []*VisNode{{VisListIndex: 1}},
+			VisLists: []*VisList{visList},
+		}
+	}
+	return notes, nil
+}
+
+// eqgBspSplit halves faces across the longer horizontal side of the bounds of their centers
+func eqgBspSplit(centers [][3]float32, faceIndexes []int) (int, float32, []int, []int) {
+	min := centers[faceIndexes[0]]
+	max := min
+	for _, index := range faceIndexes[1:] {
+		for i := 0; i < 2; i++ {
+			min[i] = float32(math.Min(float64(min[i]), float64(centers[index][i])))
+			max[i] = float32(math.Max(float64(max[i]), float64(centers[index][i])))
+		}
+	}
+	axis := 0
+	if max[1]-min[1] > max[0]-min[0] {
+		axis = 1
+	}
+	split := (min[axis] + max[axis]) / 2
+	front := []int{}
+	back := []int{}
+	for _, index := range faceIndexes {
+		if centers[index][axis] > split {
+			front = append(front, index)
+			continue
+		}
+		back = append(back, index)
+	}
+	return axis, split, front, back
+}
+
+// eqgRegion adds a REGION drawing the given faces
+func (wce *Wce) eqgRegion(paletteTag string, palette map[string]int, vertices []*ModVertex, faces []*ModFace, faceIndexes []int) (*Region, error) {
+	tag := fmt.Sprintf("R%06d", len(wce.Regions)+1)
+
+	remap := map[uint32]uint32{}
+	regionVertices := []*ModVertex{}
+	regionFaces := []*ModFace{}
+	for _, faceIndex := range faceIndexes {
+		face := *faces[faceIndex]
+		for i, index := range face.Index {
+			newIndex, ok := remap[index]
+			if !ok {
+				newIndex = uint32(len(regionVertices))
+				remap[index] = newIndex
+				regionVertices = append(regionVertices, vertices[index])
+			}
+			face.Index[i] = newIndex
+		}
+		regionFaces = append(regionFaces, &face)
+	}
+
+	sprite, err := newEqgDMSprite(tag+"_DMSPRITEDEF", paletteTag, palette, regionVertices, regionFaces)
+	if err != nil {
+		return nil, err
+	}
+	wce.DMSpriteDef2s = append(wce.DMSpriteDef2s, sprite)
+
+	region := &Region{
+		folders: []string{"REGION"},
+		Tag:     tag,
+		VisTree: &VisTree{},
+	}
+	region.SpriteTag.Valid = true
+	region.SpriteTag.String = sprite.Tag
+	region.Sphere.Valid = true
+	region.Sphere.Float32Slice4 = [4]float32{sprite.CenterOffset[0], sprite.CenterOffset[1], sprite.CenterOffset[2], sprite.BoundingRadius}
+	wce.Regions = append(wce.Regions, region)
+	return region, nil
+}
+
+// ConvertEQGZone converts the instances and lights of an eqg zone into the wlds an s3d zone
+// keeps beside its zone wld: objects (objects.wld) places ACTORINSTs of the models in models
+// (<zone>_obj.s3d), and lights (lights.wld) holds POINTLIGHTs. The terrain of wce itself becomes
+// BSP regions as it is written as a wld. What the model materials lost is returned as notes
+func (wce *Wce) ConvertEQGZone() (*Wce, *Wce, *Wce, []string, error) {
+	base := strings.TrimSuffix(filepath.Base(wce.FileName), filepath.Ext(wce.FileName))
+	objects := New("objects.wld")
+	objects.WorldDef.Zone = 1
+	lights := New("lights.wld")
+	lights.WorldDef.Zone = 1
+	models := New(base + "_obj.wld")
+	notes := []string{}
+
+	mods := map[string]*EqgModDef{}
+	for _, mod := range wce.ModDefs {
+		mods[strings.ToLower(mod.Tag)] = mod
+	}
+
+	// v1 zones name the .lit file of an instance by its instance name
+	lits := map[string][][4]uint8{}
+	for _, lit := range wce.Lits {
+		lits[strings.TrimSuffix(strings.ToLower(lit.Tag), ".lit")] = lit.Lits
+	}
+
+	for _, zon := range wce.ZonDefs {
+		for _, inst := range zon.Instances {
+			mod, ok := mods[eqgModelName(inst.ModelTag)]
+			if !ok {
+				// terrain, and models outside of this archive, are not actors
+				continue
+			}
+			modelNotes, err := eqgModelToWld(models, mod)
+			if err != nil {
+				return nil, nil, nil, nil, fmt.Errorf("model %s: %w", mod.Tag, err)
+			}
+			notes = append(notes, modelNotes...)
+
+			t := eqgInstanceTransform(inst)
+			position := EqgToServerAxis(t.translation)
+			rotation := eqgToWldRotation(t.rotation)
+			actor := &ActorInst{
+				folders:       []string{"world"},
+				Tag:           strings.ToUpper(inst.InstanceTag),
+				DefinitionTag: strings.ToUpper(mod.Tag) + "_ACTORDEF",
+			}
+			actor.Location.Valid = true
+			actor.Location.Float32Slice6 = [6]float32{position[0], position[1], position[2], rotation[0], rotation[1], rotation[2]}
+			actor.Scale.Valid = true
+			actor.Scale.Float32 = t.scale
+
+			instLits := inst.Lits
+			if len(instLits) == 0 {
+				instLits = lits[strings.TrimSuffix(strings.ToLower(inst.InstanceTag), ".lit")]
+			}
+			if len(instLits) > 0 && len(instLits) == len(mod.Vertices) {
+				track := &RGBTrackDef{
+					folders:  []string{"world"},
+					Tag:      fmt.Sprintf("OBJ%d_DMT", len(objects.RGBTrackDefs)+1),
+					Data4:    1,
+					UseAlpha: 1,
+				}
+				colors := make([][4]uint8, len(instLits))
+				for i, lit := range instLits {
+					// lits are bgra
+					colors[i] = [4]uint8{lit[2], lit[1], lit[0], lit[3]}
+				}
+				track.RGBAFrames = [][][4]uint8{colors}
+				objects.RGBTrackDefs = append(objects.RGBTrackDefs, track)
+				actor.DMRGBTrackTag.Valid = true
+				actor.DMRGBTrackTag.String = track.Tag
+			}
+			objects.ActorInsts = append(objects.ActorInsts, actor)
+		}
+
+		for _, light := range zon.Lights {
+			number := len(lights.PointLights) + 1
+			lightDef := &LightDef{
+				folders:     []string{"ZONE"},
+				Tag:         fmt.Sprintf("L%d_LDEF", number),
+				LightLevels: []float32{1},
+				Colors:      [][3]float32{light.Color},
+			}
+			lights.LightDefs = append(lights.LightDefs, lightDef)
+			lights.PointLights = append(lights.PointLights, &PointLight{
+				folders:     []string{"ZONE"},
+				Tag:         fmt.Sprintf("L%d", number),
+				LightDefTag: lightDef.Tag,
+				Location:    EqgToServerAxis(light.Position),
+				Radius:      light.Radius,
+			})
+		}
+	}
+
+	return objects, lights, models, notes, nil
+}
@@ -0,0 +1,192 @@
+package wce
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/xackery/quail/raw"
+)
+
+func TestConvertEQGZone(t *testing.T) {
+	zone := New("test.eqg")
+
+	grass := &EQMaterialDef{
+		Tag:        "grass",
+		ShaderTag:  "Opaque_MaxCB1.fx",
+		Properties: []*MaterialProperty{{Name: "e_TextureDiffuse0", Type: raw.MaterialParamTypeTexture, Value: "grass.dds"}},
+	}
+	ter := &EqgTerDef{Tag: "test", Materials: []*EQMaterialDef{grass}}
+	// a 40 by 40 grid of quads, enough faces to need more than one region
+	const size = 40
+	for y := 0; y <= size; y++ {
+		for x := 0; x <= size; x++ {
+			ter.Vertices = append(ter.Vertices, &ModVertex{Position: [3]float32{float32(x) * 10, float32(y) * 10, float32(x + y)}})
+		}
+	}
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			a := uint32(y*(size+1) + x)
+			b, c, d := a+1, a+size+2, a+size+1
+			ter.Faces = append(ter.Faces,
+				&ModFace{Index: [3]uint32{a, b, c}, MaterialName: "grass"},
+				&ModFace{Index: [3]uint32{c, d, a}, MaterialName: "grass"},
+			)
+		}
+	}
+	ter.RecomputeNormals(false)
+	zone.TerDefs = append(zone.TerDefs, ter)
+
+	tree := &EqgModDef{
+		Tag:       "tree",
+		Materials: []*EQMaterialDef{{Tag: "bark", ShaderTag: "Alpha_MaxCB1.fx"}},
+		Vertices:  []*ModVertex{{Position: [3]float32{0, 0, 0}}, {Position: [3]float32{2, 0, 0}}, {Position: [3]float32{0, 1, 5}}},
+		Faces:     []*ModFace{{Index: [3]uint32{0, 1, 2}, MaterialName: "bark"}},
+	}
+	zone.ModDefs = append(zone.ModDefs, tree)
+
+	zone.ZonDefs = append(zone.ZonDefs, &EqgZonDef{
+		Tag: "test",
+		Instances: []EqgZonInstance{
+			{ModelTag: "test.ter", InstanceTag: "terrain", Scale: 1},
+			{ModelTag: "tree.mod", InstanceTag: "tree01", Translation: [3]float32{50, 120, 7}, Rotation: [3]float32{0.3, -0.7, 2.1}, Scale: 1.5,
+				Lits: [][4]uint8{{1, 2, 3, 4}, {5, 6, 7, 8}, {9, 10, 11, 12}}},
+			{ModelTag: "tree.mod", InstanceTag: "tree02.lit", Scale: 1},
+		},
+		Lights: []EqgZonLight{{Name: "torch", Position: [3]float32{1, 2, 3}, Color: [3]float32{1, 0.5, 0}, Radius: 40}},
+	})
+	// v1 zones keep instance lits in a .lit file named by the instance
+	zone.Lits = append(zone.Lits, &EqgLit{Tag: "tree02", Lits: [][4]uint8{{20, 30, 40, 50}, {5, 6, 7, 8}, {9, 10, 11, 12}}})
+
+	want, err := zone.ZoneMesh(nil, nil)
+	if err != nil {
+		t.Fatalf("eqg zone mesh: %v", err)
+	}
+
+	objects, lights, models, notes, err := zone.ConvertEQGZone()
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	wld, terrainNotes, err := zone.ConvertEQGToWld()
+	if err != nil {
+		t.Fatalf("convert terrain: %v", err)
+	}
+	if len(notes) != 0 || len(terrainNotes) != 0 {
+		t.Fatalf("unexpected notes %v %v", notes, terrainNotes)
+	}
+
+	if len(wld.Regions) < 2 || len(wld.WorldTrees) != 1 {
+		t.Fatalf("got %d regions and %d world trees, want several regions in 1 tree", len(wld.Regions), len(wld.WorldTrees))
+	}
+	if wld.ByTag("GRASS_MDF") == nil || wld.ByTag("GRASS_SPRITE") == nil {
+		t.Fatalf("terrain material not converted")
+	}
+	bark, ok := models.ByTag("BARK_MDF").(*MaterialDef)
+	if !ok || bark.RenderMethod != "USERDEFINED_20" {
+		t.Fatalf("model material not converted to a masked render method")
+	}
+	if len(objects.ActorInsts) != 2 || objects.ActorInsts[0].DefinitionTag != "TREE_ACTORDEF" {
+		t.Fatalf("tree instances not converted")
+	}
+	if len(objects.RGBTrackDefs) != 2 || objects.RGBTrackDefs[0].RGBAFrames[0][0] != [4]uint8{3, 2, 1, 4} {
+		t.Fatalf("instance lits not converted to rgba vertex colors")
+	}
+	if objects.RGBTrackDefs[1].RGBAFrames[0][0] != [4]uint8{40, 30, 20, 50} || objects.ActorInsts[1].DMRGBTrackTag.String != objects.RGBTrackDefs[1].Tag {
+		t.Fatalf("lit file not converted to rgba vertex colors")
+	}
+	if len(lights.PointLights) != 1 || lights.PointLights[0].Location != [3]float32{2, 1, 3} {
+		t.Fatalf("light not converted")
+	}
+
+	for _, dst := range []*Wce{zone, objects, lights, models} {
+		err = dst.WriteWldRaw(&bytes.Buffer{})
+		if err != nil {
+			t.Fatalf("write %s: %v", dst.FileName, err)
+		}
+	}
+	if len(zone.Regions) != 0 || len(zone.WorldTrees) != 0 || len(zone.MaterialDefs) != 0 || len(zone.DMSpriteDef2s) != 0 {
+		t.Fatalf("converting changed the eqg zone")
+	}
+
+	s3d := New("test.wld")
+	s3d.Regions = wld.Regions
+	s3d.DMSpriteDef2s = wld.DMSpriteDef2s
+	got, err := s3d.ZoneMesh(objects, models)
+	if err != nil {
+		t.Fatalf("s3d zone mesh: %v", err)
+	}
+	if len(got.Triangles) != len(want.Triangles) {
+		t.Fatalf("got %d triangles, want %d", len(got.Triangles), len(want.Triangles))
+	}
+
+	// faces keep pointing along their vertex normals once x and y are swapped
+	sprite := wld.DMSpriteDef2s[0]
+	face := sprite.Faces[0].Triangle
+	normal := vecCross(vecSub(sprite.Vertices[face[1]], sprite.Vertices[face[0]]), vecSub(sprite.Vertices[face[2]], sprite.Vertices[face[0]]))
+	if vecDot(normal, sprite.VertexNormals[face[0]]) <= 0 {
+		t.Fatalf("face winding does not match vertex normal %v", sprite.VertexNormals[face[0]])
+	}
+
+	// every eqg triangle must have a converted triangle at the same place
+	near := func(a, b [3]float32) bool {
+		for i := 0; i < 3; i++ {
+			if math.Abs(float64(a[i]-b[i])) > 0.01 {
+				return false
+			}
+		}
+		return true
+	}
+	matched := make([]bool, len(got.Triangles))
+	for _, wantTri := range want.Triangles {
+		found := false
+		for j, gotTri := range got.Triangles {
+			if matched[j] {
+				continue
+			}
+			// the converted winding is reversed
+			for rot := 0; rot < 3 && !found; rot++ {
+				found = true
+				for k := 0; k < 3; k++ {
+					if !near(want.Vertices[wantTri.Index[k]], got.Vertices[gotTri.Index[(3+rot-k)%3]]) {
+						found = false
+						break
+					}
+				}
+			}
+			if found {
+				matched[j] = true
+				break
+			}
+		}
+		if !found {
+			t.Fatalf("triangle %v %v %v not found in converted zone", want.Vertices[wantTri.Index[0]], want.Vertices[wantTri.Index[1]], want.Vertices[wantTri.Index[2]])
+		}
+	}
+}
+
+func TestConvertEQGToWldUnknownMaterial(t *testing.T) {
+	zone := New("test.eqg")
+	zone.TerDefs = append(zone.TerDefs, &EqgTerDef{
+		Tag:       "test",
+		Materials: []*EQMaterialDef{{Tag: "grass", ShaderTag: "Opaque_MaxCB1.fx"}},
+		Vertices:  []*ModVertex{{Position: [3]float32{0, 0, 0}}, {Position: [3]float32{2, 0, 0}}, {Position: [3]float32{0, 2, 0}}, {Position: [3]float32{2, 2, 0}}},
+		Faces: []*ModFace{
+			{Index: [3]uint32{0, 1, 2}, MaterialName: "grass"},
+			{Index: [3]uint32{1, 3, 2}, MaterialName: "FailsafeShader"},
+		},
+	})
+	region := &Region{Tag: "R000001"}
+	zone.Regions = append(zone.Regions, region)
+
+	wld, notes, err := zone.ConvertEQGToWld()
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	if len(notes) != 1 || !strings.Contains(notes[0], `"FailsafeShader"`) {
+		t.Fatalf("got notes %v, want an unknown material note", notes)
+	}
+	if region.VisTree != nil || wld.Regions[0] == region || wld.Regions[0].VisTree == nil {
+		t.Fatalf("converting changed a region of the eqg zone")
+	}
+}
@@ -0,0 +1,56 @@
+package wce
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// FloraTile lists the radial flora centered inside one v4 terrain tile
+type FloraTile struct {
+	Lng   int32
+	Lat   int32
+	Flora []*FloraPlacement
+}
+
+// FloraPlacement is a radial flora entry and the .rfd it came from
+type FloraPlacement struct {
+	RfdTag string
+	Entry  *RfdEntry
+}
+
+// FloraTiles groups every .rfd entry by the terrain tile its center falls in.
+// A tile is quadsPerTile*unitsPerVert wide, latitude runs along x and longitude along y,
+// both offset by 100000 like v4 zone .dat tiles
+func (wce *Wce) FloraTiles(unitsPerVert float32, quadsPerTile int) ([]*FloraTile, error) {
+	tileSize := float64(unitsPerVert) * float64(quadsPerTile)
+	if tileSize <= 0 {
+		return nil, fmt.Errorf("tile size %0.2f must be positive", tileSize)
+	}
+
+	tiles := map[[2]int32]*FloraTile{}
+	for _, rfd := range wce.RfdDefs {
+		for _, entry := range rfd.Entries {
+			lat := int32(math.Floor(float64(entry.Position[0])/tileSize)) + 100000
+			lng := int32(math.Floor(float64(entry.Position[1])/tileSize)) + 100000
+			tile, ok := tiles[[2]int32{lng, lat}]
+			if !ok {
+				tile = &FloraTile{Lng: lng, Lat: lat}
+				tiles[[2]int32{lng, lat}] = tile
+			}
+			tile.Flora = append(tile.Flora, &FloraPlacement{RfdTag: rfd.Tag, Entry: entry})
+		}
+	}
+
+	out := make([]*FloraTile, 0, len(tiles))
+	for _, tile := range tiles {
+		out = append(out, tile)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Lng != out[j].Lng {
+			return out[i].Lng < out[j].Lng
+		}
+		return out[i].Lat < out[j].Lat
+	})
+	return out, nil
+}
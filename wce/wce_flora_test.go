@@ -0,0 +1,37 @@
+package wce
+
+import (
+	"testing"
+)
+
+func TestFloraTiles(t *testing.T) {
+	wce := New("arcstone.eqg")
+	wce.RfdDefs = []*EqgRfdDef{
+		{Tag: "farstone_a", Entries: []*RfdEntry{
+			{Flora: "fern", Position: [3]float32{10, 10, 0}},
+			{Flora: "rock", Position: [3]float32{-10, 300, 0}},
+		}},
+		{Tag: "farstone_b", Entries: []*RfdEntry{
+			{Flora: "grass", Position: [3]float32{200, 50, 0}},
+		}},
+	}
+
+	tiles, err := wce.FloraTiles(2, 128)
+	if err != nil {
+		t.Fatalf("flora tiles: %s", err.Error())
+	}
+	if len(tiles) != 2 {
+		t.Fatalf("got %d tiles, want 2", len(tiles))
+	}
+	if tiles[0].Lng != 100000 || tiles[0].Lat != 100000 || len(tiles[0].Flora) != 2 || tiles[0].Flora[1].RfdTag != "farstone_b" {
+		t.Fatalf("tile 0 got %d,%d with %d flora", tiles[0].Lng, tiles[0].Lat, len(tiles[0].Flora))
+	}
+	if tiles[1].Lng != 100001 || tiles[1].Lat != 99999 {
+		t.Fatalf("tile 1 got %d,%d", tiles[1].Lng, tiles[1].Lat)
+	}
+
+	_, err = wce.FloraTiles(0, 128)
+	if err == nil {
+		t.Fatalf("expected tile size error")
+	}
+}
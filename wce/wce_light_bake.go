@@ -0,0 +1,323 @@
+package wce
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// LightBakeOption configures vertex light baking
+type LightBakeOption struct {
+	IsShadow bool // occlude lights by ray casting against collidable zone geometry
+}
+
+// lightBakeEpsilon offsets shadow rays from surfaces to avoid self intersection
+const lightBakeEpsilon = 0.01
+
+// lightBakeLight is a point light in server space
+type lightBakeLight struct {
+	position  [3]float32
+	color     [3]float32 // 0 to 1
+	radius    float32
+	occluders []*ZoneTriangle // collidable triangles within radius, only set when shadowing
+}
+
+// lightBaker computes vertex colors from a set of point lights
+type lightBaker struct {
+	lights []*lightBakeLight
+	mesh   *ZoneMesh // set when shadowing
+}
+
+// newLightBaker prepares lights, and when mesh is not nil, occluders for shadow rays
+func newLightBaker(lights []*lightBakeLight, mesh *ZoneMesh) *lightBaker {
+	b := &lightBaker{lights: lights, mesh: mesh}
+	if mesh == nil {
+		return b
+	}
+	for _, light := range lights {
+		for _, tri := range mesh.Triangles {
+			if tri.Passable {
+				continue
+			}
+			min, max := mesh.triangleBounds(tri)
+			isOutside := false
+			for i := 0; i < 3; i++ {
+				if light.position[i]+light.radius < min[i] || light.position[i]-light.radius > max[i] {
+					isOutside = true
+					break
+				}
+			}
+			if isOutside {
+				continue
+			}
+			light.occluders = append(light.occluders, tri)
+		}
+	}
+	return b
+}
+
+// color returns the lit color of a vertex, normal may be zero to skip the lambert term
+func (b *lightBaker) color(position [3]float32, normal [3]float32, ambient [3]float32) [3]float32 {
+	out := ambient
+	hasNormal := normal != [3]float32{}
+	for _, light := range b.lights {
+		dir := vecSub(light.position, position)
+		dist := vecLength(dir)
+		if dist >= light.radius {
+			continue
+		}
+		attenuation := 1 - dist/light.radius
+		if hasNormal && dist > 0 {
+			lambert := vecDot(normal, vecScale(dir, 1/dist))
+			if lambert <= 0 {
+				continue
+			}
+			attenuation *= lambert
+		}
+		if b.mesh != nil && b.isOccluded(position, normal, light) {
+			continue
+		}
+		for i := 0; i < 3; i++ {
+			out[i] += light.color[i] * attenuation
+		}
+	}
+	return out
+}
+
+// isOccluded returns true when a collidable triangle is between position and light
+func (b *lightBaker) isOccluded(position [3]float32, normal [3]float32, light *lightBakeLight) bool {
+	origin := vecAdd(position, vecScale(normal, lightBakeEpsilon))
+	dir := vecSub(light.position, origin)
+	dist := vecLength(dir)
+	if dist == 0 {
+		return false
+	}
+	dir = vecScale(dir, 1/dist)
+	for _, tri := range light.occluders {
+		t, ok := rayTriangle(origin, dir, b.mesh.Vertices[tri.Index[0]], b.mesh.Vertices[tri.Index[1]], b.mesh.Vertices[tri.Index[2]])
+		if ok && t > lightBakeEpsilon && t < dist-lightBakeEpsilon {
+			return true
+		}
+	}
+	return false
+}
+
+// rayTriangle returns the distance along dir where the ray hits the triangle (Möller–Trumbore)
+func rayTriangle(origin [3]float32, dir [3]float32, a [3]float32, b [3]float32, c [3]float32) (float32, bool) {
+	edge1 := vecSub(b, a)
+	edge2 := vecSub(c, a)
+	p := vecCross(dir, edge2)
+	det := vecDot(edge1, p)
+	if det > -1e-7 && det < 1e-7 {
+		return 0, false
+	}
+	inv := 1 / det
+	s := vecSub(origin, a)
+	u := vecDot(s, p) * inv
+	if u < 0 || u > 1 {
+		return 0, false
+	}
+	q := vecCross(s, edge1)
+	v := vecDot(dir, q) * inv
+	if v < 0 || u+v > 1 {
+		return 0, false
+	}
+	return vecDot(edge2, q) * inv, true
+}
+
+func (m *ZoneMesh) triangleBounds(tri *ZoneTriangle) ([3]float32, [3]float32) {
+	min := m.Vertices[tri.Index[0]]
+	max := min
+	for _, index := range tri.Index[1:] {
+		v := m.Vertices[index]
+		for i := 0; i < 3; i++ {
+			min[i] = float32(math.Min(float64(min[i]), float64(v[i])))
+			max[i] = float32(math.Max(float64(max[i]), float64(v[i])))
+		}
+	}
+	return min, max
+}
+
+func vecAdd(a [3]float32, b [3]float32) [3]float32 {
+	return [3]float32{a[0] + b[0], a[1] + b[1], a[2] + b[2]}
+}
+
+func vecSub(a [3]float32, b [3]float32) [3]float32 {
+	return [3]float32{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+func vecScale(a [3]float32, s float32) [3]float32 {
+	return [3]float32{a[0] * s, a[1] * s, a[2] * s}
+}
+
+func vecDot(a [3]float32, b [3]float32) float32 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func vecCross(a [3]float32, b [3]float32) [3]float32 {
+	return [3]float32{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+
+func vecLength(a [3]float32) float32 {
+	return float32(math.Sqrt(float64(vecDot(a, a))))
+}
+
+// lightBakeColor converts a 0 to 1 color to RGBA bytes, keeping alpha
+func lightBakeColor(color [3]float32, alpha uint8) [4]uint8 {
+	out := [4]uint8{0, 0, 0, alpha}
+	for i := 0; i < 3; i++ {
+		out[i] = uint8(math.Round(float64(math.Max(0, math.Min(1, float64(color[i])))) * 255))
+	}
+	return out
+}
+
+// lightDefColor returns the first frame color of a light definition
+func lightDefColor(def *LightDef) [3]float32 {
+	if len(def.Colors) > 0 {
+		return def.Colors[0]
+	}
+	if len(def.LightLevels) > 0 {
+		level := def.LightLevels[0]
+		return [3]float32{level, level, level}
+	}
+	return [3]float32{1, 1, 1}
+}
+
+// lightDefByTag searches each wce for a light definition
+func lightDefByTag(tag string, wces ...*Wce) *LightDef {
+	for _, wce := range wces {
+		if wce == nil {
+			continue
+		}
+		for _, def := range wce.LightDefs {
+			if strings.EqualFold(def.Tag, tag) {
+				return def
+			}
+		}
+	}
+	return nil
+}
+
+// BakeVertexLights recomputes zone DMSPRITEDEF2 vertex colors, and RGBDEFORMATIONTRACKDEF colors of
+// placed objects, from POINTLIGHT, AMBIENTLIGHT and GLOBALAMBIENTLIGHTDEF data.
+//
+// lights holds POINTLIGHT definitions (lights.wld) and defaults to wce, objects holds ACTORINST
+// placements (objects.wld) and models holds placed object definitions (<zone>_obj.s3d). Attenuation
+// is linear to the light radius, scaled by the angle to the vertex normal.
+func (wce *Wce) BakeVertexLights(lights *Wce, objects *Wce, models *Wce, opt *LightBakeOption) error {
+	if opt == nil {
+		opt = &LightBakeOption{}
+	}
+	if lights == nil {
+		lights = wce
+	}
+	if models == nil {
+		models = objects
+	}
+
+	bakeLights := []*lightBakeLight{}
+	for _, light := range lights.PointLights {
+		color := [3]float32{1, 1, 1}
+		if light.LightDefTag != "" {
+			def := lightDefByTag(light.LightDefTag, lights, wce)
+			if def == nil {
+				return fmt.Errorf("pointlight %s lightdef %s not found", light.Tag, light.LightDefTag)
+			}
+			color = lightDefColor(def)
+		}
+		bakeLights = append(bakeLights, &lightBakeLight{position: light.Location, color: color, radius: light.Radius})
+	}
+
+	var mesh *ZoneMesh
+	if opt.IsShadow {
+		var err error
+		mesh, err = wce.ZoneMesh(objects, models)
+		if err != nil {
+			return fmt.Errorf("zone mesh: %w", err)
+		}
+	}
+	baker := newLightBaker(bakeLights, mesh)
+
+	globalAmbient := [3]float32{}
+	if wce.GlobalAmbientLightDef != nil {
+		for i := 0; i < 3; i++ {
+			globalAmbient[i] = float32(wce.GlobalAmbientLightDef.Color[i]) / 255
+		}
+	}
+
+	// region ambient lights override the global ambient of their region sprites
+	spriteAmbients := map[string][3]float32{}
+	for _, ambientLight := range wce.AmbientLights {
+		def := lightDefByTag(ambientLight.LightTag, wce, lights)
+		if def == nil {
+			continue
+		}
+		for _, regionIndex := range ambientLight.Regions {
+			if int(regionIndex) >= len(wce.Regions) {
+				return fmt.Errorf("ambientlight %s region %d out of range", ambientLight.Tag, regionIndex)
+			}
+			region := wce.Regions[regionIndex]
+			if region.SpriteTag.Valid {
+				spriteAmbients[region.SpriteTag.String] = lightDefColor(def)
+			}
+		}
+	}
+
+	for _, sprite := range wce.DMSpriteDef2s {
+		ambient, ok := spriteAmbients[sprite.Tag]
+		if !ok {
+			ambient = globalAmbient
+		}
+		colors := make([][4]uint8, len(sprite.Vertices))
+		for i, v := range sprite.Vertices {
+			position := vecAdd(v, sprite.CenterOffset)
+			normal := [3]float32{}
+			if len(sprite.VertexNormals) == len(sprite.Vertices) {
+				normal = sprite.VertexNormals[i]
+			}
+			alpha := uint8(255)
+			if i < len(sprite.VertexColors) {
+				alpha = sprite.VertexColors[i][3]
+			}
+			colors[i] = lightBakeColor(baker.color(position, normal, ambient), alpha)
+		}
+		sprite.VertexColors = colors
+	}
+
+	if objects == nil || models == nil {
+		return nil
+	}
+	for _, inst := range objects.ActorInsts {
+		if !inst.DMRGBTrackTag.Valid || inst.DMRGBTrackTag.String == "" {
+			continue
+		}
+		track, ok := objects.ByTag(inst.DMRGBTrackTag.String).(*RGBTrackDef)
+		if !ok {
+			return fmt.Errorf("actorinst %s rgb track %s not found", inst.Tag, inst.DMRGBTrackTag.String)
+		}
+		sprite := models.actorSprite(inst.DefinitionTag)
+		if sprite == nil {
+			continue
+		}
+		t := actorInstTransform(inst)
+		colors := make([][4]uint8, len(sprite.Vertices))
+		for i, v := range sprite.Vertices {
+			position := t.apply(vecAdd(v, sprite.CenterOffset))
+			normal := [3]float32{}
+			if len(sprite.VertexNormals) == len(sprite.Vertices) {
+				normal = t.rotate(sprite.VertexNormals[i])
+			}
+			alpha := uint8(255)
+			if len(track.RGBAFrames) > 0 && i < len(track.RGBAFrames[0]) {
+				alpha = track.RGBAFrames[0][i][3]
+			}
+			colors[i] = lightBakeColor(baker.color(position, normal, globalAmbient), alpha)
+		}
+		if len(track.RGBAFrames) == 0 {
+			track.RGBAFrames = append(track.RGBAFrames, colors)
+			continue
+		}
+		track.RGBAFrames[0] = colors
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package wce
+
+import (
+	"testing"
+)
+
+func TestBakeVertexLights(t *testing.T) {
+	newZone := func() *Wce {
+		zone := New("test")
+		zone.LightDefs = append(zone.LightDefs, &LightDef{Tag: "RED_LDEF", Colors: [][3]float32{{1, 0, 0}}})
+		zone.PointLights = append(zone.PointLights, &PointLight{Tag: "L1", LightDefTag: "RED_LDEF", Location: [3]float32{0, 0, 10}, Radius: 20})
+		zone.DMSpriteDef2s = append(zone.DMSpriteDef2s,
+			&DMSpriteDef2{
+				Tag:           "FLOOR_DMSPRITEDEF",
+				Vertices:      [][3]float32{{0, 0, 0}, {100, 0, 0}, {0, 100, 0}},
+				VertexNormals: [][3]float32{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}},
+				Faces:         []*Face{{Triangle: [3]uint16{0, 1, 2}}},
+			},
+			// a roof between the light and the floor origin
+			&DMSpriteDef2{
+				Tag:      "ROOF_DMSPRITEDEF",
+				Vertices: [][3]float32{{-5, -5, 5}, {5, -5, 5}, {-5, 5, 5}},
+				Faces:    []*Face{{Triangle: [3]uint16{0, 1, 2}}},
+			},
+		)
+		return zone
+	}
+
+	zone := newZone()
+	err := zone.BakeVertexLights(nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("bake: %s", err)
+	}
+	floor := zone.DMSpriteDef2s[0]
+	// distance 10 of radius 20 with the normal facing the light is half strength
+	if floor.VertexColors[0] != [4]uint8{128, 0, 0, 255} {
+		t.Fatalf("lit color got %v", floor.VertexColors[0])
+	}
+	if floor.VertexColors[1] != [4]uint8{0, 0, 0, 255} {
+		t.Fatalf("out of range color got %v", floor.VertexColors[1])
+	}
+
+	zone = newZone()
+	err = zone.BakeVertexLights(nil, nil, nil, &LightBakeOption{IsShadow: true})
+	if err != nil {
+		t.Fatalf("bake shadow: %s", err)
+	}
+	if zone.DMSpriteDef2s[0].VertexColors[0] != [4]uint8{0, 0, 0, 255} {
+		t.Fatalf("shadowed color got %v", zone.DMSpriteDef2s[0].VertexColors[0])
+	}
+}
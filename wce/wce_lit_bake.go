@@ -0,0 +1,126 @@
+package wce
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BakeEqgLits recomputes per vertex lighting of every EQGZONDEF instance placing an EQGMODELDEF
+// from the zone's lights. Version 1 zones store lighting in EQGLIT definitions named after the
+// instance, newer versions store it on the instance itself.
+func (wce *Wce) BakeEqgLits(opt *LightBakeOption) error {
+	if opt == nil {
+		opt = &LightBakeOption{}
+	}
+
+	// lights and geometry are both converted to server space so shadow rays match the zone mesh
+	bakeLights := []*lightBakeLight{}
+	for _, zon := range wce.ZonDefs {
+		for _, light := range zon.Lights {
+			bakeLights = append(bakeLights, &lightBakeLight{
+				position: EqgToServerAxis(light.Position),
+				color:    light.Color,
+				radius:   light.Radius,
+			})
+		}
+	}
+
+	var mesh *ZoneMesh
+	if opt.IsShadow {
+		var err error
+		mesh, err = wce.ZoneMesh(nil, nil)
+		if err != nil {
+			return fmt.Errorf("zone mesh: %w", err)
+		}
+	}
+	baker := newLightBaker(bakeLights, mesh)
+
+	mods := map[string]*EqgModDef{}
+	for _, mod := range wce.ModDefs {
+		mods[strings.ToLower(mod.Tag)] = mod
+	}
+
+	for _, zon := range wce.ZonDefs {
+		for i := range zon.Instances {
+			inst := &zon.Instances[i]
+			mod, ok := mods[strings.TrimSuffix(strings.ToLower(inst.ModelTag), ".mod")]
+			if !ok {
+				continue
+			}
+			t := zoneTransform{translation: inst.Translation, rotation: inst.Rotation, scale: inst.Scale}
+			if t.scale == 0 {
+				t.scale = 1
+			}
+
+			lits := make([][4]uint8, len(mod.Vertices))
+			for j, v := range mod.Vertices {
+				position := EqgToServerAxis(t.apply(v.Position))
+				normal := EqgToServerAxis(t.rotate(v.Normal))
+				rgba := lightBakeColor(baker.color(position, normal, [3]float32{}), 255)
+				// lit entries are BGRA
+				lits[j] = [4]uint8{rgba[2], rgba[1], rgba[0], rgba[3]}
+			}
+
+			if zon.Version > 1 {
+				inst.Lits = lits
+				continue
+			}
+			wce.eqgLitSet(strings.TrimSuffix(inst.InstanceTag, ".lit"), lits)
+		}
+	}
+
+	return wce.EqgLitValidate()
+}
+
+// eqgLitSet replaces the entries of the EQGLIT named tag, adding it if missing
+func (wce *Wce) eqgLitSet(tag string, lits [][4]uint8) {
+	for _, lit := range wce.Lits {
+		if strings.EqualFold(lit.Tag, tag) {
+			lit.Lits = lits
+			return
+		}
+	}
+	wce.Lits = append(wce.Lits, &EqgLit{
+		folders: []string{"ZONE"},
+		Tag:     tag,
+		Lits:    lits,
+	})
+}
+
+// EqgLitValidate returns an error when an instance's lighting entry count does not match the
+// vertex count of the EQGMODELDEF it places
+func (wce *Wce) EqgLitValidate() error {
+	mods := map[string]*EqgModDef{}
+	for _, mod := range wce.ModDefs {
+		mods[strings.ToLower(mod.Tag)] = mod
+	}
+	lits := map[string]*EqgLit{}
+	for _, lit := range wce.Lits {
+		lits[strings.ToLower(lit.Tag)] = lit
+	}
+
+	for _, zon := range wce.ZonDefs {
+		for _, inst := range zon.Instances {
+			mod, ok := mods[strings.TrimSuffix(strings.ToLower(inst.ModelTag), ".mod")]
+			if !ok {
+				continue
+			}
+			count := len(inst.Lits)
+			name := inst.InstanceTag
+			if zon.Version <= 1 {
+				lit, ok := lits[strings.TrimSuffix(strings.ToLower(inst.InstanceTag), ".lit")]
+				if !ok {
+					continue
+				}
+				count = len(lit.Lits)
+				name = lit.Tag
+			} else if count == 0 {
+				continue
+			}
+			if count != len(mod.Vertices) {
+				return fmt.Errorf("lit %s has %d entries, model %s has %d vertices", name, count, mod.Tag, len(mod.Vertices))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package wce
+
+import (
+	"testing"
+)
+
+func TestBakeEqgLits(t *testing.T) {
+	newZone := func(version uint32) *Wce {
+		zone := New("test")
+		zone.ModDefs = append(zone.ModDefs, &EqgModDef{
+			Tag: "obj_box",
+			Vertices: []*ModVertex{
+				{Position: [3]float32{0, 0, 0}, Normal: [3]float32{0, 0, 1}},
+				{Position: [3]float32{100, 0, 0}, Normal: [3]float32{0, 0, 1}},
+			},
+		})
+		zone.ZonDefs = append(zone.ZonDefs, &EqgZonDef{
+			Version:   version,
+			Instances: []EqgZonInstance{{ModelTag: "OBJ_BOX.MOD", InstanceTag: "box_lit", Scale: 1}},
+			Lights:    []EqgZonLight{{Name: "blue", Position: [3]float32{0, 0, 10}, Color: [3]float32{0, 0, 1}, Radius: 20}},
+		})
+		return zone
+	}
+
+	zone := newZone(1)
+	err := zone.BakeEqgLits(nil)
+	if err != nil {
+		t.Fatalf("bake v1: %s", err)
+	}
+	if len(zone.Lits) != 1 || zone.Lits[0].Tag != "box_lit" {
+		t.Fatalf("expected box_lit to be created")
+	}
+	// blue at half strength, stored BGRA
+	if zone.Lits[0].Lits[0] != [4]uint8{128, 0, 0, 255} {
+		t.Fatalf("lit got %v", zone.Lits[0].Lits[0])
+	}
+	if zone.Lits[0].Lits[1] != [4]uint8{0, 0, 0, 255} {
+		t.Fatalf("out of range lit got %v", zone.Lits[0].Lits[1])
+	}
+
+	zone = newZone(2)
+	err = zone.BakeEqgLits(nil)
+	if err != nil {
+		t.Fatalf("bake v2: %s", err)
+	}
+	if len(zone.Lits) != 0 || len(zone.ZonDefs[0].Instances[0].Lits) != 2 {
+		t.Fatalf("expected lits on the instance")
+	}
+
+	zone.ZonDefs[0].Instances[0].Lits = zone.ZonDefs[0].Instances[0].Lits[:1]
+	err = zone.EqgLitValidate()
+	if err == nil {
+		t.Fatalf("expected vertex count mismatch")
+	}
+}
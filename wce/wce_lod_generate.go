@@ -0,0 +1,126 @@
+package wce
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// LodOption configures GenerateLods
+type LodOption struct {
+	Levels    int       // reduced models made per source model
+	Ratio     float32   // face count of each level relative to the level before it
+	Distances []float32 // Levels+1 switch distances, empty doubles from 100
+	ModelTag  string    // when set, only this model is reduced
+}
+
+var lodTagRegex = regexp.MustCompile(`(?i)_lod\d+$`)
+
+// Decimate returns a copy of the model reduced to at most targetFaces faces.
+// UV seams and material boundaries are preserved, the copy keeps the source tag and folders
+func (e *EqgModDef) Decimate(targetFaces int) *EqgModDef {
+	m := modMesh(e.Vertices, e.Faces)
+	groups := map[string]int{}
+	for i, face := range e.Faces {
+		group, ok := groups[face.MaterialName]
+		if !ok {
+			group = len(groups)
+			groups[face.MaterialName] = group
+		}
+		m.Faces[i].Group = group
+	}
+	vertexKeep, faceKeep := m.Decimate(targetFaces, nil)
+
+	dst := &EqgModDef{
+		folders:   append([]string{}, e.folders...),
+		Tag:       e.Tag,
+		Version:   e.Version,
+		Materials: e.Materials,
+		Bones:     e.Bones,
+	}
+	dst.Vertices, dst.Faces = modApply(e.Vertices, e.Faces, m, vertexKeep, faceKeep)
+	return dst
+}
+
+// GenerateLods adds decimated <model>_LOD1.._LODn models for every model and a .lod
+// listing them with their switch distances. Earlier generated levels are replaced
+func (wce *Wce) GenerateLods(opt *LodOption) error {
+	if opt.Levels < 1 {
+		return fmt.Errorf("levels %d must be at least 1", opt.Levels)
+	}
+	if opt.Ratio <= 0 || opt.Ratio >= 1 {
+		return fmt.Errorf("ratio %0.2f must be between 0 and 1", opt.Ratio)
+	}
+	distances := opt.Distances
+	if len(distances) == 0 {
+		distance := float32(100)
+		for i := 0; i <= opt.Levels; i++ {
+			distances = append(distances, distance)
+			distance *= 2
+		}
+	}
+	if len(distances) != opt.Levels+1 {
+		return fmt.Errorf("got %d distances, wanted %d (one per level plus the source model)", len(distances), opt.Levels+1)
+	}
+
+	sources := []*EqgModDef{}
+	generated := map[string]bool{}
+	for _, mod := range wce.ModDefs {
+		if lodTagRegex.MatchString(mod.Tag) {
+			continue
+		}
+		if opt.ModelTag != "" && !strings.EqualFold(mod.Tag, opt.ModelTag) {
+			continue
+		}
+		sources = append(sources, mod)
+		for i := 1; i <= opt.Levels; i++ {
+			generated[strings.ToLower(fmt.Sprintf("%s_LOD%d", mod.Tag, i))] = true
+		}
+	}
+	if len(sources) == 0 {
+		if opt.ModelTag != "" {
+			return fmt.Errorf("model %s not found", opt.ModelTag)
+		}
+		return fmt.Errorf("no models found")
+	}
+
+	mods := []*EqgModDef{}
+	for _, mod := range wce.ModDefs {
+		if generated[strings.ToLower(mod.Tag)] {
+			continue
+		}
+		mods = append(mods, mod)
+	}
+
+	for _, mod := range sources {
+		lod := &EqgLodDef{
+			folders: []string{strings.TrimSuffix(strings.ToLower(wce.FileName), ".eqg")},
+			Tag:     mod.Tag,
+			Lods:    []*LodEntry{{Category: "LOD", ObjectName: strings.ToUpper(mod.Tag), Distance: distances[0]}},
+		}
+
+		targetFaces := float32(len(mod.Faces))
+		for i := 1; i <= opt.Levels; i++ {
+			targetFaces *= opt.Ratio
+			level := mod.Decimate(int(targetFaces))
+			level.Tag = fmt.Sprintf("%s_LOD%d", mod.Tag, i)
+			mods = append(mods, level)
+			lod.Lods = append(lod.Lods, &LodEntry{Category: "LOD", ObjectName: strings.ToUpper(level.Tag), Distance: distances[i]})
+		}
+
+		isReplaced := false
+		for i, existing := range wce.LodDefs {
+			if !strings.EqualFold(existing.Tag, mod.Tag) {
+				continue
+			}
+			wce.LodDefs[i] = lod
+			isReplaced = true
+			break
+		}
+		if !isReplaced {
+			wce.LodDefs = append(wce.LodDefs, lod)
+		}
+	}
+	wce.ModDefs = mods
+	return nil
+}
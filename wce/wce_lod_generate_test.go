@@ -0,0 +1,54 @@
+package wce
+
+import (
+	"testing"
+)
+
+func TestGenerateLods(t *testing.T) {
+	mod := &EqgModDef{Tag: "obj_plane"}
+	size := 5
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			mod.Vertices = append(mod.Vertices, &ModVertex{Position: [3]float32{float32(x), float32(y), 0}, Uv: [2]float32{float32(x), float32(y)}})
+		}
+	}
+	for y := 0; y < size-1; y++ {
+		for x := 0; x < size-1; x++ {
+			i := uint32(y*size + x)
+			mod.Faces = append(mod.Faces, &ModFace{Index: [3]uint32{i, i + 1, i + uint32(size) + 1}, MaterialName: "plane"})
+			mod.Faces = append(mod.Faces, &ModFace{Index: [3]uint32{i, i + uint32(size) + 1, i + uint32(size)}, MaterialName: "plane"})
+		}
+	}
+
+	wce := New("test.eqg")
+	wce.ModDefs = []*EqgModDef{mod, {Tag: "obj_plane_lod1"}}
+	err := wce.GenerateLods(&LodOption{Levels: 2, Ratio: 0.5})
+	if err != nil {
+		t.Fatalf("generate lods: %s", err.Error())
+	}
+	if len(wce.ModDefs) != 3 {
+		t.Fatalf("got %d models, want 3", len(wce.ModDefs))
+	}
+	lod1 := wce.ModDefs[1]
+	if lod1.Tag != "obj_plane_LOD1" || len(lod1.Faces) > 16 || len(lod1.Faces) == 0 {
+		t.Fatalf("lod1 %s got %d faces", lod1.Tag, len(lod1.Faces))
+	}
+	for _, v := range lod1.Vertices {
+		if v.Uv[0] != v.Position[0] || v.Uv[1] != v.Position[1] {
+			t.Fatalf("vertex %v lost its uv", v.Position)
+		}
+	}
+
+	if len(wce.LodDefs) != 1 || len(wce.LodDefs[0].Lods) != 3 {
+		t.Fatalf("lod defs got %d", len(wce.LodDefs))
+	}
+	entry := wce.LodDefs[0].Lods[2]
+	if entry.ObjectName != "OBJ_PLANE_LOD2" || entry.Distance != 400 {
+		t.Fatalf("lod entry got %s %0.1f", entry.ObjectName, entry.Distance)
+	}
+
+	err = wce.GenerateLods(&LodOption{Levels: 2, Ratio: 0.5, Distances: []float32{1}})
+	if err == nil {
+		t.Fatalf("expected distance count error")
+	}
+}
@@ -0,0 +1,153 @@
+package wce
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/xackery/quail/helper"
+	"github.com/xackery/quail/raw"
+)
+
+// eqgMaterialTag returns the MATERIALDEF tag of an eqg material
+func eqgMaterialTag(name string) string {
+	return strings.ToUpper(name) + "_MDF"
+}
+
+// wldMaterialName returns the eqg material name of a MATERIALDEF tag
+func wldMaterialName(tag string) string {
+	return strings.ToLower(strings.TrimSuffix(baseTag(tag), "_MDF"))
+}
+
+// wldMaterialTexture returns the first texture file of a MATERIALDEF
+func (wce *Wce) wldMaterialTexture(material *MaterialDef) string {
+	sprite, ok := wce.ByTag(material.SimpleSpriteTag).(*SimpleSpriteDef)
+	if !ok {
+		return ""
+	}
+	frames := simpleSpriteFrameFiles(sprite)
+	if len(frames) == 0 {
+		return ""
+	}
+	return frames[0]
+}
+
+// simpleSpriteFrameFiles returns the first texture file of each frame of a sprite
+func simpleSpriteFrameFiles(sprite *SimpleSpriteDef) []string {
+	files := []string{}
+	for _, frame := range sprite.SimpleSpriteFrames {
+		if len(frame.TextureFiles) == 0 {
+			continue
+		}
+		files = append(files, strings.ToLower(frame.TextureFiles[0]))
+	}
+	return files
+}
+
+// MaterialDefToEqg returns the eqg material closest to a MATERIALDEF, with the shader picked by
+// its render method and its texture as every texture property the shader reads. Each part of the
+// render method the shader does not keep is returned as a note
+func (wce *Wce) MaterialDefToEqg(material *MaterialDef) (*EQMaterialDef, []string) {
+	shader, notes := helper.RenderMethodShader(material.RenderMethod)
+	def := &EQMaterialDef{
+		Tag:       wldMaterialName(material.Tag),
+		ShaderTag: shader,
+	}
+	frames := []string{}
+	sprite, ok := wce.ByTag(material.SimpleSpriteTag).(*SimpleSpriteDef)
+	if ok {
+		frames = simpleSpriteFrameFiles(sprite)
+	}
+	if len(frames) > 0 {
+		// unlit shaders glow with the diffuse texture too
+		for _, name := range helper.ShaderProperties(shader) {
+			def.Properties = append(def.Properties, &MaterialProperty{
+				Name:  name,
+				Type:  raw.MaterialParamTypeTexture,
+				Value: frames[0],
+			})
+		}
+	}
+	if len(frames) > 1 {
+		// animated textures cycle through every frame of the sprite
+		def.AnimationTextures = frames
+		if sprite.Sleep.Valid {
+			def.AnimationSleep = sprite.Sleep.Uint32
+		}
+	}
+	return def, notes
+}
+
+// eqgPropertyNames name the eqg material properties a MATERIALDEF has no place for in notes
+var eqgPropertyNames = map[string]string{
+	"e_texturenormal0":      "normal map",
+	"e_textureenvironment0": "environment map",
+	"e_textureglow0":        "glow map",
+	"e_fshininess0":         "shininess",
+}
+
+// EqgMaterialToWld returns the MATERIALDEF closest to an eqg material, with the render method
+// picked by its shader, and a SIMPLESPRITEDEF of its diffuse texture, or nil if it has none.
+// Each part of the shader the render method does not keep, and each property other than the
+// diffuse texture, is returned as a note. The glow map of an unlit shader is kept as its lighting
+func EqgMaterialToWld(material *EQMaterialDef, folder string) (*MaterialDef, *SimpleSpriteDef, []string) {
+	renderMethod, notes := helper.ShaderRenderMethod(material.ShaderTag)
+	isUnlit := helper.ShaderStyle(material.ShaderTag).Unlit
+	for _, prop := range material.Properties {
+		name := strings.ToLower(prop.Name)
+		if name == "e_texturediffuse0" || (name == "e_textureglow0" && isUnlit) {
+			continue
+		}
+		label, ok := eqgPropertyNames[name]
+		if !ok {
+			label = prop.Name
+		}
+		notes = append(notes, label+" dropped")
+	}
+	def := &MaterialDef{
+		folders:       []string{folder},
+		Tag:           eqgMaterialTag(material.Tag),
+		RenderMethod:  renderMethod,
+		RGBPen:        [4]uint8{178, 178, 178, 0},
+		ScaledAmbient: 0.75,
+	}
+
+	textures := material.AnimationTextures
+	if len(textures) == 0 {
+		for _, prop := range material.Properties {
+			if prop.Type == raw.MaterialParamTypeTexture && strings.EqualFold(prop.Name, "e_TextureDiffuse0") {
+				textures = []string{prop.Value}
+				break
+			}
+		}
+	}
+	if len(textures) == 0 {
+		return def, nil, notes
+	}
+	sprite := &SimpleSpriteDef{
+		folders: []string{folder},
+		Tag:     strings.ToUpper(material.Tag) + "_SPRITE",
+	}
+	for _, texture := range textures {
+		sprite.SimpleSpriteFrames = append(sprite.SimpleSpriteFrames, SimpleSpriteFrame{
+			TextureTag:   strings.ToUpper(strings.TrimSuffix(texture, filepath.Ext(texture))),
+			TextureFiles: []string{strings.ToUpper(texture)},
+		})
+	}
+	if len(textures) > 1 {
+		// animated textures cycle through every frame
+		sprite.Sleep.Valid = true
+		sprite.Sleep.Uint32 = material.AnimationSleep
+	}
+	def.SimpleSpriteTag = sprite.Tag
+	return def, sprite, notes
+}
+
+// materialNotes returns what a material lost while converting between formats as one note, or
+// none if nothing was lost
+func materialNotes(tag string, notes []string) []string {
+	if len(notes) == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("material %s: %s", tag, strings.Join(notes, ", "))}
+}
@@ -0,0 +1,42 @@
+package wce
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xackery/quail/raw"
+)
+
+func TestMaterialConvertProperties(t *testing.T) {
+	rock := &EQMaterialDef{
+		Tag:       "rock",
+		ShaderTag: "Opaque_MaxCBSE1.fx",
+		Properties: []*MaterialProperty{
+			{Name: "e_TextureDiffuse0", Type: raw.MaterialParamTypeTexture, Value: "rock.dds"},
+			{Name: "e_TextureNormal0", Type: raw.MaterialParamTypeTexture, Value: "rock_n.dds"},
+			{Name: "e_TextureEnvironment0", Type: raw.MaterialParamTypeTexture, Value: "sky.dds"},
+			{Name: "e_fShininess0", Type: raw.MaterialParamTypeUnused, Value: "12"},
+		},
+	}
+	_, _, notes := EqgMaterialToWld(rock, "world")
+	got := strings.Join(notes, ", ")
+	if got != "normal map dropped, environment map dropped, shininess dropped" {
+		t.Fatalf("got notes %q", got)
+	}
+
+	// unlit surfaces glow with their diffuse texture, which converts back without loss
+	wld := New("test")
+	wld.SimpleSpriteDefs = append(wld.SimpleSpriteDefs, &SimpleSpriteDef{
+		Tag:                "SPARK_SPRITE",
+		SimpleSpriteFrames: []SimpleSpriteFrame{{TextureTag: "SPARK", TextureFiles: []string{"SPARK.BMP"}}},
+	})
+	spark := &MaterialDef{Tag: "SPARK_MDF", RenderMethod: "USERDEFINED_12", SimpleSpriteTag: "SPARK_SPRITE"}
+	eqg, notes := wld.MaterialDefToEqg(spark)
+	if len(notes) != 0 || eqg.ShaderTag != "AddAlpha_MaxCG1.fx" || len(eqg.Properties) != 2 || eqg.Properties[1].Name != "e_TextureGlow0" || eqg.Properties[1].Value != "spark.bmp" {
+		t.Fatalf("got shader %s properties %d notes %v", eqg.ShaderTag, len(eqg.Properties), notes)
+	}
+	back, _, notes := EqgMaterialToWld(eqg, "world")
+	if len(notes) != 0 || back.RenderMethod != "USERDEFINED_12" {
+		t.Fatalf("got render method %s notes %v", back.RenderMethod, notes)
+	}
+}
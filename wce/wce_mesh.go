@@ -0,0 +1,241 @@
+package wce
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/xackery/quail/mesh"
+)
+
+// mesh returns the sprite geometry, with face groups set to material indexes
+func (e *DMSpriteDef2) mesh() *mesh.Mesh {
+	m := &mesh.Mesh{Positions: e.Vertices, Normals: e.VertexNormals}
+	materials := mesh.Expand(e.FaceMaterialGroups)
+	for i, face := range e.Faces {
+		meshFace := &mesh.Face{Index: [3]uint32{uint32(face.Triangle[0]), uint32(face.Triangle[1]), uint32(face.Triangle[2])}}
+		if i < len(materials) {
+			meshFace.Group = materials[i]
+		}
+		m.Faces = append(m.Faces, meshFace)
+	}
+	return m
+}
+
+// meshApply copies mesh geometry back into the sprite, reordering every per vertex and per face
+// value by the keep lists. Faces index vertices as uint16, so the sprite is left unchanged if the
+// mesh has more vertices than that. The frames of a DMTRACKDEF2 and mesh ops index the vertices
+// too, so a sprite with either is left unchanged if its vertices would move
+func (e *DMSpriteDef2) meshApply(m *mesh.Mesh, vertexKeep []int, faceKeep []int) error {
+	if len(m.Positions) > math.MaxUint16 {
+		return fmt.Errorf("%s would have %d vertices, max is %d", e.Tag, len(m.Positions), math.MaxUint16)
+	}
+	vertexCount := len(e.Vertices)
+	if vertexCount != len(m.Positions) || !isIdentity(vertexKeep) {
+		if e.DmTrackTag != "" {
+			return fmt.Errorf("%s vertices would move, which breaks the frames of %s", e.Tag, e.DmTrackTag)
+		}
+		if len(e.MeshOps) > 0 {
+			return fmt.Errorf("%s vertices would move, which breaks its %d mesh ops", e.Tag, len(e.MeshOps))
+		}
+	}
+	bones := mesh.Expand(e.SkinAssignmentGroups)
+	vertexMaterials := mesh.Expand(e.VertexMaterialGroups)
+
+	e.UVs = mesh.Reorder(e.UVs, vertexKeep, vertexCount)
+	e.VertexColors = mesh.Reorder(e.VertexColors, vertexKeep, vertexCount)
+	if len(bones) == vertexCount {
+		e.SkinAssignmentGroups = runsInt16(mesh.Runs(mesh.Reorder(bones, vertexKeep, vertexCount)))
+	}
+	if len(vertexMaterials) == vertexCount {
+		e.VertexMaterialGroups = runsInt16(mesh.Runs(mesh.Reorder(vertexMaterials, vertexKeep, vertexCount)))
+	}
+
+	faces := make([]*Face, 0, len(m.Faces))
+	groups := make([]int, 0, len(m.Faces))
+	for i, meshFace := range m.Faces {
+		faces = append(faces, &Face{
+			Passable: e.Faces[faceKeep[i]].Passable,
+			Triangle: [3]uint16{uint16(meshFace.Index[0]), uint16(meshFace.Index[1]), uint16(meshFace.Index[2])},
+		})
+		groups = append(groups, meshFace.Group)
+	}
+	if len(e.FaceMaterialGroups) > 0 {
+		e.FaceMaterialGroups = runsUint16(mesh.Runs(groups))
+	}
+	e.Faces = faces
+	e.Vertices = m.Positions
+	if len(e.VertexNormals) > 0 || len(m.Normals) == len(m.Positions) {
+		e.VertexNormals = m.Normals
+	}
+	return nil
+}
+
+// Weld merges duplicate vertices within tolerance that share uv, color and bone
+func (e *DMSpriteDef2) Weld(tolerance float32) error {
+	m := e.mesh()
+	bones := mesh.Expand(e.SkinAssignmentGroups)
+	isSame := func(a int, b int) bool {
+		if len(e.UVs) == len(e.Vertices) && e.UVs[a] != e.UVs[b] {
+			return false
+		}
+		if len(e.VertexColors) == len(e.Vertices) && e.VertexColors[a] != e.VertexColors[b] {
+			return false
+		}
+		if len(bones) == len(e.Vertices) && bones[a] != bones[b] {
+			return false
+		}
+		return true
+	}
+	vertexKeep, faceKeep := m.Weld(tolerance, isSame)
+	return e.meshApply(m, vertexKeep, faceKeep)
+}
+
+// RecomputeNormals sets vertex normals from faces, flat normals split every face into its own vertices
+func (e *DMSpriteDef2) RecomputeNormals(isFlat bool) error {
+	m := e.mesh()
+	if !isFlat {
+		m.SmoothNormals()
+		e.VertexNormals = m.Normals
+		return nil
+	}
+	vertexKeep := m.FlatNormals()
+	return e.meshApply(m, vertexKeep, identity(len(e.Faces)))
+}
+
+// SortGroups orders faces by material and vertices by bone, rebuilding FaceMaterialGroups and
+// SkinAssignmentGroups so each material and bone is one contiguous run
+func (e *DMSpriteDef2) SortGroups() error {
+	m := e.mesh()
+	faceKeep, _ := m.SortFaces()
+	vertexKeep := identity(len(e.Vertices))
+	bones := mesh.Expand(e.SkinAssignmentGroups)
+	if len(bones) == len(e.Vertices) {
+		vertexKeep, _ = m.SortVertices(bones)
+	}
+	if len(e.FaceMaterialGroups) == 0 && len(m.Faces) > 0 {
+		// faces without material groups all use material 0
+		e.FaceMaterialGroups = [][2]uint16{{uint16(len(m.Faces)), 0}}
+	}
+	return e.meshApply(m, vertexKeep, faceKeep)
+}
+
+// RecomputeBounds sets the bounding box and radius from the vertices, which are relative to CenterOffset
+func (e *DMSpriteDef2) RecomputeBounds() {
+	m := &mesh.Mesh{Positions: e.Vertices}
+	min, max := m.Bounds()
+	e.BoundingBoxMin = vecAdd(min, e.CenterOffset)
+	e.BoundingBoxMax = vecAdd(max, e.CenterOffset)
+	e.BoundingRadius = m.BoundingRadius([3]float32{})
+}
+
+// Weld merges duplicate vertices within tolerance that share uvs, tint and bone weights
+func (e *EqgModDef) Weld(tolerance float32) {
+	e.Vertices, e.Faces = modWeld(e.Vertices, e.Faces, tolerance)
+}
+
+// RecomputeNormals sets vertex normals from faces, flat normals split every face into its own vertices
+func (e *EqgModDef) RecomputeNormals(isFlat bool) {
+	e.Vertices, e.Faces = modNormals(e.Vertices, e.Faces, isFlat)
+}
+
+// Weld merges duplicate vertices within tolerance that share uvs and tint
+func (e *EqgTerDef) Weld(tolerance float32) {
+	e.Vertices, e.Faces = modWeld(e.Vertices, e.Faces, tolerance)
+}
+
+// RecomputeNormals sets vertex normals from faces, flat normals split every face into its own vertices
+func (e *EqgTerDef) RecomputeNormals(isFlat bool) {
+	e.Vertices, e.Faces = modNormals(e.Vertices, e.Faces, isFlat)
+}
+
+func modMesh(vertices []*ModVertex, faces []*ModFace) *mesh.Mesh {
+	m := &mesh.Mesh{}
+	for _, v := range vertices {
+		m.Positions = append(m.Positions, v.Position)
+		m.Normals = append(m.Normals, v.Normal)
+	}
+	for _, face := range faces {
+		m.Faces = append(m.Faces, &mesh.Face{Index: face.Index})
+	}
+	return m
+}
+
+// modApply builds new vertices and faces from mesh geometry and the keep lists
+func modApply(vertices []*ModVertex, faces []*ModFace, m *mesh.Mesh, vertexKeep []int, faceKeep []int) ([]*ModVertex, []*ModFace) {
+	newVertices := make([]*ModVertex, 0, len(vertexKeep))
+	for i, old := range vertexKeep {
+		v := *vertices[old]
+		v.Position = m.Positions[i]
+		v.Normal = m.Normals[i]
+		newVertices = append(newVertices, &v)
+	}
+	newFaces := make([]*ModFace, 0, len(faceKeep))
+	for i, old := range faceKeep {
+		face := *faces[old]
+		face.Index = m.Faces[i].Index
+		newFaces = append(newFaces, &face)
+	}
+	return newVertices, newFaces
+}
+
+func modWeld(vertices []*ModVertex, faces []*ModFace, tolerance float32) ([]*ModVertex, []*ModFace) {
+	m := modMesh(vertices, faces)
+	isSame := func(a int, b int) bool {
+		va := vertices[a]
+		vb := vertices[b]
+		if va.Uv != vb.Uv || va.Uv2 != vb.Uv2 || va.Tint != vb.Tint || len(va.Weights) != len(vb.Weights) {
+			return false
+		}
+		for i := range va.Weights {
+			if *va.Weights[i] != *vb.Weights[i] {
+				return false
+			}
+		}
+		return true
+	}
+	vertexKeep, faceKeep := m.Weld(tolerance, isSame)
+	return modApply(vertices, faces, m, vertexKeep, faceKeep)
+}
+
+func modNormals(vertices []*ModVertex, faces []*ModFace, isFlat bool) ([]*ModVertex, []*ModFace) {
+	m := modMesh(vertices, faces)
+	if !isFlat {
+		m.SmoothNormals()
+		return modApply(vertices, faces, m, identity(len(vertices)), identity(len(faces)))
+	}
+	vertexKeep := m.FlatNormals()
+	return modApply(vertices, faces, m, vertexKeep, identity(len(faces)))
+}
+
+func identity(size int) []int {
+	keep := make([]int, size)
+	for i := range keep {
+		keep[i] = i
+	}
+	return keep
+}
+
+func isIdentity(keep []int) bool {
+	for i, index := range keep {
+		if i != index {
+			return false
+		}
+	}
+	return true
+}
+
+func runsUint16(runs [][2]int) [][2]uint16 {
+	out := make([][2]uint16, len(runs))
+	for i, run := range runs {
+		out[i] = [2]uint16{uint16(run[0]), uint16(run[1])}
+	}
+	return out
+}
+
+func runsInt16(runs [][2]int) [][2]int16 {
+	out := make([][2]int16, len(runs))
+	for i, run := range runs {
+		out[i] = [2]int16{int16(run[0]), int16(run[1])}
+	}
+	return out
+}
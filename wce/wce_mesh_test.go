@@ -0,0 +1,113 @@
+package wce
+
+import (
+	"testing"
+)
+
+func TestDMSpriteDef2Mesh(t *testing.T) {
+	sprite := &DMSpriteDef2{
+		Vertices:             [][3]float32{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 0, 0}, {1, 1, 0}, {0, 1, 0}},
+		UVs:                  [][2]float32{{0, 0}, {1, 0}, {1, 1}, {0, 0}, {1, 1}, {0, 1}},
+		SkinAssignmentGroups: [][2]int16{{3, 1}, {3, 0}},
+		Faces:                []*Face{{Passable: 1, Triangle: [3]uint16{0, 1, 2}}, {Triangle: [3]uint16{3, 4, 5}}},
+		FaceMaterialGroups:   [][2]uint16{{1, 2}, {1, 0}},
+		MeshOps:              []*MeshOp{{}},
+		DmTrackTag:           "TEST_DMTRACKDEF",
+	}
+
+	// mesh ops and track frames index the vertices, so moving them is an error
+	err := sprite.SortGroups()
+	if err == nil {
+		t.Fatalf("expected track error")
+	}
+	sprite.DmTrackTag = ""
+	err = sprite.SortGroups()
+	if err == nil {
+		t.Fatalf("expected mesh ops error")
+	}
+	if sprite.Vertices[2] != [3]float32{1, 1, 0} || len(sprite.MeshOps) != 1 {
+		t.Fatalf("sprite changed on error")
+	}
+	sprite.MeshOps = nil
+
+	err = sprite.SortGroups()
+	if err != nil {
+		t.Fatalf("sort groups: %s", err)
+	}
+	if sprite.FaceMaterialGroups[0] != [2]uint16{1, 0} || sprite.Faces[0].Passable != 0 || sprite.Faces[1].Passable != 1 {
+		t.Fatalf("faces not sorted by material: %v", sprite.FaceMaterialGroups)
+	}
+	if sprite.SkinAssignmentGroups[0] != [2]int16{3, 0} || sprite.Vertices[2] != [3]float32{0, 1, 0} {
+		t.Fatalf("vertices not sorted by bone: %v", sprite.SkinAssignmentGroups)
+	}
+
+	// vertices on different bones never weld
+	err = sprite.Weld(0.001)
+	if err != nil {
+		t.Fatalf("weld: %s", err)
+	}
+	if len(sprite.Vertices) != 6 {
+		t.Fatalf("welded across bones, got %d vertices", len(sprite.Vertices))
+	}
+	sprite.SkinAssignmentGroups = nil
+	err = sprite.Weld(0.001)
+	if err != nil {
+		t.Fatalf("weld: %s", err)
+	}
+	if len(sprite.Vertices) != 4 || len(sprite.UVs) != 4 {
+		t.Fatalf("got %d vertices %d uvs, want 4", len(sprite.Vertices), len(sprite.UVs))
+	}
+
+	err = sprite.RecomputeNormals(false)
+	if err != nil {
+		t.Fatalf("recompute normals: %s", err)
+	}
+	if len(sprite.VertexNormals) != 4 || sprite.VertexNormals[0][2] < 0.99 {
+		t.Fatalf("normals got %v", sprite.VertexNormals)
+	}
+
+	sprite.CenterOffset = [3]float32{10, 0, 0}
+	sprite.RecomputeBounds()
+	if sprite.BoundingBoxMin != [3]float32{10, 0, 0} || sprite.BoundingBoxMax != [3]float32{11, 1, 0} {
+		t.Fatalf("bounds got %v %v", sprite.BoundingBoxMin, sprite.BoundingBoxMax)
+	}
+}
+
+func TestDMSpriteDef2MeshVertexLimit(t *testing.T) {
+	sprite := &DMSpriteDef2{
+		Tag:      "BIG_DMSPRITEDEF",
+		Vertices: [][3]float32{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}},
+	}
+	// flat normals give each face its own 3 vertices, past what uint16 faces can index
+	for i := 0; i < 21846; i++ {
+		sprite.Faces = append(sprite.Faces, &Face{Triangle: [3]uint16{0, 1, 2}})
+	}
+	err := sprite.RecomputeNormals(true)
+	if err == nil {
+		t.Fatalf("expected vertex limit error")
+	}
+	if len(sprite.Vertices) != 3 || sprite.Faces[21845].Triangle != [3]uint16{0, 1, 2} {
+		t.Fatalf("sprite changed on error, got %d vertices", len(sprite.Vertices))
+	}
+}
+
+func TestEqgModDefMesh(t *testing.T) {
+	mod := &EqgModDef{
+		Vertices: []*ModVertex{
+			{Position: [3]float32{0, 0, 0}}, {Position: [3]float32{1, 0, 0}}, {Position: [3]float32{1, 1, 0}},
+			{Position: [3]float32{0, 0, 0}}, {Position: [3]float32{1, 1, 0}}, {Position: [3]float32{0, 1, 0}},
+		},
+		Faces: []*ModFace{
+			{Index: [3]uint32{0, 1, 2}, MaterialName: "a"},
+			{Index: [3]uint32{3, 4, 5}, MaterialName: "b"},
+		},
+	}
+	mod.Weld(0.001)
+	if len(mod.Vertices) != 4 || mod.Faces[1].MaterialName != "b" || mod.Faces[1].Index != [3]uint32{0, 2, 3} {
+		t.Fatalf("weld got %d vertices, face %v", len(mod.Vertices), mod.Faces[1])
+	}
+	mod.RecomputeNormals(true)
+	if len(mod.Vertices) != 6 || mod.Vertices[5].Normal[2] < 0.99 {
+		t.Fatalf("flat normals got %d vertices", len(mod.Vertices))
+	}
+}
@@ -0,0 +1,102 @@
+package wce
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LintIssue is a problem found in a file of an archive
+type LintIssue struct {
+	File   string
+	Name   string
+	Reason string
+}
+
+// ParticleLint checks the particle points of each .pts against the bones of the model of the same
+// name, that each point is played by the .prt of that model, and that each .prt entry plays a known
+// point and emitter. Emitters are numbered as in EmitterByID, and with no emitters the emitter check
+// is skipped
+func (wce *Wce) ParticleLint() []*LintIssue {
+	issues := []*LintIssue{}
+	modelBones := func(tag string) (map[string]bool, bool) {
+		bones := map[string]bool{}
+		for _, mds := range wce.MdsDefs {
+			if !strings.EqualFold(mds.Tag, tag) {
+				continue
+			}
+			for _, bone := range mds.Bones {
+				bones[strings.ToLower(bone.Name)] = true
+			}
+			return bones, true
+		}
+		for _, mod := range wce.ModDefs {
+			if !strings.EqualFold(mod.Tag, tag) {
+				continue
+			}
+			for _, bone := range mod.Bones {
+				bones[strings.ToLower(bone.Name)] = true
+			}
+			return bones, true
+		}
+		return nil, false
+	}
+
+	// points are looked up by model, then point name
+	points := map[string]map[string]bool{}
+	for _, pts := range wce.PtsDefs {
+		file := pts.Tag + ".pts"
+		tag := strings.ToLower(pts.Tag)
+		points[tag] = map[string]bool{}
+		bones, ok := modelBones(pts.Tag)
+		if !ok {
+			issues = append(issues, &LintIssue{File: file, Reason: fmt.Sprintf("no model named %s", pts.Tag)})
+		}
+		for _, point := range pts.Points {
+			points[tag][strings.ToLower(point.Name)] = true
+			if ok && point.BoneName != "" && !bones[strings.ToLower(point.BoneName)] {
+				issues = append(issues, &LintIssue{File: file, Name: point.Name, Reason: fmt.Sprintf("bone %s not found", point.BoneName)})
+			}
+		}
+	}
+
+	played := map[string]map[string]bool{}
+	for _, prt := range wce.PrtDefs {
+		file := prt.Tag + ".prt"
+		tag := strings.ToLower(prt.Tag)
+		if played[tag] == nil {
+			played[tag] = map[string]bool{}
+		}
+		for i, render := range prt.Renders {
+			name := fmt.Sprintf("entry %d", i)
+			pointName := strings.ToLower(render.ParticlePoint)
+			played[tag][pointName] = true
+			if !points[tag][pointName] {
+				issues = append(issues, &LintIssue{File: file, Name: name, Reason: fmt.Sprintf("particle point %s not found", render.ParticlePoint)})
+			}
+			if len(wce.EmitterDefs) == 0 {
+				continue
+			}
+			if wce.EmitterByID(render.EmitterID) == nil {
+				issues = append(issues, &LintIssue{File: file, Name: name, Reason: fmt.Sprintf("emitter %d not found", render.EmitterID)})
+			}
+			if render.ColdEmitterID != 0 && wce.EmitterByID(render.ColdEmitterID) == nil {
+				issues = append(issues, &LintIssue{File: file, Name: name, Reason: fmt.Sprintf("cold emitter %d not found", render.ColdEmitterID)})
+			}
+		}
+	}
+
+	for _, pts := range wce.PtsDefs {
+		tag := strings.ToLower(pts.Tag)
+		for _, point := range pts.Points {
+			if !played[tag][strings.ToLower(point.Name)] {
+				issues = append(issues, &LintIssue{File: pts.Tag + ".pts", Name: point.Name, Reason: "not played by any particle render"})
+			}
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].File < issues[j].File
+	})
+	return issues
+}
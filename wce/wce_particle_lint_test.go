@@ -0,0 +1,47 @@
+package wce
+
+import (
+	"testing"
+)
+
+func TestParticleLint(t *testing.T) {
+	eqg := New("torch.eqg")
+	eqg.MdsDefs = append(eqg.MdsDefs, &EqgMdsDef{Tag: "torch", Bones: []*MdsBone{{Name: "root"}, {Name: "TIP"}}})
+	eqg.PtsDefs = append(eqg.PtsDefs,
+		&EqgParticlePointDef{Tag: "torch", Points: []*ParticlePointEntry{
+			{Name: "tip_fire", BoneName: "tip"},
+			{Name: "hand_smoke", BoneName: "hand"},
+		}},
+		&EqgParticlePointDef{Tag: "lamp"},
+	)
+	eqg.PrtDefs = append(eqg.PrtDefs, &EqgParticleRenderDef{Tag: "torch", Renders: []*ParticleRenderEntry{
+		{EmitterID: 1, ParticlePoint: "tip_fire"},
+		{EmitterID: 3, ParticlePoint: "wick"},
+	}})
+
+	issues := eqg.ParticleLint()
+	reasons := map[string]bool{}
+	for _, issue := range issues {
+		reasons[issue.File+" "+issue.Name+": "+issue.Reason] = true
+	}
+	want := []string{
+		"lamp.pts : no model named lamp",
+		"torch.pts hand_smoke: bone hand not found",
+		"torch.pts hand_smoke: not played by any particle render",
+		"torch.prt entry 1: particle point wick not found",
+	}
+	for _, w := range want {
+		if !reasons[w] {
+			t.Fatalf("issue %q not reported in %v", w, reasons)
+		}
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("got %d issues, want %d without emitters", len(issues), len(want))
+	}
+
+	eqg.EmitterDefs = append(eqg.EmitterDefs, &EmitterDef{Name: "fire"})
+	issues = eqg.ParticleLint()
+	if len(issues) != len(want)+1 || issues[2].Reason != "emitter 3 not found" {
+		t.Fatalf("unknown emitter 3 not reported")
+	}
+}
@@ -0,0 +1,77 @@
+package wce
+
+import (
+	"sort"
+	"strings"
+)
+
+// AnimatedTexture is a texture that cycles through frames
+type AnimatedTexture struct {
+	Definition string   // definition holding the animation, like SIMPLESPRITEDEF
+	Owner      string   // model holding the material, empty for wld sprites
+	Tag        string   // sprite or material tag
+	Sleep      uint32   // milliseconds each frame shows
+	Frames     []string // texture file of each frame
+}
+
+// Cycle returns how many milliseconds one loop through every frame takes
+func (e *AnimatedTexture) Cycle() uint32 {
+	return e.Sleep * uint32(len(e.Frames))
+}
+
+// AnimatedTextures returns every texture with more than one frame, from SIMPLESPRITEDEFs and
+// the materials of eqg models and terrain
+func (wce *Wce) AnimatedTextures() []*AnimatedTexture {
+	textures := []*AnimatedTexture{}
+	for _, sprite := range wce.SimpleSpriteDefs {
+		frames := simpleSpriteFrameFiles(sprite)
+		if len(frames) < 2 {
+			continue
+		}
+		texture := &AnimatedTexture{
+			Definition: sprite.Definition(),
+			Tag:        sprite.Tag,
+			Frames:     frames,
+		}
+		if sprite.Sleep.Valid {
+			texture.Sleep = sprite.Sleep.Uint32
+		}
+		textures = append(textures, texture)
+	}
+
+	eqgTextures := func(definition string, owner string, materials []*EQMaterialDef) {
+		for _, material := range materials {
+			if len(material.AnimationTextures) < 2 {
+				continue
+			}
+			frames := []string{}
+			for _, frame := range material.AnimationTextures {
+				frames = append(frames, strings.ToLower(frame))
+			}
+			textures = append(textures, &AnimatedTexture{
+				Definition: definition,
+				Owner:      owner,
+				Tag:        material.Tag,
+				Sleep:      material.AnimationSleep,
+				Frames:     frames,
+			})
+		}
+	}
+	for _, mod := range wce.ModDefs {
+		eqgTextures(mod.Definition(), mod.Tag, mod.Materials)
+	}
+	for _, mds := range wce.MdsDefs {
+		eqgTextures(mds.Definition(), mds.Tag, mds.Materials)
+	}
+	for _, ter := range wce.TerDefs {
+		eqgTextures(ter.Definition(), ter.Tag, ter.Materials)
+	}
+
+	sort.SliceStable(textures, func(i, j int) bool {
+		if textures[i].Owner != textures[j].Owner {
+			return textures[i].Owner < textures[j].Owner
+		}
+		return textures[i].Tag < textures[j].Tag
+	})
+	return textures
+}
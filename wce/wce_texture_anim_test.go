@@ -0,0 +1,45 @@
+package wce
+
+import (
+	"testing"
+
+	"github.com/xackery/quail/raw"
+)
+
+func TestAnimatedTextureConvert(t *testing.T) {
+	lava := &EQMaterialDef{
+		Tag:               "lava",
+		ShaderTag:         "Opaque_MaxCB1.fx",
+		Properties:        []*MaterialProperty{{Name: "e_TextureDiffuse0", Type: raw.MaterialParamTypeTexture, Value: "lava1.dds"}},
+		AnimationSleep:    150,
+		AnimationTextures: []string{"lava1.dds", "lava2.dds", "lava3.dds"},
+	}
+
+	def, sprite, _ := EqgMaterialToWld(lava, "world")
+	if sprite == nil || len(sprite.SimpleSpriteFrames) != 3 || !sprite.Sleep.Valid || sprite.Sleep.Uint32 != 150 {
+		t.Fatalf("animation not carried to sprite frames")
+	}
+	if sprite.SimpleSpriteFrames[1].TextureFiles[0] != "LAVA2.DDS" {
+		t.Fatalf("frame 1 is %v, want LAVA2.DDS", sprite.SimpleSpriteFrames[1].TextureFiles)
+	}
+
+	wld := New("test")
+	wld.MaterialDefs = append(wld.MaterialDefs, def)
+	wld.SimpleSpriteDefs = append(wld.SimpleSpriteDefs, sprite)
+	back, _ := wld.MaterialDefToEqg(def)
+	if back.AnimationSleep != 150 || len(back.AnimationTextures) != 3 || back.AnimationTextures[2] != "lava3.dds" {
+		t.Fatalf("animation not carried back, got sleep %d textures %v", back.AnimationSleep, back.AnimationTextures)
+	}
+	if back.Properties[0].Value != "lava1.dds" {
+		t.Fatalf("diffuse %s, want lava1.dds", back.Properties[0].Value)
+	}
+
+	wld.ModDefs = append(wld.ModDefs, &EqgModDef{Tag: "pool", Materials: []*EQMaterialDef{lava}})
+	textures := wld.AnimatedTextures()
+	if len(textures) != 2 {
+		t.Fatalf("got %d animated textures, want 2", len(textures))
+	}
+	if textures[0].Tag != "LAVA_SPRITE" || textures[1].Owner != "pool" || textures[1].Cycle() != 450 {
+		t.Fatalf("unexpected animated textures %+v %+v", textures[0], textures[1])
+	}
+}
@@ -0,0 +1,57 @@
+package wce
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/xackery/quail/helper"
+	"github.com/xackery/quail/raw"
+)
+
+func TestEqgTogDefReadWrite(t *testing.T) {
+	data := []byte("// \"farstone\" lamps\r\n*BEGIN_OBJECTGROUP\r\n\t*BEGIN_OBJECT\r\n\t\t*NAME obj_lamp01\r\n\t\t*POSITION 1 2 3\r\n" +
+		"\t\t*LIGHT lamp_flicker\r\n\t\t*FILE MOD obj_lamp01\r\n\t*END_OBJECT\r\n*END_OBJECTGROUP\r\n")
+	src := &raw.Tog{MetaFileName: "farstone_camp"}
+	err := src.Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("raw read: %s", err.Error())
+	}
+
+	wce := New("arcstone.eqg")
+	def := &EqgTogDef{}
+	err = def.FromRaw(wce, src)
+	if err != nil {
+		t.Fatalf("from raw: %s", err.Error())
+	}
+	wce.TogDefs = append(wce.TogDefs, def)
+
+	dir := filepath.Join(t.TempDir(), "arcstone.quail")
+	err = wce.WriteAscii(dir)
+	if err != nil {
+		t.Fatalf("write ascii: %s", err.Error())
+	}
+	wce2 := New("arcstone.eqg")
+	err = wce2.ReadAscii(filepath.Join(dir, "_root.wce"))
+	if err != nil {
+		t.Fatalf("read ascii: %s", err.Error())
+	}
+	if len(wce2.TogDefs) != 1 {
+		t.Fatalf("got %d tog defs, want 1", len(wce2.TogDefs))
+	}
+
+	dst := &raw.Tog{}
+	err = wce2.TogDefs[0].ToRaw(wce2, dst)
+	if err != nil {
+		t.Fatalf("to raw: %s", err.Error())
+	}
+	buf := &bytes.Buffer{}
+	err = dst.Write(buf)
+	if err != nil {
+		t.Fatalf("raw write: %s", err.Error())
+	}
+	err = helper.ByteCompareTest(data, buf.Bytes())
+	if err != nil {
+		t.Fatalf("byteCompare: %s", err)
+	}
+}
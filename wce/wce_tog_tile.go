@@ -0,0 +1,32 @@
+package wce
+
+import (
+	"strings"
+
+	"github.com/xackery/quail/raw"
+)
+
+// TogTile is a toggled object group placed by a v4 zone .dat tile
+type TogTile struct {
+	Lng int32
+	Lat int32
+	Ref *raw.DatZonTogRef
+	Tog *EqgTogDef // nil when no loaded .tog matches the reference
+}
+
+// TogTiles links every tile TogRef of a v4 zone dat to the .tog it names
+func (wce *Wce) TogTiles(dat *raw.DatZon) []*TogTile {
+	togs := map[string]*EqgTogDef{}
+	for _, tog := range wce.TogDefs {
+		togs[strings.ToLower(tog.Tag)] = tog
+	}
+
+	out := []*TogTile{}
+	for _, tile := range dat.Tiles {
+		for _, ref := range tile.TogRefs {
+			name := strings.TrimSuffix(strings.ToLower(ref.Name), ".tog")
+			out = append(out, &TogTile{Lng: tile.Lng, Lat: tile.Lat, Ref: ref, Tog: togs[name]})
+		}
+	}
+	return out
+}
@@ -0,0 +1,30 @@
+package wce
+
+import (
+	"testing"
+
+	"github.com/xackery/quail/raw"
+)
+
+func TestTogTiles(t *testing.T) {
+	wce := New("arcstone.eqg")
+	wce.TogDefs = []*EqgTogDef{{Tag: "farstone_camp", Entries: []*TogEntry{{Name: "obj_tent"}}}}
+
+	dat := &raw.DatZon{
+		Tiles: []*raw.DatZonTile{
+			{Lng: 100001, Lat: 100002, TogRefs: []*raw.DatZonTogRef{{Name: "FARSTONE_CAMP.tog"}, {Name: "missing"}}},
+			{Lng: 100003, Lat: 100004},
+		},
+	}
+
+	tiles := wce.TogTiles(dat)
+	if len(tiles) != 2 {
+		t.Fatalf("got %d tog tiles, want 2", len(tiles))
+	}
+	if tiles[0].Tog != wce.TogDefs[0] || tiles[0].Lng != 100001 || tiles[0].Lat != 100002 {
+		t.Fatalf("tog tile 0 not linked: %+v", tiles[0])
+	}
+	if tiles[1].Tog != nil {
+		t.Fatalf("missing tog should not link")
+	}
+}
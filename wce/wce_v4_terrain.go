@@ -0,0 +1,125 @@
+package wce
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xackery/quail/raw"
+)
+
+// v4TerrainHoleFlag marks a quad of a v4 zone .dat tile that has no terrain
+const v4TerrainHoleFlag = 0x01
+
+// FromV4 builds terrain from the tiles of a v4 zone .dat, placed by the bounds of the zone .zon.
+// Each tile is QuadsPerTile by QuadsPerTile quads of two triangles, quads flagged as holes are
+// left out. Vertices keep the tile vertex color as tint, uv spans the tile so it lines up with
+// the layer detail masks, and uv2 repeats FallbackDetailRepeat times per tile for detail textures.
+// Faces use the tile base layer material. Tiles outside of the zone bounds are skipped
+func (e *EqgTerDef) FromV4(wce *Wce, info *raw.V4Info, dat *raw.DatZon) error {
+	if info.QuadsPerTile < 1 {
+		return fmt.Errorf("quads per tile %d must be at least 1", info.QuadsPerTile)
+	}
+	if info.UnitsPerVert <= 0 {
+		return fmt.Errorf("units per vert %0.2f must be above 0", info.UnitsPerVert)
+	}
+	if dat.QuadsPerTile != info.QuadsPerTile {
+		return fmt.Errorf("dat quads per tile %d does not match zon %d", dat.QuadsPerTile, info.QuadsPerTile)
+	}
+
+	e.Tag = strings.TrimSuffix(dat.FileName(), ".dat")
+	e.folders = append(e.folders, "ter/"+e.Tag)
+	e.Version = 3
+
+	q := info.QuadsPerTile
+	tileSize := info.UnitsPerVert * float32(q)
+	zoneMin := [2]float32{float32(info.MinLat) * tileSize, float32(info.MinLng) * tileSize}
+	repeat := float32(dat.FallbackDetailRepeat)
+	if repeat == 0 {
+		repeat = 1
+	}
+
+	materials := map[string]bool{}
+	for _, tile := range dat.Tiles {
+		lat := int(tile.Lat) - 100000
+		lng := int(tile.Lng) - 100000
+		if lat < info.MinLat || lat > info.MaxLat || lng < info.MinLng || lng > info.MaxLng {
+			continue
+		}
+		vertCount := (q + 1) * (q + 1)
+		if len(tile.Floats) != vertCount || len(tile.Colors) != vertCount || len(tile.Flags) != q*q {
+			return fmt.Errorf("tile %d,%d has %d heights, %d colors and %d flags, wanted %d, %d and %d", tile.Lng, tile.Lat, len(tile.Floats), len(tile.Colors), len(tile.Flags), vertCount, vertCount, q*q)
+		}
+
+		material := tile.LayerBaseMaterial
+		if material == "" {
+			material = dat.FallbackDetailMapName
+		}
+		if material != "" && !materials[material] {
+			materials[material] = true
+			e.Materials = append(e.Materials, &EQMaterialDef{Tag: material})
+		}
+
+		startX := zoneMin[0] + float32(lat-info.MinLat)*tileSize
+		startY := zoneMin[1] + float32(lng-info.MinLng)*tileSize
+
+		// tile vertices are only added once a face uses them
+		remap := make([]int, vertCount)
+		for i := range remap {
+			remap[i] = -1
+		}
+		vertex := func(row int, col int) uint32 {
+			index := row*(q+1) + col
+			if remap[index] >= 0 {
+				return uint32(remap[index])
+			}
+			remap[index] = len(e.Vertices)
+			c := tile.Colors[index]
+			uv := [2]float32{float32(col) / float32(q), float32(row) / float32(q)}
+			e.Vertices = append(e.Vertices, &ModVertex{
+				Position: [3]float32{startX + float32(col)*info.UnitsPerVert, startY + float32(row)*info.UnitsPerVert, tile.Floats[index]},
+				Tint:     [4]uint8{uint8(c >> 16), uint8(c >> 8), uint8(c), uint8(c >> 24)},
+				Uv:       uv,
+				Uv2:      [2]float32{uv[0] * repeat, uv[1] * repeat},
+			})
+			return uint32(remap[index])
+		}
+
+		for row := 0; row < q; row++ {
+			for col := 0; col < q; col++ {
+				if tile.Flags[row*q+col]&v4TerrainHoleFlag != 0 {
+					continue
+				}
+				a := vertex(row, col)
+				b := vertex(row, col+1)
+				c := vertex(row+1, col+1)
+				d := vertex(row+1, col)
+				e.Faces = append(e.Faces,
+					&ModFace{Index: [3]uint32{a, b, c}, MaterialName: material},
+					&ModFace{Index: [3]uint32{c, d, a}, MaterialName: material},
+				)
+			}
+		}
+	}
+
+	e.RecomputeNormals(false)
+	return nil
+}
+
+// AddV4Terrain adds the terrain of a v4 zone .dat as an EQGTERDEF, for paths that need zone
+// geometry such as server maps, minimaps and s3d conversion. Nothing is added if a .ter of the
+// same name exists. It is not called on read, so a v4 zone round trip does not gain a .ter
+func (wce *Wce) AddV4Terrain(info *raw.V4Info, dat *raw.DatZon) error {
+	tag := strings.TrimSuffix(dat.FileName(), ".dat")
+	for _, ter := range wce.TerDefs {
+		if strings.EqualFold(ter.Tag, tag) {
+			return nil
+		}
+	}
+	def := &EqgTerDef{}
+	err := def.FromV4(wce, info, dat)
+	if err != nil {
+		return fmt.Errorf("%s: %w", dat.FileName(), err)
+	}
+	wce.TerDefs = append(wce.TerDefs, def)
+	return nil
+}
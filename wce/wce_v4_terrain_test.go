@@ -0,0 +1,117 @@
+package wce
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/xackery/quail/pfs"
+	"github.com/xackery/quail/raw"
+)
+
+func TestEqgTerDefFromV4(t *testing.T) {
+	info := &raw.V4Info{MinLat: -1, MaxLat: 0, MinLng: 0, MaxLng: 0, UnitsPerVert: 2, QuadsPerTile: 2}
+	tile := func(lat int32, height float32) *raw.DatZonTile {
+		tile := &raw.DatZonTile{Lng: 100000, Lat: lat, LayerBaseMaterial: "grass"}
+		for i := 0; i < 9; i++ {
+			tile.Floats = append(tile.Floats, height)
+			tile.Colors = append(tile.Colors, 0x80102030)
+		}
+		tile.Flags = make([]uint8, 4)
+		return tile
+	}
+	dat := &raw.DatZon{MetaFileName: "arcstone.dat", QuadsPerTile: 2, FallbackDetailRepeat: 4}
+	dat.Tiles = append(dat.Tiles, tile(99999, 1), tile(100000, 2), tile(100005, 3))
+	dat.Tiles[1].Flags[3] = v4TerrainHoleFlag
+
+	wce := New("arcstone.eqg")
+	ter := &EqgTerDef{}
+	err := ter.FromV4(wce, info, dat)
+	if err != nil {
+		t.Fatalf("from v4: %v", err)
+	}
+	if ter.Tag != "arcstone" {
+		t.Fatalf("tag %s, want arcstone", ter.Tag)
+	}
+	// tile 100005 is outside of the bounds, tile 100000 has one hole
+	if len(ter.Faces) != 14 {
+		t.Fatalf("got %d faces, want 14", len(ter.Faces))
+	}
+	// the hole drops the top right corner of the second tile
+	if len(ter.Vertices) != 17 {
+		t.Fatalf("got %d vertices, want 17", len(ter.Vertices))
+	}
+	if len(ter.Materials) != 1 || ter.Materials[0].Tag != "grass" || ter.Faces[0].MaterialName != "grass" {
+		t.Fatalf("material not set")
+	}
+
+	first := ter.Vertices[0]
+	if first.Position != [3]float32{-4, 0, 1} {
+		t.Fatalf("first vertex at %v, want [-4 0 1]", first.Position)
+	}
+	if first.Tint != [4]uint8{0x10, 0x20, 0x30, 0x80} {
+		t.Fatalf("tint %v, want [16 32 48 128]", first.Tint)
+	}
+	if first.Normal != [3]float32{0, 0, 1} {
+		t.Fatalf("normal %v, want [0 0 1]", first.Normal)
+	}
+	third := ter.Vertices[2]
+	if third.Uv != [2]float32{0.5, 0.5} || third.Uv2 != [2]float32{2, 2} {
+		t.Fatalf("uv %v uv2 %v, want [0.5 0.5] and [2 2]", third.Uv, third.Uv2)
+	}
+
+	wce.TerDefs = append(wce.TerDefs, ter)
+	mesh, err := wce.ZoneMesh(nil, nil)
+	if err != nil {
+		t.Fatalf("zone mesh: %v", err)
+	}
+	if len(mesh.Triangles) != 14 {
+		t.Fatalf("zone mesh has %d triangles, want 14", len(mesh.Triangles))
+	}
+}
+
+func TestAddV4Terrain(t *testing.T) {
+	zon := &raw.Zon{Version: 4}
+	zon.V4Info = raw.V4Info{Name: "arcstone", MinLat: 0, MaxLat: 0, MinLng: 0, MaxLng: 0, UnitsPerVert: 2, QuadsPerTile: 1}
+	zonBuf := &bytes.Buffer{}
+	err := zon.WriteV4(zonBuf)
+	if err != nil {
+		t.Fatalf("write zon: %v", err)
+	}
+	dat := &raw.DatZon{MetaFileName: "arcstone.dat", QuadsPerTile: 1}
+	dat.Tiles = append(dat.Tiles, &raw.DatZonTile{Lng: 100000, Lat: 100000, Floats: make([]float32, 4), Colors: make([]uint32, 4), Flags: make([]uint8, 1)})
+	datBuf := &bytes.Buffer{}
+	err = dat.Write(datBuf)
+	if err != nil {
+		t.Fatalf("write dat: %v", err)
+	}
+
+	archive, err := pfs.New("arcstone.eqg")
+	if err != nil {
+		t.Fatalf("new archive: %v", err)
+	}
+	for name, data := range map[string][]byte{"arcstone.zon": zonBuf.Bytes(), "arcstone.dat": datBuf.Bytes()} {
+		err = archive.Add(name, data)
+		if err != nil {
+			t.Fatalf("add %s: %v", name, err)
+		}
+	}
+
+	wce := New("arcstone.eqg")
+	err = wce.ReadEqgRaw(archive)
+	if err != nil {
+		t.Fatalf("read eqg: %v", err)
+	}
+	if len(wce.TerDefs) != 0 {
+		t.Fatalf("reading a v4 zone added %d terrain, want 0", len(wce.TerDefs))
+	}
+
+	for i := 0; i < 2; i++ {
+		err = wce.AddV4Terrain(&zon.V4Info, dat)
+		if err != nil {
+			t.Fatalf("add v4 terrain: %v", err)
+		}
+	}
+	if len(wce.TerDefs) != 1 || len(wce.TerDefs[0].Faces) != 2 {
+		t.Fatalf("got %d terrain, want 1 with 2 faces", len(wce.TerDefs))
+	}
+}
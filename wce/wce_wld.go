@@ -3,6 +3,7 @@ package wce
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 
@@ -9248,9 +9249,9 @@ func (e *DMTrackDef2) ToRaw(wce *Wce, rawWld *raw.Wld) (int32, error) {
 		frames := make([][3]int16, 0)
 		for _, vert := range frame {
 			frames = append(frames, [3]int16{
-				int16(vert[0] / scale),
-				int16(vert[1] / scale),
-				int16(vert[2] / scale),
+				int16(math.Round(float64(vert[0] / scale))),
+				int16(math.Round(float64(vert[1] / scale))),
+				int16(math.Round(float64(vert[2] / scale))),
 			})
 		}
 		wfTrack2.Frames = append(wfTrack2.Frames, frames)
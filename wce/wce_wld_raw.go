@@ -353,12 +353,15 @@ func readRawFrag(e *Wce, rawWld *raw.Wld, fragment helper.FragmentReadWriter, fo
 }
 
 func (wce *Wce) WriteWldRaw(w io.Writer) error {
-	var err error
-
-	err = wce.convertEQGToWld()
+	wld, _, err := wce.ConvertEQGToWld()
 	if err != nil {
 		return fmt.Errorf("convert eqg to wld: %w", err)
 	}
+	return wld.writeWldRaw(w)
+}
+
+func (wce *Wce) writeWldRaw(w io.Writer) error {
+	var err error
 
 	dst := &raw.Wld{
 		IsNewWorld: false,
@@ -762,28 +765,47 @@ func appendUnique(slice []string, value string) []string {
 	return append(slice, value)
 }
 
-func (wce *Wce) convertEQGToWld() error {
-
-	//for _, mds := range wce.MdsDefs {
-	//}
-
-	//for _, mod := range wce.ModDefs {
-	//}
-
-	//for _, ter := range wce.TerDefs {
-	//}
-
-	//for _, ani := range wce.AniDefs {
-	//}
-
-	//for _, lay := range wce.LayDefs {
-	//}
+// ConvertEQGToWld returns wce with its eqg terrain and models converted to wld definitions, and
+// what their materials and faces lost as notes. The conversion is done on a copy, so wce is left as read
+func (wce *Wce) ConvertEQGToWld() (*Wce, []string, error) {
+	isTerrain := len(wce.TerDefs) > 0 && len(wce.WorldTrees) == 0
+	// zone models are placed by ConvertEQGZone into a separate _obj wld
+	isModels := len(wce.ZonDefs) == 0 && len(wce.ModDefs) > 0
+	if !isTerrain && !isModels {
+		return wce, nil, nil
+	}
 
-	//for _, pts := range wce.PtsDefs {
+	dst := *wce
+	dst.ActorDefs = append([]*ActorDef{}, wce.ActorDefs...)
+	dst.DMSpriteDef2s = append([]*DMSpriteDef2{}, wce.DMSpriteDef2s...)
+	dst.MaterialDefs = append([]*MaterialDef{}, wce.MaterialDefs...)
+	dst.MaterialPalettes = append([]*MaterialPalette{}, wce.MaterialPalettes...)
+	// terrain conversion sets the vis trees of every region
+	dst.Regions = make([]*Region, len(wce.Regions))
+	for i, region := range wce.Regions {
+		copied := *region
+		dst.Regions[i] = &copied
+	}
+	dst.SimpleSpriteDefs = append([]*SimpleSpriteDef{}, wce.SimpleSpriteDefs...)
+	dst.WorldTrees = append([]*WorldTree{}, wce.WorldTrees...)
 
-	//}
+	notes := []string{}
+	if isTerrain {
+		terrainNotes, err := dst.convertEqgTerrain()
+		if err != nil {
+			return nil, nil, fmt.Errorf("terrain: %w", err)
+		}
+		notes = append(notes, terrainNotes...)
+	}
 
-	//for _, prt := range wce.PrtDefs {
-	//}
-	return nil
+	if isModels {
+		for _, mod := range wce.ModDefs {
+			modelNotes, err := eqgModelToWld(&dst, mod)
+			if err != nil {
+				return nil, nil, fmt.Errorf("model %s: %w", mod.Tag, err)
+			}
+			notes = append(notes, modelNotes...)
+		}
+	}
+	return &dst, notes, nil
 }
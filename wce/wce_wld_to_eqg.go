@@ -0,0 +1,495 @@
+package wce
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// regexChrMaterial matches character materials, race and piece, variation, then page, like HUMCH0001_MDF
+var regexChrMaterial = regexp.MustCompile(`^([A-Z0-9]{5})(\d{2})(\d{2})_MDF$`)
+
+// wldDagPose is a dag of a hierarchical sprite placed in model space
+type wldDagPose struct {
+	translation [3]float32
+	rotation    [4]float32 // x, y, z, w
+}
+
+// quatMul returns the rotation b followed by a
+func quatMul(a [4]float32, b [4]float32) [4]float32 {
+	return [4]float32{
+		a[3]*b[0] + a[0]*b[3] + a[1]*b[2] - a[2]*b[1],
+		a[3]*b[1] - a[0]*b[2] + a[1]*b[3] + a[2]*b[0],
+		a[3]*b[2] + a[0]*b[1] - a[1]*b[0] + a[2]*b[3],
+		a[3]*b[3] - a[0]*b[0] - a[1]*b[1] - a[2]*b[2],
+	}
+}
+
+// quatRotate rotates v by the unit quaternion q
+func quatRotate(q [4]float32, v [3]float32) [3]float32 {
+	u := [3]float32{q[0], q[1], q[2]}
+	t := vecScale(vecCross(u, v), 2)
+	return vecAdd(vecAdd(v, vecScale(t, q[3])), vecCross(u, t))
+}
+
+// quatNormalize returns q scaled to unit length, or no rotation if q is zero
+func quatNormalize(q [4]float32) [4]float32 {
+	length := float32(math.Sqrt(float64(q[0]*q[0] + q[1]*q[1] + q[2]*q[2] + q[3]*q[3])))
+	if length == 0 {
+		return [4]float32{0, 0, 0, 1}
+	}
+	return [4]float32{q[0] / length, q[1] / length, q[2] / length, q[3] / length}
+}
+
+// wldToEqgQuat converts a wld rotation to eqg space, which has x and y swapped
+func wldToEqgQuat(q [4]float32) [4]float32 {
+	// mirroring the axes also mirrors the direction of rotation
+	return [4]float32{-q[1], -q[0], -q[2], q[3]}
+}
+
+// wldTrackFrame returns the translation and rotation of a frame of a track, holding the last frame
+func wldTrackFrame(track *TrackDef, index int) ([3]float32, [4]float32) {
+	shift := func(xyz [3]int16, scale int16) [3]float32 {
+		if scale == 0 {
+			return [3]float32{}
+		}
+		return [3]float32{float32(xyz[0]) / float32(scale), float32(xyz[1]) / float32(scale), float32(xyz[2]) / float32(scale)}
+	}
+	if len(track.Frames) > 0 {
+		frame := track.Frames[min(index, len(track.Frames)-1)]
+		rotation := quatNormalize([4]float32{float32(frame.Rotation[0]), float32(frame.Rotation[1]), float32(frame.Rotation[2]), float32(frame.RotScale)})
+		return shift(frame.XYZ, frame.XYZScale), rotation
+	}
+	if len(track.LegacyFrames) > 0 {
+		frame := track.LegacyFrames[min(index, len(track.LegacyFrames)-1)]
+		return shift(frame.XYZ, frame.XYZScale), quatNormalize(frame.Rotation)
+	}
+	return [3]float32{}, [4]float32{0, 0, 0, 1}
+}
+
+// wldTrackFrameCount returns how many frames a track has
+func wldTrackFrameCount(track *TrackDef) int {
+	return max(len(track.Frames), len(track.LegacyFrames))
+}
+
+// trackDefByInstance returns the TRACKDEFINITION a TRACKINSTANCE tag plays
+func (wce *Wce) trackDefByInstance(tag string) (*TrackInstance, *TrackDef, error) {
+	instance, ok := wce.ByTag(tag).(*TrackInstance)
+	if !ok {
+		return nil, nil, fmt.Errorf("track instance %s not found", tag)
+	}
+	track, ok := wce.ByTag(instance.Def).(*TrackDef)
+	if !ok {
+		return nil, nil, fmt.Errorf("track %s of %s not found", instance.Def, tag)
+	}
+	return instance, track, nil
+}
+
+// convertWldCharacters adds an EQGSKINNEDMODELDEF for each ACTORDEF drawn by a HIERARCHICALSPRITEDEF,
+// with an EQGANIDEF per animation and an EQGLAYERDEF of texture variations, and returns what the
+// materials lost
+func (wce *Wce) convertWldCharacters() ([]string, error) {
+	notes := []string{}
+	for _, actor := range wce.ActorDefs {
+		var sprite *HierarchicalSpriteDef
+		for _, action := range actor.Actions {
+			for _, lod := range action.LevelOfDetails {
+				hs, ok := wce.ByTag(lod.SpriteTag).(*HierarchicalSpriteDef)
+				if ok && sprite == nil {
+					sprite = hs
+				}
+			}
+		}
+		if sprite == nil {
+			continue
+		}
+
+		tag := strings.ToLower(strings.TrimSuffix(baseTag(actor.Tag), "_ACTORDEF"))
+		isConverted := false
+		for _, mds := range wce.MdsDefs {
+			if strings.EqualFold(mds.Tag, tag) {
+				isConverted = true
+				break
+			}
+		}
+		if isConverted {
+			continue
+		}
+
+		characterNotes, err := wce.convertWldCharacter(tag, sprite)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", actor.Tag, err)
+		}
+		notes = append(notes, characterNotes...)
+	}
+	return notes, nil
+}
+
+// convertWldCharacter converts a HIERARCHICALSPRITEDEF into a skinned model named tag. Each dag
+// becomes a bone, and skins are moved from dag space into model space with the vertex fully
+// weighted to the dag it was assigned to
+func (wce *Wce) convertWldCharacter(tag string, sprite *HierarchicalSpriteDef) ([]string, error) {
+	if len(sprite.Dags) == 0 {
+		return nil, fmt.Errorf("%s has no dags", sprite.Tag)
+	}
+
+	parents := make([]int, len(sprite.Dags))
+	for i := range parents {
+		parents[i] = -1
+	}
+	for i, dag := range sprite.Dags {
+		for _, sub := range dag.SubDags {
+			if int(sub) >= len(sprite.Dags) || int(sub) == i {
+				return nil, fmt.Errorf("dag %s sub dag %d out of range", dag.Tag, sub)
+			}
+			parents[sub] = i
+		}
+	}
+
+	mds := &EqgMdsDef{
+		folders: []string{tag},
+		Tag:     tag,
+		Version: 1,
+	}
+
+	// bones are placed by the first frame of their track, which is the rest pose
+	locals := make([]wldDagPose, len(sprite.Dags))
+	for i, dag := range sprite.Dags {
+		locals[i].rotation = [4]float32{0, 0, 0, 1}
+		if dag.Track == "" {
+			continue
+		}
+		_, track, err := wce.trackDefByInstance(dag.Track)
+		if err != nil {
+			return nil, fmt.Errorf("dag %s: %w", dag.Tag, err)
+		}
+		locals[i].translation, locals[i].rotation = wldTrackFrame(track, 0)
+	}
+
+	poses := make([]*wldDagPose, len(sprite.Dags))
+	var pose func(i int, depth int) (*wldDagPose, error)
+	pose = func(i int, depth int) (*wldDagPose, error) {
+		if poses[i] != nil {
+			return poses[i], nil
+		}
+		if depth > len(sprite.Dags) {
+			return nil, fmt.Errorf("dag %s is its own parent", sprite.Dags[i].Tag)
+		}
+		local := locals[i]
+		if parents[i] < 0 {
+			poses[i] = &local
+			return poses[i], nil
+		}
+		parent, err := pose(parents[i], depth+1)
+		if err != nil {
+			return nil, err
+		}
+		poses[i] = &wldDagPose{
+			translation: vecAdd(parent.translation, quatRotate(parent.rotation, local.translation)),
+			rotation:    quatNormalize(quatMul(parent.rotation, local.rotation)),
+		}
+		return poses[i], nil
+	}
+
+	boneNames := make([]string, len(sprite.Dags))
+	for i, dag := range sprite.Dags {
+		_, err := pose(i, 0)
+		if err != nil {
+			return nil, err
+		}
+		boneNames[i] = strings.ToLower(strings.TrimSuffix(baseTag(dag.Tag), "_DAG"))
+
+		bone := &MdsBone{
+			Name:       boneNames[i],
+			Next:       -1,
+			ChildIndex: -1,
+			Pivot:      EqgToServerAxis(locals[i].translation),
+			Quaternion: wldToEqgQuat(locals[i].rotation),
+			Scale:      [3]float32{1, 1, 1},
+		}
+		if len(dag.SubDags) > 0 {
+			bone.ChildIndex = int32(dag.SubDags[0])
+			bone.ChildrenCount = uint32(len(dag.SubDags))
+		}
+		mds.Bones = append(mds.Bones, bone)
+	}
+	for _, dag := range sprite.Dags {
+		for j := 1; j < len(dag.SubDags); j++ {
+			mds.Bones[dag.SubDags[j-1]].Next = int32(dag.SubDags[j])
+		}
+	}
+
+	// skins are attached skins, and sprites drawn directly by a dag
+	type skin struct {
+		sprite *DMSpriteDef2
+		dag    int
+	}
+	skins := []skin{}
+	for _, attached := range sprite.AttachedSkins {
+		dm, ok := wce.ByTag(attached.DMSpriteTag).(*DMSpriteDef2)
+		if !ok {
+			return nil, fmt.Errorf("skin %s not found", attached.DMSpriteTag)
+		}
+		skins = append(skins, skin{sprite: dm, dag: -1})
+	}
+	for i, dag := range sprite.Dags {
+		dm, ok := wce.ByTag(dag.SpriteTag).(*DMSpriteDef2)
+		if !ok {
+			continue
+		}
+		skins = append(skins, skin{sprite: dm, dag: i})
+	}
+	if len(skins) == 0 {
+		return nil, fmt.Errorf("%s has no skins", sprite.Tag)
+	}
+
+	notes := []string{}
+	materials := map[string]bool{}
+	for i, s := range skins {
+		model, skinNotes, err := wce.wldSkinToMds(s.sprite, s.dag, poses, mds, materials)
+		if err != nil {
+			return nil, fmt.Errorf("skin %s: %w", s.sprite.Tag, err)
+		}
+		notes = append(notes, skinNotes...)
+		if i == 0 {
+			model.MainPiece = 1
+		}
+		mds.Models = append(mds.Models, model)
+	}
+	wce.MdsDefs = append(wce.MdsDefs, mds)
+	wce.isWldConverted = true
+
+	err := wce.convertWldAnimations(tag, sprite, boneNames)
+	if err != nil {
+		return nil, fmt.Errorf("animations: %w", err)
+	}
+
+	wce.convertWldVariations(tag, mds)
+	return notes, nil
+}
+
+// wldSkinToMds converts a DMSPRITEDEF2 skin into an eqg skinned model piece. A dag of -1 places
+// vertices by the skin assignment groups of the sprite. What new materials lost is returned as notes
+func (wce *Wce) wldSkinToMds(sprite *DMSpriteDef2, dag int, poses []*wldDagPose, mds *EqgMdsDef, materials map[string]bool) (*EqgMdsModel, []string, error) {
+	model := &EqgMdsModel{
+		Name:      strings.ToLower(strings.TrimSuffix(baseTag(sprite.Tag), "_DMSPRITEDEF")),
+		BoneCount: uint32(len(poses)),
+	}
+	notes := []string{}
+
+	dags := make([]int, len(sprite.Vertices))
+	if dag >= 0 {
+		for i := range dags {
+			dags[i] = dag
+		}
+	} else {
+		next := 0
+		for _, group := range sprite.SkinAssignmentGroups {
+			for j := 0; j < int(group[0]); j++ {
+				if next >= len(dags) {
+					return nil, nil, fmt.Errorf("skin assignment groups cover more than %d vertices", len(dags))
+				}
+				if int(group[1]) < 0 || int(group[1]) >= len(poses) {
+					return nil, nil, fmt.Errorf("skin assignment dag %d out of range", group[1])
+				}
+				dags[next] = int(group[1])
+				next++
+			}
+		}
+	}
+
+	for i, v := range sprite.Vertices {
+		pose := poses[dags[i]]
+		vertex := &ModVertex{
+			Position: EqgToServerAxis(vecAdd(pose.translation, quatRotate(pose.rotation, vecAdd(v, sprite.CenterOffset)))),
+			Tint:     [4]uint8{128, 128, 128, 255},
+			Weights:  []*ModBoneWeight{{BoneIndex: int32(dags[i]), Value: 1}},
+		}
+		if i < len(sprite.VertexNormals) {
+			vertex.Normal = EqgToServerAxis(quatRotate(pose.rotation, sprite.VertexNormals[i]))
+		}
+		if i < len(sprite.UVs) {
+			vertex.Uv = sprite.UVs[i]
+		}
+		if i < len(sprite.VertexColors) {
+			vertex.Tint = sprite.VertexColors[i]
+		}
+		model.Vertices = append(model.Vertices, vertex)
+	}
+
+	palette, ok := wce.ByTag(sprite.MaterialPaletteTag).(*MaterialPalette)
+	if !ok {
+		return nil, nil, fmt.Errorf("material palette %s not found", sprite.MaterialPaletteTag)
+	}
+	faceMaterials := make([]string, 0, len(sprite.Faces))
+	for _, group := range sprite.FaceMaterialGroups {
+		if int(group[1]) >= len(palette.Materials) {
+			return nil, nil, fmt.Errorf("face material %d out of range of palette %s", group[1], palette.Tag)
+		}
+		materialTag := palette.Materials[group[1]]
+		for j := 0; j < int(group[0]); j++ {
+			faceMaterials = append(faceMaterials, materialTag)
+		}
+	}
+
+	for i, face := range sprite.Faces {
+		for _, index := range face.Triangle {
+			if int(index) >= len(model.Vertices) {
+				return nil, nil, fmt.Errorf("face %d index %d out of range", i, index)
+			}
+		}
+		// swapping x and y mirrors the mesh, so the winding is reversed to keep faces pointing out
+		mdsFace := &MdsFace{
+			Index:    [3]uint32{uint32(face.Triangle[0]), uint32(face.Triangle[2]), uint32(face.Triangle[1])},
+			Passable: face.Passable,
+		}
+		if i < len(faceMaterials) {
+			material, ok := wce.ByTag(faceMaterials[i]).(*MaterialDef)
+			if !ok {
+				return nil, nil, fmt.Errorf("material %s not found", faceMaterials[i])
+			}
+			mdsFace.MaterialName = wldMaterialName(material.Tag)
+			if !materials[mdsFace.MaterialName] {
+				materials[mdsFace.MaterialName] = true
+				eqgMaterial, materialLoss := wce.MaterialDefToEqg(material)
+				notes = append(notes, materialNotes(material.Tag, materialLoss)...)
+				mds.Materials = append(mds.Materials, eqgMaterial)
+			}
+		}
+		model.Faces = append(model.Faces, mdsFace)
+	}
+	return model, notes, nil
+}
+
+// convertWldAnimations adds an EQGANIDEF for each animation of a hierarchical sprite. Animation
+// tracks are named by an animation code followed by the track of the dag they move, like
+// C01HUMPE_TRACK moving the dag with track HUMPE_TRACK
+func (wce *Wce) convertWldAnimations(tag string, sprite *HierarchicalSpriteDef, boneNames []string) error {
+	animations := map[string]*EqgAniDef{}
+	for i, dag := range sprite.Dags {
+		if dag.Track == "" {
+			continue
+		}
+		for _, instance := range wce.TrackInstances {
+			code := ""
+			if len(instance.Tag) > 3 && baseTag(instance.Tag)[3:] == dag.Track {
+				code = instance.Tag[:3]
+			}
+			if code == "" || !regexAniPrefix.MatchString(code) {
+				continue
+			}
+			_, track, err := wce.trackDefByInstance(instance.Tag)
+			if err != nil {
+				return err
+			}
+
+			ani, ok := animations[code]
+			if !ok {
+				ani = &EqgAniDef{
+					folders: []string{tag + "/" + tag + "_ani"},
+					Tag:     fmt.Sprintf("%s_%s", tag, strings.ToLower(code)),
+					Version: 2,
+				}
+				animations[code] = ani
+			}
+
+			sleep := uint32(100)
+			if instance.Sleep.Valid && instance.Sleep.Uint32 > 0 {
+				sleep = instance.Sleep.Uint32
+			}
+			bone := &AniBone{Name: boneNames[i]}
+			for frame := 0; frame < wldTrackFrameCount(track); frame++ {
+				translation, rotation := wldTrackFrame(track, frame)
+				bone.Frames = append(bone.Frames, &AniBoneFrame{
+					Milliseconds: uint32(frame) * sleep,
+					Translation:  EqgToServerAxis(translation),
+					Rotation:     wldToEqgQuat(rotation),
+					Scale:        [3]float32{1, 1, 1},
+				})
+			}
+			ani.Bones = append(ani.Bones, bone)
+		}
+	}
+
+	codes := make([]string, 0, len(animations))
+	for code := range animations {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		wce.AniDefs = append(wce.AniDefs, animations[code])
+	}
+	return nil
+}
+
+// convertWldVariations adds an EQGLAYERDEF listing the texture variations of the character
+// materials of mds, like HUMCH0101_MDF for HUMCH0001_MDF
+func (wce *Wce) convertWldVariations(tag string, mds *EqgMdsDef) {
+	used := map[string]bool{}
+	pages := map[string]bool{}
+	for _, material := range mds.Materials {
+		used[material.Tag] = true
+		match := regexChrMaterial.FindStringSubmatch(strings.ToUpper(material.Tag) + "_MDF")
+		if match == nil {
+			continue
+		}
+		pages[match[1]+"|"+match[3]] = true
+	}
+
+	lay := &EqgLayDef{
+		folders: []string{tag},
+		Tag:     tag,
+		Version: 3,
+	}
+	for _, material := range wce.MaterialDefs {
+		match := regexChrMaterial.FindStringSubmatch(baseTag(material.Tag))
+		if match == nil || !pages[match[1]+"|"+match[3]] {
+			continue
+		}
+		name := wldMaterialName(material.Tag)
+		if used[name] {
+			continue
+		}
+		used[name] = true
+		texture := wce.wldMaterialTexture(material)
+		if texture == "" {
+			continue
+		}
+		lay.Layers = append(lay.Layers, &LayEntry{
+			Material: name,
+			Diffuse:  texture,
+		})
+	}
+	if len(lay.Layers) == 0 {
+		return
+	}
+	wce.LayDefs = append(wce.LayDefs, lay)
+}
+
+// OnDemandResources returns the OnDemandResources.txt entries of the models, animations and
+// texture layers of an eqg named eqgName
+func (wce *Wce) OnDemandResources(eqgName string) []string {
+	eqgName = strings.ToLower(filepath.Base(eqgName))
+	entries := []string{}
+	for _, mds := range wce.MdsDefs {
+		name := strings.ToUpper(mds.Tag)
+		entries = append(entries, fmt.Sprintf("%s^%s.MDS^%s_ACTORDEF^EQGS", eqgName, name, name))
+	}
+	for _, mod := range wce.ModDefs {
+		name := strings.ToUpper(mod.Tag)
+		entries = append(entries, fmt.Sprintf("%s^%s.MOD^%s_ACTORDEF^EQGM", eqgName, name, name))
+	}
+	for _, ani := range wce.AniDefs {
+		name := strings.ToUpper(ani.Tag)
+		entries = append(entries, fmt.Sprintf("%s^%s.ANI^%s^EQGA", eqgName, name, name))
+	}
+	for _, lay := range wce.LayDefs {
+		for _, layer := range lay.Layers {
+			entries = append(entries, fmt.Sprintf("%s^%s.LAY^%s^EQGL", eqgName, strings.ToUpper(lay.Tag), layer.Material))
+		}
+	}
+	return entries
+}
@@ -0,0 +1,218 @@
+package wce
+
+import (
+	"fmt"
+	"strings"
+)
+
+// convertWldParticles adds an EQGPARTICLEPOINTDEF and EQGPARTICLERENDERDEF for each converted
+// character whose dags carry a PARTICLECLOUDDEF. Each cloud becomes a particle point on the bone
+// of its dag, and an emitter played at that point. An ACTORDEF drawn by a PARTICLECLOUDDEF alone
+// becomes an empty model with the cloud played at its origin. Blit sprites no cloud plays are kept
+// as empty particle renders
+func (wce *Wce) convertWldParticles() error {
+	for _, actor := range wce.ActorDefs {
+		var sprite *HierarchicalSpriteDef
+		var cloud *ParticleCloudDef
+		for _, action := range actor.Actions {
+			for _, lod := range action.LevelOfDetails {
+				switch def := wce.ByTag(lod.SpriteTag).(type) {
+				case *HierarchicalSpriteDef:
+					if sprite == nil {
+						sprite = def
+					}
+				case *ParticleCloudDef:
+					if cloud == nil {
+						cloud = def
+					}
+				}
+			}
+		}
+		if sprite == nil && cloud == nil {
+			continue
+		}
+
+		tag := strings.ToLower(strings.TrimSuffix(baseTag(actor.Tag), "_ACTORDEF"))
+		if wce.hasParticlePoints(tag) {
+			continue
+		}
+		if sprite == nil {
+			wce.convertWldParticleActor(tag, cloud)
+			continue
+		}
+		isConverted := false
+		for _, mds := range wce.MdsDefs {
+			if strings.EqualFold(mds.Tag, tag) {
+				isConverted = true
+				break
+			}
+		}
+		if !isConverted {
+			continue
+		}
+
+		err := wce.convertWldParticle(tag, sprite)
+		if err != nil {
+			return fmt.Errorf("%s: %w", actor.Tag, err)
+		}
+	}
+
+	played := map[string]bool{}
+	for _, cloud := range wce.ParticleCloudDefs {
+		played[cloud.BlitSpriteDefTag] = true
+	}
+	for _, blit := range wce.BlitSpriteDefs {
+		if played[blit.Tag] {
+			continue
+		}
+		isConverted := false
+		for _, prt := range wce.PrtDefs {
+			if prt.Tag == blit.Tag {
+				isConverted = true
+				break
+			}
+		}
+		if isConverted {
+			continue
+		}
+		wce.PrtDefs = append(wce.PrtDefs, &EqgParticleRenderDef{
+			folders: []string{strings.ToLower(blit.Tag)},
+			Tag:     blit.Tag,
+			Version: 4,
+		})
+	}
+	return nil
+}
+
+// hasParticlePoints returns true if the model tag already has an EQGPARTICLEPOINTDEF
+func (wce *Wce) hasParticlePoints(tag string) bool {
+	for _, pts := range wce.PtsDefs {
+		if strings.EqualFold(pts.Tag, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// convertWldParticleActor converts an actor drawn by a particle cloud alone into an empty model
+// named tag, with the cloud played at a point on its origin
+func (wce *Wce) convertWldParticleActor(tag string, cloud *ParticleCloudDef) {
+	isModel := false
+	for _, mod := range wce.ModDefs {
+		if strings.EqualFold(mod.Tag, tag) {
+			isModel = true
+			break
+		}
+	}
+	if !isModel {
+		wce.ModDefs = append(wce.ModDefs, &EqgModDef{
+			folders: []string{tag},
+			Tag:     tag,
+			Version: 1,
+		})
+		wce.isWldConverted = true
+	}
+
+	pts := &EqgParticlePointDef{
+		folders: []string{tag},
+		Tag:     tag,
+		Version: 1,
+	}
+	prt := &EqgParticleRenderDef{
+		folders: []string{tag},
+		Tag:     tag,
+		Version: 4,
+	}
+	// a point without a bone is placed from the model origin
+	point := &ParticlePointEntry{
+		Name:  strings.ToLower(strings.TrimSuffix(baseTag(cloud.Tag), "_PCD")),
+		Scale: [3]float32{1, 1, 1},
+	}
+	pts.Points = append(pts.Points, point)
+	prt.Renders = append(prt.Renders, wce.particleCloudRender(tag, point, cloud))
+
+	wce.PtsDefs = append(wce.PtsDefs, pts)
+	wce.PrtDefs = append(wce.PrtDefs, prt)
+}
+
+// convertWldParticle converts the particle clouds of the dags of sprite for the skinned model tag
+func (wce *Wce) convertWldParticle(tag string, sprite *HierarchicalSpriteDef) error {
+	pts := &EqgParticlePointDef{
+		folders: []string{tag},
+		Tag:     tag,
+		Version: 1,
+	}
+	prt := &EqgParticleRenderDef{
+		folders: []string{tag},
+		Tag:     tag,
+		Version: 4,
+	}
+	for _, dag := range sprite.Dags {
+		if dag.SpriteTag == "" {
+			continue
+		}
+		cloud, ok := wce.ByTag(dag.SpriteTag).(*ParticleCloudDef)
+		if !ok {
+			continue
+		}
+
+		// bones are named as convertWldCharacter names them
+		bone := strings.ToLower(strings.TrimSuffix(baseTag(dag.Tag), "_DAG"))
+		cloudName := strings.ToLower(strings.TrimSuffix(baseTag(cloud.Tag), "_PCD"))
+		point := &ParticlePointEntry{
+			Name:     fmt.Sprintf("%s_%s", bone, cloudName),
+			BoneName: bone,
+			Scale:    [3]float32{1, 1, 1},
+		}
+		pts.Points = append(pts.Points, point)
+		prt.Renders = append(prt.Renders, wce.particleCloudRender(tag, point, cloud))
+	}
+	if len(pts.Points) == 0 {
+		return nil
+	}
+
+	wce.PtsDefs = append(wce.PtsDefs, pts)
+	wce.PrtDefs = append(wce.PrtDefs, prt)
+	return nil
+}
+
+// particleCloudRender adds the emitter of a particle cloud of the model tag, and returns a render
+// playing it at point
+func (wce *Wce) particleCloudRender(tag string, point *ParticlePointEntry, cloud *ParticleCloudDef) *ParticleRenderEntry {
+	emitter := wce.particleCloudEmitterDef(cloud)
+	emitter.folders = []string{tag}
+	emitter.TagIndex = len(wce.EmitterDefs)
+	wce.EmitterDefs = append(wce.EmitterDefs, emitter)
+	return &ParticleRenderEntry{
+		EmitterID:     int32(len(wce.EmitterDefs)),
+		ParticlePoint: point.Name,
+		ParticleType:  int32(cloud.ParticleType),
+		Lifespan:      int32(cloud.Duration),
+	}
+}
+
+// particleCloudEmitterDef returns the emitter closest to a PARTICLECLOUDDEF, textured by the
+// first frame of its blit sprite
+func (wce *Wce) particleCloudEmitterDef(cloud *ParticleCloudDef) *EmitterDef {
+	texture, ok := wce.spriteTextureFile(cloud.BlitSpriteDefTag)
+	if !ok {
+		texture = cloud.BlitSpriteDefTag
+	}
+
+	emitter := classicEmitterDef(&classicParticle{
+		name:         strings.ToLower(baseTag(cloud.Tag)),
+		sprite:       texture,
+		particleType: int32(cloud.ParticleType),
+		colorBGRA:    cloud.Tint,
+		gravity:      cloud.Gravity,
+		spawnNormal:  cloud.SpawnVelocity,
+		spawnRadius:  cloud.SpawnRadius,
+		lifespan:     cloud.Lifespan,
+		velocity:     cloud.SpawnVelocityMultiplier,
+		spawnRate:    cloud.SpawnRate,
+		spawnScale:   cloud.SpawnScale,
+	})
+	emitter.DefaultLifeSpan = float32(cloud.Duration) / 1000
+	emitter.OldSize = cloud.Size
+	return emitter
+}
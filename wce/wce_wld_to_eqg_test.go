@@ -0,0 +1,320 @@
+package wce
+
+import (
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xackery/quail/pfs"
+)
+
+func TestConvertWldCharacters(t *testing.T) {
+	chr := New("hum_chr.wld")
+
+	track := func(tag string, frames ...*Frame) {
+		chr.TrackDefs = append(chr.TrackDefs, &TrackDef{Tag: tag + "DEF", Frames: frames})
+		instance := &TrackInstance{Tag: tag, Def: tag + "DEF"}
+		instance.Sleep.Valid = true
+		instance.Sleep.Uint32 = 50
+		chr.TrackInstances = append(chr.TrackInstances, instance)
+	}
+	rest := &Frame{RotScale: 16384}
+	// 90 degrees about z, 2 units up
+	turned := &Frame{XYZScale: 256, XYZ: [3]int16{0, 0, 512}, RotScale: 16384, Rotation: [3]int16{0, 0, 16384}}
+	track("HUM_TRACK", rest)
+	track("HUMHE_TRACK", turned)
+	track("C01HUM_TRACK", rest, rest)
+	track("C01HUMHE_TRACK", turned, rest)
+
+	for _, name := range []string{"HUMCH0001", "HUMCH0101"} {
+		chr.SimpleSpriteDefs = append(chr.SimpleSpriteDefs, &SimpleSpriteDef{
+			Tag:                name + "_SPRITE",
+			SimpleSpriteFrames: []SimpleSpriteFrame{{TextureTag: name, TextureFiles: []string{name + ".BMP"}}},
+		})
+		// blended has no eqg shader, so converting it is lossy
+		chr.MaterialDefs = append(chr.MaterialDefs, &MaterialDef{Tag: name + "_MDF", RenderMethod: "USERDEFINED_6", SimpleSpriteTag: name + "_SPRITE"})
+	}
+	chr.MaterialPalettes = append(chr.MaterialPalettes, &MaterialPalette{Tag: "HUM_MP", Materials: []string{"HUMCH0001_MDF"}})
+	chr.DMSpriteDef2s = append(chr.DMSpriteDef2s, &DMSpriteDef2{
+		Tag:                  "HUM_DMSPRITEDEF",
+		MaterialPaletteTag:   "HUM_MP",
+		Vertices:             [][3]float32{{1, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+		VertexNormals:        [][3]float32{{0, 0, 1}, {1, 0, 0}, {0, 0, 1}},
+		UVs:                  [][2]float32{{0, 0}, {1, 0}, {0, 1}},
+		SkinAssignmentGroups: [][2]int16{{1, 0}, {2, 1}},
+		Faces:                []*Face{{Triangle: [3]uint16{0, 1, 2}}},
+		FaceMaterialGroups:   [][2]uint16{{1, 0}},
+	})
+	chr.HierarchicalSpriteDefs = append(chr.HierarchicalSpriteDefs, &HierarchicalSpriteDef{
+		Tag: "HUM_HS_DEF",
+		Dags: []Dag{
+			{Tag: "HUM_DAG", Track: "HUM_TRACK", SubDags: []uint32{1}},
+			{Tag: "HUMHE_DAG", Track: "HUMHE_TRACK"},
+		},
+		AttachedSkins: []AttachedSkin{{DMSpriteTag: "HUM_DMSPRITEDEF"}},
+	})
+	chr.ActorDefs = append(chr.ActorDefs, &ActorDef{
+		Tag:     "HUM_ACTORDEF",
+		Actions: []ActorAction{{LevelOfDetails: []ActorLevelOfDetail{{SpriteTag: "HUM_HS_DEF"}}}},
+	})
+
+	converted, notes, err := chr.ConvertWldToEQG()
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	if len(notes) != 1 || !strings.HasPrefix(notes[0], "material HUMCH0001_MDF: ") {
+		t.Fatalf("lossy material not noted, got %v", notes)
+	}
+	if len(chr.MdsDefs) != 0 || len(chr.AniDefs) != 0 {
+		t.Fatalf("converting changed the wld")
+	}
+	if len(converted.MdsDefs) != 1 {
+		t.Fatalf("got %d mds, want 1", len(converted.MdsDefs))
+	}
+	mds := converted.MdsDefs[0]
+	if mds.Tag != "hum" || len(mds.Bones) != 2 || len(mds.Models) != 1 {
+		t.Fatalf("got mds %s with %d bones and %d models, want hum with 2 and 1", mds.Tag, len(mds.Bones), len(mds.Models))
+	}
+	if mds.Bones[0].ChildIndex != 1 || mds.Bones[0].ChildrenCount != 1 || mds.Bones[1].ChildIndex != -1 || mds.Bones[1].Name != "humhe" {
+		t.Fatalf("bone hierarchy not kept")
+	}
+
+	near := func(a, b [3]float32) bool {
+		for i := 0; i < 3; i++ {
+			if math.Abs(float64(a[i]-b[i])) > 0.001 {
+				return false
+			}
+		}
+		return true
+	}
+	model := mds.Models[0]
+	// the second vertex (1,0,0) of the head, turned to (0,1,0) and raised, then x and y swapped
+	want := [][3]float32{{0, 1, 0}, {1, 0, 2}, {0, -1, 2}}
+	for i, v := range model.Vertices {
+		if !near(v.Position, want[i]) {
+			t.Fatalf("vertex %d at %v, want %v", i, v.Position, want[i])
+		}
+	}
+	if !near(model.Vertices[1].Normal, [3]float32{1, 0, 0}) {
+		t.Fatalf("head normal %v, want [1 0 0]", model.Vertices[1].Normal)
+	}
+	if model.Vertices[1].Weights[0].BoneIndex != 1 || model.Vertices[1].Weights[0].Value != 1 {
+		t.Fatalf("head vertex not weighted to the head bone")
+	}
+	if model.Faces[0].Index != [3]uint32{0, 2, 1} || model.Faces[0].MaterialName != "humch0001" {
+		t.Fatalf("face %v %s, want reversed winding with humch0001", model.Faces[0].Index, model.Faces[0].MaterialName)
+	}
+	if len(mds.Materials) != 1 || mds.Materials[0].Properties[0].Value != "humch0001.bmp" {
+		t.Fatalf("material texture not converted")
+	}
+
+	if len(converted.AniDefs) != 1 || converted.AniDefs[0].Tag != "hum_c01" || len(converted.AniDefs[0].Bones) != 2 {
+		t.Fatalf("animation not converted")
+	}
+	frames := converted.AniDefs[0].Bones[1].Frames
+	if len(frames) != 2 || frames[1].Milliseconds != 50 || !near(frames[0].Translation, [3]float32{0, 0, 2}) {
+		t.Fatalf("animation frames not converted")
+	}
+
+	if len(converted.LayDefs) != 1 || len(converted.LayDefs[0].Layers) != 1 || converted.LayDefs[0].Layers[0].Material != "humch0101" {
+		t.Fatalf("variation not converted to a layer")
+	}
+
+	// the converted model keeps the wld animation codes, so audits the same as its source
+	audits, err := converted.AnimAudit(AnimSetNPC)
+	if err != nil {
+		t.Fatalf("audit: %v", err)
+	}
+	if len(audits) != 2 || audits[0].Model != "HUM" || audits[1].Model != "hum" {
+		t.Fatalf("got %d audits, want HUM and hum", len(audits))
+	}
+	if len(audits[1].Present) != 1 || audits[1].Present[0] != "C01" || len(audits[1].Missing) != len(audits[0].Missing) {
+		t.Fatalf("converted hum present %v missing %v, want C01 as in HUM", audits[1].Present, audits[1].Missing)
+	}
+
+	entries := converted.OnDemandResources("hum_chr.eqg")
+	if len(entries) != 3 || entries[0] != "hum_chr.eqg^HUM.MDS^HUM_ACTORDEF^EQGS" {
+		t.Fatalf("unexpected ondemand entries %v", entries)
+	}
+
+	archive, err := pfs.New("hum_chr.eqg")
+	if err != nil {
+		t.Fatalf("pfs new: %v", err)
+	}
+	err = converted.WriteEqgRaw(archive)
+	if err != nil {
+		t.Fatalf("write eqg: %v", err)
+	}
+	if len(converted.MdsDefs) != 1 {
+		t.Fatalf("writing again converted the model twice")
+	}
+
+	dir := t.TempDir()
+	ok, err := converted.WriteSingleFile(SideFileOnDemand, filepath.Join(dir, "hum_chr_ondemand.txt"))
+	if err != nil || !ok {
+		t.Fatalf("ondemand side file not written for converted wld: %v", err)
+	}
+	eqg := New("hum_chr.eqg")
+	eqg.MdsDefs = converted.MdsDefs
+	ok, err = eqg.WriteSingleFile(SideFileOnDemand, filepath.Join(dir, "eqg_ondemand.txt"))
+	if err != nil || ok {
+		t.Fatalf("ondemand side file written for an eqg not converted from wld")
+	}
+}
+
+func TestConvertWldParticles(t *testing.T) {
+	chr := New("torch_chr.wld")
+	for _, tag := range []string{"TORCH_TRACK", "TORCHTI_TRACK"} {
+		chr.TrackDefs = append(chr.TrackDefs, &TrackDef{Tag: tag + "DEF", Frames: []*Frame{{RotScale: 16384}}})
+		chr.TrackInstances = append(chr.TrackInstances, &TrackInstance{Tag: tag, Def: tag + "DEF"})
+	}
+	chr.SimpleSpriteDefs = append(chr.SimpleSpriteDefs, &SimpleSpriteDef{
+		Tag:                "FLAME_SPRITE",
+		SimpleSpriteFrames: []SimpleSpriteFrame{{TextureTag: "FLAME", TextureFiles: []string{"FLAME.BMP"}}},
+	})
+	chr.MaterialDefs = append(chr.MaterialDefs, &MaterialDef{Tag: "TORCH_MDF", RenderMethod: "USERDEFINED_2", SimpleSpriteTag: "FLAME_SPRITE"})
+	chr.MaterialPalettes = append(chr.MaterialPalettes, &MaterialPalette{Tag: "TORCH_MP", Materials: []string{"TORCH_MDF"}})
+	chr.DMSpriteDef2s = append(chr.DMSpriteDef2s, &DMSpriteDef2{
+		Tag:                  "TORCH_DMSPRITEDEF",
+		MaterialPaletteTag:   "TORCH_MP",
+		Vertices:             [][3]float32{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+		VertexNormals:        [][3]float32{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}},
+		UVs:                  [][2]float32{{0, 0}, {1, 0}, {0, 1}},
+		SkinAssignmentGroups: [][2]int16{{3, 0}},
+		Faces:                []*Face{{Triangle: [3]uint16{0, 1, 2}}},
+		FaceMaterialGroups:   [][2]uint16{{1, 0}},
+	})
+	chr.BlitSpriteDefs = append(chr.BlitSpriteDefs, &BlitSpriteDef{Tag: "FLAME_SPB", SpriteTag: "FLAME_SPRITE"})
+	chr.ParticleCloudDefs = append(chr.ParticleCloudDefs, &ParticleCloudDef{
+		Tag:                     "FIRE_PCD",
+		BlitSpriteDefTag:        "FLAME_SPB",
+		ParticleType:            1,
+		Duration:                5000,
+		Lifespan:                800,
+		SpawnVelocity:           [3]float32{0, 0, 1},
+		SpawnVelocityMultiplier: 3,
+		SpawnRate:               12,
+		Tint:                    [4]uint8{0, 128, 255, 255},
+	})
+	chr.HierarchicalSpriteDefs = append(chr.HierarchicalSpriteDefs, &HierarchicalSpriteDef{
+		Tag: "TORCH_HS_DEF",
+		Dags: []Dag{
+			{Tag: "TORCH_DAG", Track: "TORCH_TRACK", SubDags: []uint32{1}},
+			{Tag: "TORCHTI_DAG", Track: "TORCHTI_TRACK", SpriteTag: "FIRE_PCD"},
+		},
+		AttachedSkins: []AttachedSkin{{DMSpriteTag: "TORCH_DMSPRITEDEF"}},
+	})
+	chr.ActorDefs = append(chr.ActorDefs, &ActorDef{
+		Tag:     "TORCH_ACTORDEF",
+		Actions: []ActorAction{{LevelOfDetails: []ActorLevelOfDetail{{SpriteTag: "TORCH_HS_DEF"}}}},
+	})
+
+	archive, err := pfs.New("torch_chr.eqg")
+	if err != nil {
+		t.Fatalf("pfs new: %v", err)
+	}
+	converted, _, err := chr.ConvertWldToEQG()
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	if len(chr.PtsDefs) != 0 || len(chr.EmitterDefs) != 0 {
+		t.Fatalf("converting changed the wld")
+	}
+	err = converted.WriteEqgRaw(archive)
+	if err != nil {
+		t.Fatalf("write eqg: %v", err)
+	}
+	if len(converted.PtsDefs) != 1 || len(converted.PrtDefs) != 1 || len(converted.EmitterDefs) != 1 {
+		t.Fatalf("got %d pts, %d prt and %d emitters, want 1 each", len(converted.PtsDefs), len(converted.PrtDefs), len(converted.EmitterDefs))
+	}
+	point := converted.PtsDefs[0].Points[0]
+	if converted.PtsDefs[0].Tag != "torch" || point.BoneName != "torchti" || point.Name != "torchti_fire" {
+		t.Fatalf("point %s on bone %s, want torchti_fire on torchti", point.Name, point.BoneName)
+	}
+	render := converted.PrtDefs[0].Renders[0]
+	if render.ParticlePoint != point.Name || converted.EmitterByID(render.EmitterID) != converted.EmitterDefs[0] || render.Lifespan != 5000 {
+		t.Fatalf("render not played at the point with the cloud emitter")
+	}
+	emitter := converted.EmitterDefs[0]
+	if emitter.Texture != "flame.bmp" || emitter.TintStart != [3]uint32{255, 128, 0} || emitter.SpeedMax[2] != 3 || emitter.DefaultLifeSpan != 5 {
+		t.Fatalf("emitter parameters not mapped: %+v", emitter)
+	}
+	if archive.Len() != 3 {
+		t.Fatalf("archive has %d files, want mds, pts and prt", archive.Len())
+	}
+
+	err = converted.WriteEqgRaw(archive)
+	if err != nil {
+		t.Fatalf("write eqg again: %v", err)
+	}
+	if len(converted.PtsDefs) != 1 || len(converted.EmitterDefs) != 1 {
+		t.Fatalf("writing again converted the particles twice")
+	}
+}
+
+func TestConvertWldParticleActor(t *testing.T) {
+	wld := New("sparkle.wld")
+	wld.SimpleSpriteDefs = append(wld.SimpleSpriteDefs, &SimpleSpriteDef{
+		Tag:                "SPARK_SPRITE",
+		SimpleSpriteFrames: []SimpleSpriteFrame{{TextureTag: "SPARK", TextureFiles: []string{"SPARK.BMP"}}},
+	})
+	wld.BlitSpriteDefs = append(wld.BlitSpriteDefs,
+		&BlitSpriteDef{Tag: "SPARK_SPB", SpriteTag: "SPARK_SPRITE"},
+		&BlitSpriteDef{Tag: "UNUSED_SPB", SpriteTag: "SPARK_SPRITE"},
+	)
+	wld.ParticleCloudDefs = append(wld.ParticleCloudDefs, &ParticleCloudDef{
+		Tag:              "SPARKLE_PCD",
+		BlitSpriteDefTag: "SPARK_SPB",
+		Duration:         2000,
+		SpawnRate:        5,
+	})
+	wld.ActorDefs = append(wld.ActorDefs, &ActorDef{
+		Tag:     "SPARKLE_ACTORDEF",
+		Actions: []ActorAction{{LevelOfDetails: []ActorLevelOfDetail{{SpriteTag: "SPARKLE_PCD"}}}},
+	})
+
+	archive, err := pfs.New("sparkle.eqg")
+	if err != nil {
+		t.Fatalf("pfs new: %v", err)
+	}
+	converted, _, err := wld.ConvertWldToEQG()
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	err = converted.WriteEqgRaw(archive)
+	if err != nil {
+		t.Fatalf("write eqg: %v", err)
+	}
+	if len(converted.ModDefs) != 1 || converted.ModDefs[0].Tag != "sparkle" || len(converted.PtsDefs) != 1 || len(converted.EmitterDefs) != 1 {
+		t.Fatalf("got %d mods, %d pts and %d emitters, want a sparkle model with 1 point and emitter", len(converted.ModDefs), len(converted.PtsDefs), len(converted.EmitterDefs))
+	}
+	point := converted.PtsDefs[0].Points[0]
+	if point.Name != "sparkle" || point.BoneName != "" || point.Translation != [3]float32{} {
+		t.Fatalf("point %s on bone %q at %v, want sparkle at the model origin", point.Name, point.BoneName, point.Translation)
+	}
+	if len(converted.PrtDefs) != 2 || converted.PrtDefs[0].Renders[0].ParticlePoint != "sparkle" || converted.PrtDefs[1].Tag != "UNUSED_SPB" {
+		t.Fatalf("want a sparkle render and an empty render for the unused blit")
+	}
+	if issues := converted.ParticleLint(); len(issues) != 0 {
+		t.Fatalf("lint found %d issues, first %s: %s", len(issues), issues[0].File, issues[0].Reason)
+	}
+	if archive.Len() != 4 {
+		t.Fatalf("archive has %d files, want mod, pts and 2 prt", archive.Len())
+	}
+	entries := converted.OnDemandResources("sparkle.eqg")
+	if len(entries) != 1 || entries[0] != "sparkle.eqg^SPARKLE.MOD^SPARKLE_ACTORDEF^EQGM" {
+		t.Fatalf("unexpected ondemand entries %v", entries)
+	}
+
+	err = converted.WriteEqgRaw(archive)
+	if err != nil {
+		t.Fatalf("write eqg again: %v", err)
+	}
+	if len(wld.ModDefs) != 0 || len(wld.PrtDefs) != 0 {
+		t.Fatalf("converting changed the wld")
+	}
+	if len(converted.ModDefs) != 1 || len(converted.PtsDefs) != 1 || len(converted.PrtDefs) != 2 || len(converted.EmitterDefs) != 1 {
+		t.Fatalf("writing again converted the particles twice")
+	}
+}
@@ -0,0 +1,287 @@
+package wce
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ZoneMesh is a flattened world space triangle soup of a zone and its placed objects
+type ZoneMesh struct {
+	Vertices  [][3]float32
+	Triangles []*ZoneTriangle
+}
+
+// ZoneTriangle is a single face of a ZoneMesh
+type ZoneTriangle struct {
+	Index    [3]uint32
+	Passable bool   // true when the face has no collision
+	Source   string // tag of the sprite or model the face came from
+}
+
+// zoneTransform places model space vertices into the world
+type zoneTransform struct {
+	translation [3]float32
+	rotation    [3]float32 // radians, applied x then y then z
+	scale       float32
+}
+
+var zoneTransformIdentity = zoneTransform{scale: 1}
+
+func (t zoneTransform) apply(v [3]float32) [3]float32 {
+	x := float64(v[0] * t.scale)
+	y := float64(v[1] * t.scale)
+	z := float64(v[2] * t.scale)
+
+	sin, cos := math.Sincos(float64(t.rotation[0]))
+	y, z = y*cos-z*sin, y*sin+z*cos
+	sin, cos = math.Sincos(float64(t.rotation[1]))
+	x, z = x*cos+z*sin, -x*sin+z*cos
+	sin, cos = math.Sincos(float64(t.rotation[2]))
+	x, y = x*cos-y*sin, x*sin+y*cos
+
+	return [3]float32{
+		float32(x) + t.translation[0],
+		float32(y) + t.translation[1],
+		float32(z) + t.translation[2],
+	}
+}
+
+// rotate applies only the rotation of t, used for normals
+func (t zoneTransform) rotate(v [3]float32) [3]float32 {
+	return zoneTransform{rotation: t.rotation, scale: 1}.apply(v)
+}
+
+// add appends vertices transformed by t, and faces offset to the new vertices
+func (m *ZoneMesh) add(source string, t zoneTransform, vertices [][3]float32, faces [][3]uint32, passable []bool) {
+	offset := uint32(len(m.Vertices))
+	for _, v := range vertices {
+		m.Vertices = append(m.Vertices, t.apply(v))
+	}
+	for i, face := range faces {
+		m.Triangles = append(m.Triangles, &ZoneTriangle{
+			Index:    [3]uint32{face[0] + offset, face[1] + offset, face[2] + offset},
+			Passable: passable[i],
+			Source:   source,
+		})
+	}
+}
+
+func (m *ZoneMesh) addDMSpriteDef2(sprite *DMSpriteDef2, t zoneTransform) {
+	vertices := make([][3]float32, len(sprite.Vertices))
+	for i, v := range sprite.Vertices {
+		vertices[i] = [3]float32{v[0] + sprite.CenterOffset[0], v[1] + sprite.CenterOffset[1], v[2] + sprite.CenterOffset[2]}
+	}
+	faces := make([][3]uint32, 0, len(sprite.Faces))
+	passable := make([]bool, 0, len(sprite.Faces))
+	for _, face := range sprite.Faces {
+		faces = append(faces, [3]uint32{uint32(face.Triangle[0]), uint32(face.Triangle[1]), uint32(face.Triangle[2])})
+		passable = append(passable, face.Passable != 0)
+	}
+	m.add(sprite.Tag, t, vertices, faces, passable)
+}
+
+func (m *ZoneMesh) addModel(tag string, modVertices []*ModVertex, modFaces []*ModFace, t zoneTransform) {
+	vertices := make([][3]float32, len(modVertices))
+	for i, v := range modVertices {
+		vertices[i] = v.Position
+	}
+	faces := make([][3]uint32, 0, len(modFaces))
+	passable := make([]bool, 0, len(modFaces))
+	for _, face := range modFaces {
+		faces = append(faces, face.Index)
+		passable = append(passable, face.Passable != 0)
+	}
+	m.add(tag, t, vertices, faces, passable)
+}
+
+// ZoneMesh flattens the zone geometry of wce into world space.
+//
+// For s3d zones, objects is the wld holding ACTORINST placements (objects.wld) and
+// models is the wld holding the placed ACTORDEF and DMSPRITEDEF2 (<zone>_obj.s3d).
+// Either may be nil. For eqg zones, terrain and EQGZONDEF instances of wce are used.
+func (wce *Wce) ZoneMesh(objects *Wce, models *Wce) (*ZoneMesh, error) {
+	mesh := &ZoneMesh{}
+
+	if len(wce.ZonDefs) > 0 || len(wce.TerDefs) > 0 {
+		err := wce.zoneMeshEqg(mesh)
+		if err != nil {
+			return nil, fmt.Errorf("eqg: %w", err)
+		}
+		return mesh, nil
+	}
+
+	sprites := map[string]*DMSpriteDef2{}
+	for _, sprite := range wce.DMSpriteDef2s {
+		sprites[sprite.Tag] = sprite
+	}
+	isRegionSprite := false
+	for _, region := range wce.Regions {
+		if !region.SpriteTag.Valid || region.SpriteTag.String == "" {
+			continue
+		}
+		sprite, ok := sprites[region.SpriteTag.String]
+		if !ok {
+			return nil, fmt.Errorf("region %s sprite %s not found", region.Tag, region.SpriteTag.String)
+		}
+		mesh.addDMSpriteDef2(sprite, zoneTransformIdentity)
+		isRegionSprite = true
+	}
+	if !isRegionSprite {
+		for _, sprite := range wce.DMSpriteDef2s {
+			mesh.addDMSpriteDef2(sprite, zoneTransformIdentity)
+		}
+	}
+
+	if objects == nil {
+		return mesh, nil
+	}
+	if models == nil {
+		models = objects
+	}
+
+	for _, inst := range objects.ActorInsts {
+		sprite := models.actorSprite(inst.DefinitionTag)
+		if sprite == nil {
+			// placements of hierarchical or missing actors have no static geometry
+			continue
+		}
+		mesh.addDMSpriteDef2(sprite, actorInstTransform(inst))
+	}
+
+	return mesh, nil
+}
+
+// actorInstTransform returns the placement of an actor instance
+func actorInstTransform(inst *ActorInst) zoneTransform {
+	t := zoneTransform{scale: 1}
+	if inst.Scale.Valid && inst.Scale.Float32 != 0 {
+		t.scale = inst.Scale.Float32
+	}
+	if inst.Location.Valid {
+		loc := inst.Location.Float32Slice6
+		t.translation = [3]float32{loc[0], loc[1], loc[2]}
+		// wld rotations are in 1/512 of a circle
+		for i := 0; i < 3; i++ {
+			t.rotation[i] = loc[3+i] / 512 * 2 * math.Pi
+		}
+	}
+	return t
+}
+
+// actorSprite returns the first level of detail DMSPRITEDEF2 of an actordef tag, or nil
+func (wce *Wce) actorSprite(tag string) *DMSpriteDef2 {
+	for _, actor := range wce.ActorDefs {
+		if !strings.EqualFold(actor.Tag, tag) {
+			continue
+		}
+		for _, action := range actor.Actions {
+			for _, lod := range action.LevelOfDetails {
+				sprite, ok := wce.ByTag(lod.SpriteTag).(*DMSpriteDef2)
+				if ok {
+					return sprite
+				}
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+func (wce *Wce) zoneMeshEqg(mesh *ZoneMesh) error {
+	mods := map[string]*EqgModDef{}
+	for _, mod := range wce.ModDefs {
+		mods[strings.ToLower(mod.Tag)] = mod
+	}
+	ters := map[string]*EqgTerDef{}
+	for _, ter := range wce.TerDefs {
+		ters[strings.ToLower(ter.Tag)] = ter
+	}
+
+	placedTers := map[string]bool{}
+	for _, zon := range wce.ZonDefs {
+		for _, inst := range zon.Instances {
+			t := eqgInstanceTransform(inst)
+			name := eqgModelName(inst.ModelTag)
+			mod, ok := mods[name]
+			if ok {
+				mesh.addModel(mod.Tag, mod.Vertices, mod.Faces, t)
+				continue
+			}
+			ter, ok := ters[name]
+			if ok {
+				mesh.addModel(ter.Tag, ter.Vertices, ter.Faces, t)
+				placedTers[name] = true
+				continue
+			}
+			// models outside of this archive have no geometry to add
+		}
+	}
+	for _, ter := range wce.TerDefs {
+		if placedTers[strings.ToLower(ter.Tag)] {
+			continue
+		}
+		mesh.addModel(ter.Tag, ter.Vertices, ter.Faces, zoneTransformIdentity)
+	}
+
+	for i, v := range mesh.Vertices {
+		mesh.Vertices[i] = EqgToServerAxis(v)
+	}
+	return nil
+}
+
+// EqgToServerAxis converts an eqg space position to s3d and server space, which has x and y swapped
+func EqgToServerAxis(v [3]float32) [3]float32 {
+	return [3]float32{v[1], v[0], v[2]}
+}
+
+// Bounds returns the minimum and maximum corners of every vertex in the mesh
+func (m *ZoneMesh) Bounds() ([3]float32, [3]float32) {
+	if len(m.Vertices) == 0 {
+		return [3]float32{}, [3]float32{}
+	}
+	min := m.Vertices[0]
+	max := m.Vertices[0]
+	for _, v := range m.Vertices[1:] {
+		for i := 0; i < 3; i++ {
+			if v[i] < min[i] {
+				min[i] = v[i]
+			}
+			if v[i] > max[i] {
+				max[i] = v[i]
+			}
+		}
+	}
+	return min, max
+}
+
+// Slice returns the line segments where collidable triangles cross the horizontal plane at z
+func (m *ZoneMesh) Slice(z float32) [][2][3]float32 {
+	segments := [][2][3]float32{}
+	for _, tri := range m.Triangles {
+		if tri.Passable {
+			continue
+		}
+		points := [][3]float32{}
+		for i := 0; i < 3; i++ {
+			a := m.Vertices[tri.Index[i]]
+			b := m.Vertices[tri.Index[(i+1)%3]]
+			da := a[2] - z
+			db := b[2] - z
+			if (da < 0) == (db < 0) {
+				continue
+			}
+			t := da / (da - db)
+			points = append(points, [3]float32{
+				a[0] + (b[0]-a[0])*t,
+				a[1] + (b[1]-a[1])*t,
+				z,
+			})
+		}
+		if len(points) != 2 || points[0] == points[1] {
+			continue
+		}
+		segments = append(segments, [2][3]float32{points[0], points[1]})
+	}
+	return segments
+}
@@ -0,0 +1,74 @@
+package wce
+
+import (
+	"math"
+	"testing"
+)
+
+func TestZoneMesh(t *testing.T) {
+	box := &DMSpriteDef2{
+		Tag:          "BOX_DMSPRITEDEF",
+		CenterOffset: [3]float32{0, 0, 1},
+		Vertices:     [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+		Faces:        []*Face{{Triangle: [3]uint16{0, 1, 2}}, {Passable: 1, Triangle: [3]uint16{2, 1, 0}}},
+	}
+
+	zone := New("test")
+	zone.DMSpriteDef2s = append(zone.DMSpriteDef2s, box)
+
+	models := New("test_obj")
+	models.DMSpriteDef2s = append(models.DMSpriteDef2s, box)
+	models.ActorDefs = append(models.ActorDefs, &ActorDef{
+		Tag:     "BOX_ACTORDEF",
+		Actions: []ActorAction{{LevelOfDetails: []ActorLevelOfDetail{{SpriteTag: box.Tag}}}},
+	})
+
+	objects := New("objects")
+	inst := &ActorInst{DefinitionTag: "BOX_ACTORDEF"}
+	inst.Location.Valid = true
+	// 128/512 of a circle is 90 degrees about z
+	inst.Location.Float32Slice6 = [6]float32{10, 20, 30, 0, 0, 128}
+	objects.ActorInsts = append(objects.ActorInsts, inst)
+
+	mesh, err := zone.ZoneMesh(objects, models)
+	if err != nil {
+		t.Fatalf("zone mesh: %s", err)
+	}
+	if len(mesh.Vertices) != 6 || len(mesh.Triangles) != 4 {
+		t.Fatalf("got %d vertices %d triangles, want 6 and 4", len(mesh.Vertices), len(mesh.Triangles))
+	}
+	if mesh.Triangles[0].Passable || !mesh.Triangles[1].Passable {
+		t.Fatalf("passable flags not carried")
+	}
+
+	// vertex 1 (1,0,1) rotated 90 degrees about z becomes (0,1,1), then translated
+	got := mesh.Vertices[4]
+	want := [3]float32{10, 21, 31}
+	for i := range got {
+		if math.Abs(float64(got[i]-want[i])) > 0.001 {
+			t.Fatalf("placed vertex got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestZoneMeshSlice(t *testing.T) {
+	mesh := &ZoneMesh{
+		Vertices: [][3]float32{{0, 0, 0}, {10, 0, 10}, {0, 10, 10}},
+		Triangles: []*ZoneTriangle{
+			{Index: [3]uint32{0, 1, 2}},
+			{Index: [3]uint32{2, 1, 0}, Passable: true},
+		},
+	}
+	segments := mesh.Slice(5)
+	if len(segments) != 1 {
+		t.Fatalf("got %d segments, want 1", len(segments))
+	}
+	for _, point := range segments[0] {
+		if point[2] != 5 || point[0]+point[1] != 5 {
+			t.Fatalf("unexpected point %v", point)
+		}
+	}
+	if len(mesh.Slice(20)) != 0 {
+		t.Fatalf("expected no segments above mesh")
+	}
+}
@@ -0,0 +1,321 @@
+package wce
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ZoneKind is the primary behavior of a zone (BSP region group) in an s3d zone
+type ZoneKind int
+
+const (
+	ZoneKindNormal ZoneKind = iota
+	ZoneKindWater
+	ZoneKindLava
+	ZoneKindZoneline
+	ZoneKindPvP
+	ZoneKindSlippery
+	ZoneKindSlime
+	ZoneKindFreezingWater
+	ZoneKindUnknown
+)
+
+// String returns a readable name of the zone kind
+func (k ZoneKind) String() string {
+	switch k {
+	case ZoneKindNormal:
+		return "normal"
+	case ZoneKindWater:
+		return "water"
+	case ZoneKindLava:
+		return "lava"
+	case ZoneKindZoneline:
+		return "zoneline"
+	case ZoneKindPvP:
+		return "pvp"
+	case ZoneKindSlippery:
+		return "slippery"
+	case ZoneKindSlime:
+		return "slime"
+	case ZoneKindFreezingWater:
+		return "freezingwater"
+	default:
+		return "unknown"
+	}
+}
+
+// ZoneFlag is a bitmask of every behavior encoded in a zone tag, e.g. WTNTP is water and zoneline
+type ZoneFlag uint32
+
+const (
+	ZoneFlagWater ZoneFlag = 1 << iota
+	ZoneFlagLava
+	ZoneFlagZoneline
+	ZoneFlagPvP
+	ZoneFlagSlippery
+	ZoneFlagSlime
+	ZoneFlagFreezingWater
+)
+
+// String returns a pipe separated list of flags
+func (f ZoneFlag) String() string {
+	names := []string{}
+	if f&ZoneFlagWater != 0 {
+		names = append(names, "WATER")
+	}
+	if f&ZoneFlagLava != 0 {
+		names = append(names, "LAVA")
+	}
+	if f&ZoneFlagZoneline != 0 {
+		names = append(names, "ZONELINE")
+	}
+	if f&ZoneFlagPvP != 0 {
+		names = append(names, "PVP")
+	}
+	if f&ZoneFlagSlippery != 0 {
+		names = append(names, "SLIPPERY")
+	}
+	if f&ZoneFlagSlime != 0 {
+		names = append(names, "SLIME")
+	}
+	if f&ZoneFlagFreezingWater != 0 {
+		names = append(names, "FREEZINGWATER")
+	}
+	if len(names) == 0 {
+		return "NONE"
+	}
+	return strings.Join(names, "|")
+}
+
+// zonelineReferenceZoneID is the zone id used when a zoneline points to a server side zone_points index
+const zonelineReferenceZoneID = 255
+
+// Zoneline is the decoded target of a DRNTP/WTNTP/LANTP zone
+type Zoneline struct {
+	IsReference bool // true when Index points to a server zone_points entry
+	Index       int
+	ZoneID      int
+	Position    [3]float32
+	Heading     int
+}
+
+// ZoneType is the typed representation of a zone tag or userdata string
+type ZoneType struct {
+	Kind     ZoneKind
+	Flags    ZoneFlag
+	Zoneline *Zoneline
+}
+
+// ZoneTypeDecode parses a zone tag (e.g. WT_ZONE, DRNTP00010-00100000200000030256_ZONE) into a ZoneType
+func ZoneTypeDecode(tag string) (*ZoneType, error) {
+	name := strings.ToLower(baseTag(tag))
+	zt := &ZoneType{}
+
+	switch {
+	case strings.HasPrefix(name, "wtntp"):
+		zt.Kind = ZoneKindZoneline
+		zt.Flags = ZoneFlagWater | ZoneFlagZoneline
+	case strings.HasPrefix(name, "lantp"):
+		zt.Kind = ZoneKindZoneline
+		zt.Flags = ZoneFlagLava | ZoneFlagZoneline
+	case strings.HasPrefix(name, "drntp"):
+		zt.Kind = ZoneKindZoneline
+		zt.Flags = ZoneFlagZoneline
+	case strings.HasPrefix(name, "wtn_"), strings.HasPrefix(name, "wt_"):
+		zt.Kind = ZoneKindWater
+		zt.Flags = ZoneFlagWater
+	case strings.HasPrefix(name, "lan_"), strings.HasPrefix(name, "la_"):
+		zt.Kind = ZoneKindLava
+		zt.Flags = ZoneFlagLava
+	case strings.HasPrefix(name, "drp_"):
+		zt.Kind = ZoneKindPvP
+		zt.Flags = ZoneFlagPvP
+	case strings.HasPrefix(name, "drn_"):
+		if !strings.Contains(name, "_s_") {
+			zt.Kind = ZoneKindUnknown
+			return zt, nil
+		}
+		zt.Kind = ZoneKindSlippery
+		zt.Flags = ZoneFlagSlippery
+	case strings.HasPrefix(name, "sln_"):
+		zt.Kind = ZoneKindSlime
+		zt.Flags = ZoneFlagSlime
+	case strings.HasPrefix(name, "vwn_"):
+		zt.Kind = ZoneKindFreezingWater
+		zt.Flags = ZoneFlagFreezingWater
+	default:
+		zt.Kind = ZoneKindNormal
+		return zt, nil
+	}
+
+	if zt.Flags&ZoneFlagZoneline == 0 {
+		return zt, nil
+	}
+
+	zoneline, err := zonelineDecode(name)
+	if err != nil {
+		return nil, fmt.Errorf("zoneline %s: %w", tag, err)
+	}
+	zt.Zoneline = zoneline
+	return zt, nil
+}
+
+// zonelineDecode parses the fixed width zoneline fields after the 5 character prefix
+func zonelineDecode(name string) (*Zoneline, error) {
+	name = strings.TrimSuffix(name, "_zone")
+	if len(name) <= 5 || name[5] == '_' {
+		return &Zoneline{IsReference: true}, nil
+	}
+
+	field := func(start int, size int) (int, error) {
+		if len(name) < start+size {
+			return 0, fmt.Errorf("offset %d size %d exceeds length %d", start, size, len(name))
+		}
+		val, err := strconv.Atoi(name[start : start+size])
+		if err != nil {
+			return 0, fmt.Errorf("offset %d: %w", start, err)
+		}
+		return val, nil
+	}
+
+	zoneID, err := field(5, 5)
+	if err != nil {
+		return nil, fmt.Errorf("zone id: %w", err)
+	}
+	if zoneID == zonelineReferenceZoneID {
+		index, err := field(10, 6)
+		if err != nil {
+			return nil, fmt.Errorf("index: %w", err)
+		}
+		return &Zoneline{IsReference: true, Index: index, ZoneID: zoneID}, nil
+	}
+
+	zoneline := &Zoneline{ZoneID: zoneID}
+	for i := 0; i < 3; i++ {
+		val, err := field(10+i*6, 6)
+		if err != nil {
+			return nil, fmt.Errorf("position %d: %w", i, err)
+		}
+		zoneline.Position[i] = float32(val)
+	}
+	zoneline.Heading, err = field(28, 3)
+	if err != nil {
+		return nil, fmt.Errorf("heading: %w", err)
+	}
+	return zoneline, nil
+}
+
+// Encode returns the zone tag representing the zone type
+func (zt *ZoneType) Encode() (string, error) {
+	if zt == nil {
+		return "", fmt.Errorf("zone type is nil")
+	}
+
+	if zt.Flags&ZoneFlagZoneline != 0 {
+		prefix := "DRNTP"
+		switch zt.Flags &^ ZoneFlagZoneline {
+		case 0:
+		case ZoneFlagWater:
+			prefix = "WTNTP"
+		case ZoneFlagLava:
+			prefix = "LANTP"
+		default:
+			return "", fmt.Errorf("zoneline can not be combined with %s", zt.Flags&^ZoneFlagZoneline)
+		}
+		if zt.Zoneline == nil {
+			return "", fmt.Errorf("zoneline flag set without zoneline data")
+		}
+		zoneline, err := zt.Zoneline.encode()
+		if err != nil {
+			return "", fmt.Errorf("zoneline: %w", err)
+		}
+		return prefix + zoneline + "_ZONE", nil
+	}
+
+	switch zt.Flags {
+	case 0:
+		if zt.Kind != ZoneKindNormal {
+			return "", fmt.Errorf("kind %s has no flags", zt.Kind)
+		}
+		return "", nil
+	case ZoneFlagWater:
+		return "WT_ZONE", nil
+	case ZoneFlagLava:
+		return "LA_ZONE", nil
+	case ZoneFlagPvP:
+		return "DRP_ZONE", nil
+	case ZoneFlagSlippery:
+		return "DRN_S_ZONE", nil
+	case ZoneFlagSlime:
+		return "SLN_ZONE", nil
+	case ZoneFlagFreezingWater:
+		return "VWN_ZONE", nil
+	}
+	return "", fmt.Errorf("unsupported flag combination %s", zt.Flags)
+}
+
+// encode returns the fixed width zoneline fields, or an error when a value does not fit its field
+func (z *Zoneline) encode() (string, error) {
+	// a field of n digits holds -(10^(n-1)-1) through 10^n-1, as a minus sign takes a digit
+	fits := func(name string, val int, size int) error {
+		max := int(math.Pow10(size)) - 1
+		min := -(int(math.Pow10(size-1)) - 1)
+		if val < min || val > max {
+			return fmt.Errorf("%s %d is outside %d to %d", name, val, min, max)
+		}
+		return nil
+	}
+
+	if z.IsReference {
+		err := fits("index", z.Index, 6)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%05d%06d%06d%06d%03d", zonelineReferenceZoneID, z.Index, 0, 0, 0), nil
+	}
+
+	if z.ZoneID < 0 || z.ZoneID > 99999 {
+		return "", fmt.Errorf("zone id %d is outside 0 to 99999", z.ZoneID)
+	}
+	if z.ZoneID == zonelineReferenceZoneID {
+		return "", fmt.Errorf("zone id %d is reserved for zone_points references", z.ZoneID)
+	}
+	for i, pos := range z.Position {
+		err := fits(fmt.Sprintf("position %d", i), int(pos), 6)
+		if err != nil {
+			return "", err
+		}
+	}
+	err := fits("heading", z.Heading, 3)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%05d%06d%06d%06d%03d", z.ZoneID, int(z.Position[0]), int(z.Position[1]), int(z.Position[2]), z.Heading), nil
+}
+
+// ZoneType decodes the zone tag, falling back to userdata when the tag carries no type
+func (e *Zone) ZoneType() (*ZoneType, error) {
+	zt, err := ZoneTypeDecode(e.Tag)
+	if err != nil {
+		return nil, err
+	}
+	if zt.Kind != ZoneKindNormal || e.UserData == "" {
+		return zt, nil
+	}
+	return ZoneTypeDecode(e.UserData)
+}
+
+// SetZoneType encodes zt into the zone tag
+func (e *Zone) SetZoneType(zt *ZoneType) error {
+	tag, err := zt.Encode()
+	if err != nil {
+		return err
+	}
+	if tag == "" {
+		return fmt.Errorf("normal zones have no type tag")
+	}
+	e.Tag = tag
+	return nil
+}
@@ -0,0 +1,108 @@
+package wce
+
+import (
+	"testing"
+)
+
+func TestZoneTypeDecode(t *testing.T) {
+	tests := []struct {
+		name      string
+		tag       string
+		wantKind  ZoneKind
+		wantFlags ZoneFlag
+		zoneline  *Zoneline
+	}{
+		{name: "water", tag: "WT_ZONE", wantKind: ZoneKindWater, wantFlags: ZoneFlagWater},
+		{name: "water new", tag: "WTN__01521000000000000000000000___000000000000", wantKind: ZoneKindWater, wantFlags: ZoneFlagWater},
+		{name: "lava", tag: "LA_ZONE", wantKind: ZoneKindLava, wantFlags: ZoneFlagLava},
+		{name: "pvp", tag: "DRP_ZONE", wantKind: ZoneKindPvP, wantFlags: ZoneFlagPvP},
+		{name: "slippery", tag: "DRN__S_ZONE", wantKind: ZoneKindSlippery, wantFlags: ZoneFlagSlippery},
+		{name: "slime", tag: "SLN_ZONE", wantKind: ZoneKindSlime, wantFlags: ZoneFlagSlime},
+		{name: "freezing", tag: "VWN_ZONE", wantKind: ZoneKindFreezingWater, wantFlags: ZoneFlagFreezingWater},
+		{name: "normal", tag: "R1_ZONE", wantKind: ZoneKindNormal},
+		{name: "indexed", tag: "WT_ZONE.001", wantKind: ZoneKindWater, wantFlags: ZoneFlagWater},
+		{name: "zoneline reference", tag: "DRNTP_ZONE", wantKind: ZoneKindZoneline, wantFlags: ZoneFlagZoneline, zoneline: &Zoneline{IsReference: true}},
+		{name: "zoneline index", tag: "DRNTP00255000012000000000000000_ZONE", wantKind: ZoneKindZoneline, wantFlags: ZoneFlagZoneline, zoneline: &Zoneline{IsReference: true, Index: 12, ZoneID: 255}},
+		{name: "zoneline absolute", tag: "DRNTP00010-00100000200000030256_ZONE", wantKind: ZoneKindZoneline, wantFlags: ZoneFlagZoneline, zoneline: &Zoneline{ZoneID: 10, Position: [3]float32{-100, 200, 30}, Heading: 256}},
+		{name: "water zoneline", tag: "WTNTP00010000001000002000003064_ZONE", wantKind: ZoneKindZoneline, wantFlags: ZoneFlagWater | ZoneFlagZoneline, zoneline: &Zoneline{ZoneID: 10, Position: [3]float32{1, 2, 3}, Heading: 64}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zt, err := ZoneTypeDecode(tt.tag)
+			if err != nil {
+				t.Fatalf("decode %s: %s", tt.tag, err)
+			}
+			if zt.Kind != tt.wantKind {
+				t.Fatalf("kind got %s, want %s", zt.Kind, tt.wantKind)
+			}
+			if zt.Flags != tt.wantFlags {
+				t.Fatalf("flags got %s, want %s", zt.Flags, tt.wantFlags)
+			}
+			if tt.zoneline == nil {
+				if zt.Zoneline != nil {
+					t.Fatalf("unexpected zoneline %+v", zt.Zoneline)
+				}
+				return
+			}
+			if zt.Zoneline == nil {
+				t.Fatalf("zoneline missing")
+			}
+			if *zt.Zoneline != *tt.zoneline {
+				t.Fatalf("zoneline got %+v, want %+v", zt.Zoneline, tt.zoneline)
+			}
+		})
+	}
+}
+
+func TestZoneTypeEncode(t *testing.T) {
+	tests := []*ZoneType{
+		{Kind: ZoneKindWater, Flags: ZoneFlagWater},
+		{Kind: ZoneKindLava, Flags: ZoneFlagLava},
+		{Kind: ZoneKindPvP, Flags: ZoneFlagPvP},
+		{Kind: ZoneKindSlippery, Flags: ZoneFlagSlippery},
+		{Kind: ZoneKindSlime, Flags: ZoneFlagSlime},
+		{Kind: ZoneKindFreezingWater, Flags: ZoneFlagFreezingWater},
+		{Kind: ZoneKindZoneline, Flags: ZoneFlagZoneline, Zoneline: &Zoneline{IsReference: true, Index: 3, ZoneID: 255}},
+		{Kind: ZoneKindZoneline, Flags: ZoneFlagZoneline, Zoneline: &Zoneline{ZoneID: 54, Position: [3]float32{-1500, 25, -3}, Heading: 128}},
+		{Kind: ZoneKindZoneline, Flags: ZoneFlagLava | ZoneFlagZoneline, Zoneline: &Zoneline{ZoneID: 31, Position: [3]float32{10, 20, 30}, Heading: 0}},
+	}
+	for _, want := range tests {
+		t.Run(want.Kind.String(), func(t *testing.T) {
+			tag, err := want.Encode()
+			if err != nil {
+				t.Fatalf("encode: %s", err)
+			}
+			zone := &Zone{Tag: tag}
+			got, err := zone.ZoneType()
+			if err != nil {
+				t.Fatalf("decode %s: %s", tag, err)
+			}
+			if got.Kind != want.Kind || got.Flags != want.Flags {
+				t.Fatalf("%s: got %s/%s, want %s/%s", tag, got.Kind, got.Flags, want.Kind, want.Flags)
+			}
+			if want.Zoneline != nil && *got.Zoneline != *want.Zoneline {
+				t.Fatalf("%s: zoneline got %+v, want %+v", tag, got.Zoneline, want.Zoneline)
+			}
+		})
+	}
+
+	_, err := (&ZoneType{Flags: ZoneFlagWater | ZoneFlagPvP}).Encode()
+	if err == nil {
+		t.Fatalf("expected error for unsupported flag combination")
+	}
+
+	overflows := []*Zoneline{
+		{ZoneID: 100000},
+		{ZoneID: 54, Position: [3]float32{1000000, 0, 0}},
+		{ZoneID: 54, Position: [3]float32{0, -100000, 0}},
+		{ZoneID: 54, Heading: 1000},
+		{ZoneID: 54, Heading: -100},
+		{IsReference: true, Index: 1000000},
+	}
+	for _, zoneline := range overflows {
+		_, err = (&ZoneType{Kind: ZoneKindZoneline, Flags: ZoneFlagZoneline, Zoneline: zoneline}).Encode()
+		if err == nil {
+			t.Fatalf("expected error for zoneline %+v overflowing its fields", zoneline)
+		}
+	}
+}